@@ -0,0 +1,351 @@
+package check_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("amqpcheck", func() {
+	var (
+		broker *fakeBroker
+		logger *gbytes.Buffer
+		url    string
+	)
+
+	BeforeEach(func() {
+		broker = newFakeBroker()
+		logger = gbytes.NewBuffer()
+		url = fmt.Sprintf("amqp://app:secret@%s/orders", broker.addr())
+	})
+
+	JustBeforeEach(func() {
+		broker.start()
+	})
+
+	AfterEach(func() {
+		broker.close()
+	})
+
+	It("performs the connection handshake", func() {
+		Expect(check.AMQP(url).WithLogger(logger).IsReady()).To(BeTrue())
+		Expect(broker.login()).To(Equal([]string{"PLAIN", "\x00app\x00secret", "orders"}))
+		Expect(logger).To(gbytes.Say("Connecting to amqp://app@" + broker.addr() + "/orders"))
+	})
+
+	It("defaults to guest and the vhost '/'", func() {
+		broker.password = "guest"
+		broker.vhosts = []string{"/"}
+		Expect(check.AMQP("amqp://" + broker.addr()).WithLogger(logger).IsReady()).To(BeTrue())
+		Expect(broker.login()).To(Equal([]string{"PLAIN", "\x00guest\x00guest", "/"}))
+	})
+
+	It("decodes the vhost", func() {
+		broker.vhosts = []string{"/"}
+		Expect(check.AMQP("amqp://app:secret@" + broker.addr() + "/%2f").WithLogger(logger).IsReady()).To(BeTrue())
+		Expect(broker.login()[2]).To(Equal("/"))
+	})
+
+	It("fails if the password is wrong", func() {
+		url = strings.Replace(url, "secret", "wrong", 1)
+		Expect(check.AMQP(url).WithLogger(logger).IsReady()).To(BeFalse())
+		Expect(logger).To(gbytes.Say("403 ACCESS_REFUSED - Login was refused using authentication mechanism PLAIN"))
+	})
+
+	It("fails if the vhost does not exist", func() {
+		url = strings.Replace(url, "/orders", "/billing", 1)
+		Expect(check.AMQP(url).WithLogger(logger).IsReady()).To(BeFalse())
+		Expect(logger).To(gbytes.Say("530 NOT_ALLOWED - vhost billing not found"))
+	})
+
+	It("fails if the URL is invalid", func() {
+		Expect(check.AMQP("http://" + broker.addr()).WithLogger(logger).IsReady()).To(BeFalse())
+		Expect(logger).To(gbytes.Say("invalid URL: unsupported scheme 'http'"))
+	})
+
+	Context("when the broker speaks a different protocol version", func() {
+		BeforeEach(func() {
+			broker.reject = true
+		})
+
+		It("returns false", func() {
+			Expect(check.AMQP(url).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("broker does not support AMQP 0-9-1"))
+		})
+	})
+
+	Context("when the broker sends a frame that is too large", func() {
+		BeforeEach(func() {
+			broker.oversized = true
+		})
+
+		It("returns false", func() {
+			Expect(check.AMQP(url).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("frame of 4294967295 bytes exceeds the limit of 1 MiB"))
+		})
+	})
+
+	Context("when the broker does not answer", func() {
+		BeforeEach(func() {
+			broker.hang = true
+		})
+
+		It("times out", func() {
+			Expect(check.AMQP(url).WithTimeout(50 * time.Millisecond).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("i/o timeout"))
+		})
+	})
+
+	Describe(".WithQueue and .WithExchange", func() {
+		It("declare the queue and exchange passively", func() {
+			Expect(check.AMQP(url).WithQueue("jobs").WithExchange("events").WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(broker.declarations()).To(Equal([]string{"queue jobs passive", "exchange events passive"}))
+		})
+
+		It("fail if the queue does not exist", func() {
+			Expect(check.AMQP(url).WithQueue("missing").WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("404 NOT_FOUND - no queue 'missing' in vhost 'orders'"))
+		})
+
+		It("fail if the exchange does not exist", func() {
+			Expect(check.AMQP(url).WithExchange("missing").WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("404 NOT_FOUND - no exchange 'missing' in vhost 'orders'"))
+		})
+	})
+})
+
+// fakeBroker implements the broker side of the AMQP 0-9-1 methods used by the
+// check. Its settings must be changed before it is started.
+type fakeBroker struct {
+	listener net.Listener
+
+	password  string
+	vhosts    []string
+	reject    bool
+	oversized bool
+	hang      bool
+
+	mutex    sync.Mutex
+	received []string
+	declared []string
+}
+
+func newFakeBroker() *fakeBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	return &fakeBroker{listener: listener, password: "secret", vhosts: []string{"orders"}}
+}
+
+func (b *fakeBroker) start() {
+	go func() {
+		for {
+			conn, err := b.listener.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+}
+
+func (b *fakeBroker) addr() string {
+	return b.listener.Addr().String()
+}
+
+func (b *fakeBroker) close() {
+	b.listener.Close()
+}
+
+func (b *fakeBroker) login() []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.received
+}
+
+func (b *fakeBroker) declarations() []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.declared
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	defer GinkgoRecover()
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return
+	}
+	Expect(string(header)).To(Equal("AMQP\x00\x00\x09\x01"))
+
+	if b.hang {
+		time.Sleep(time.Second)
+		return
+	}
+
+	if b.reject {
+		conn.Write([]byte("AMQP\x00\x01\x00\x00"))
+		return
+	}
+
+	if b.oversized {
+		conn.Write([]byte{1, 0, 0, 0xff, 0xff, 0xff, 0xff})
+		return
+	}
+
+	send := func(channel uint16, class, method uint16, args []byte) {
+		payload := make([]byte, 4)
+		binary.BigEndian.PutUint16(payload, class)
+		binary.BigEndian.PutUint16(payload[2:], method)
+		payload = append(payload, args...)
+
+		frame := make([]byte, 7)
+		frame[0] = 1
+		binary.BigEndian.PutUint16(frame[1:], channel)
+		binary.BigEndian.PutUint32(frame[3:], uint32(len(payload)))
+		conn.Write(append(append(frame, payload...), 0xce))
+	}
+
+	receive := func() (uint16, uint16, *bytes.Buffer) {
+		frame := make([]byte, 7)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return 0, 0, nil
+		}
+
+		payload := make([]byte, binary.BigEndian.Uint32(frame[3:])+1)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return 0, 0, nil
+		}
+
+		return binary.BigEndian.Uint16(payload), binary.BigEndian.Uint16(payload[2:]), bytes.NewBuffer(payload[4 : len(payload)-1])
+	}
+
+	shortString := func(buf *bytes.Buffer) string {
+		n, _ := buf.ReadByte()
+		return string(buf.Next(int(n)))
+	}
+
+	longString := func(buf *bytes.Buffer) string {
+		var n uint32
+		binary.Read(buf, binary.BigEndian, &n)
+		return string(buf.Next(int(n)))
+	}
+
+	closeWith := func(channel uint16, class uint16, code uint16, text string) {
+		args := make([]byte, 2)
+		binary.BigEndian.PutUint16(args, code)
+		args = append(args, byte(len(text)))
+		args = append(args, text...)
+		args = append(args, 0, 0, 0, 0)
+		send(channel, class, map[uint16]uint16{10: 50, 20: 40}[class], args)
+	}
+
+	// connection.start: version 0-9, no server properties, mechanisms, locales
+	start := []byte{0, 9, 0, 0, 0, 0}
+	start = append(start, 0, 0, 0, 14)
+	start = append(start, "AMQPLAIN PLAIN"...)
+	start = append(start, 0, 0, 0, 5)
+	start = append(start, "en_US"...)
+	send(0, 10, 10, start)
+
+	class, method, args := receive()
+	Expect([]uint16{class, method}).To(Equal([]uint16{10, 11}))
+
+	var size uint32
+	binary.Read(args, binary.BigEndian, &size)
+	properties := string(args.Next(int(size)))
+	Expect(properties).To(ContainSubstring("authentication_failure_close"))
+
+	mechanism := shortString(args)
+	response := longString(args)
+
+	if response != "\x00app\x00"+b.password && response != "\x00guest\x00"+b.password {
+		closeWith(0, 10, 403, "ACCESS_REFUSED - Login was refused using authentication mechanism PLAIN.")
+		return
+	}
+
+	// connection.tune: channel max, frame max, heartbeat
+	send(0, 10, 30, []byte{0x07, 0xff, 0, 2, 0, 0, 0, 60})
+
+	class, method, args = receive()
+	Expect([]uint16{class, method}).To(Equal([]uint16{10, 31}))
+	Expect(args.Bytes()).To(Equal([]byte{0x07, 0xff, 0, 2, 0, 0, 0, 0}))
+
+	class, method, args = receive()
+	Expect([]uint16{class, method}).To(Equal([]uint16{10, 40}))
+	vhost := shortString(args)
+
+	b.mutex.Lock()
+	b.received = []string{mechanism, response, vhost}
+	b.mutex.Unlock()
+
+	known := false
+	for _, v := range b.vhosts {
+		known = known || v == vhost
+	}
+
+	if !known {
+		closeWith(0, 10, 530, fmt.Sprintf("NOT_ALLOWED - vhost %s not found", vhost))
+		return
+	}
+
+	send(0, 10, 41, []byte{0})
+
+	for {
+		class, method, args := receive()
+		if args == nil {
+			return
+		}
+
+		switch {
+		case class == 10 && method == 50:
+			send(0, 10, 51, nil)
+			return
+		case class == 20 && method == 10:
+			send(1, 20, 11, []byte{0, 0, 0, 0})
+		case class == 50 && method == 10, class == 40 && method == 10:
+			kind := map[uint16]string{40: "exchange", 50: "queue"}[class]
+
+			args.Next(2)
+			name := shortString(args)
+			if class == 40 {
+				shortString(args)
+			}
+
+			flags, _ := args.ReadByte()
+			if flags&0x01 == 0 {
+				Fail("declaration is not passive")
+			}
+
+			if name == "missing" {
+				closeWith(1, 20, 404, fmt.Sprintf("NOT_FOUND - no %s '%s' in vhost '%s'", kind, name, vhost))
+				continue
+			}
+
+			b.mutex.Lock()
+			b.declared = append(b.declared, kind+" "+name+" passive")
+			b.mutex.Unlock()
+
+			if class == 50 {
+				send(1, 50, 11, append([]byte{byte(len(name))}, append([]byte(name), 0, 0, 0, 0, 0, 0, 0, 0)...))
+			} else {
+				send(1, 40, 11, nil)
+			}
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package check
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+type BannerCheck interface {
+	IsReady() bool
+
+	WithFingerprint(string) BannerCheck
+	WithTimeout(time.Duration) BannerCheck
+	WithLogger(io.Writer) BannerCheck
+}
+
+type bannercheck struct {
+	protocol    string
+	addr        string
+	fingerprint string
+	timeout     time.Duration
+	logger      io.Writer
+}
+
+type bannerProtocol struct {
+	port  int
+	greet func(*bannercheck, net.Conn) error
+}
+
+var bannerProtocols = map[string]bannerProtocol{
+	"smtp": {25, (*bannercheck).smtp},
+	"ssh":  {22, (*bannercheck).ssh},
+	"ftp":  {21, (*bannercheck).ftp},
+}
+
+// BannerProtocols returns the names of the protocols supported by Banner in
+// alphabetical order.
+func BannerProtocols() []string {
+	var names []string
+	for name := range bannerProtocols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Banner checks whether the server at the given address, 'host[:port]',
+// greets its clients the way the given protocol, 'smtp', 'ssh' or 'ftp',
+// requires. The port defaults to the well-known port of the protocol.
+func Banner(protocol, addr string) BannerCheck {
+	return &bannercheck{
+		protocol: protocol,
+		addr:     addr,
+		timeout:  DefaultTimeout,
+		logger:   DefaultLogger,
+	}
+}
+
+// WithFingerprint requires an SSH server to present the host key with the
+// given fingerprint, e.g. 'SHA256:...' as printed by 'ssh-keygen -l', once
+// the default key exchange has been negotiated. It is ignored for other
+// protocols.
+func (b *bannercheck) WithFingerprint(fingerprint string) BannerCheck {
+	b.fingerprint = fingerprint
+	return b
+}
+
+// WithTimeout limits the time a single attempt, i.e. connecting and the
+// exchange with the server, may take.
+func (b *bannercheck) WithTimeout(timeout time.Duration) BannerCheck {
+	b.timeout = timeout
+	return b
+}
+
+func (b *bannercheck) WithLogger(w io.Writer) BannerCheck {
+	b.logger = w
+	return b
+}
+
+// IsReady returns true once the server has sent the expected greeting.
+func (b *bannercheck) IsReady() bool {
+	if err := b.run(); err != nil {
+		fmt.Fprintln(b.logger, err.Error())
+		return false
+	}
+	return true
+}
+
+func (b *bannercheck) run() error {
+	protocol, ok := bannerProtocols[b.protocol]
+	if !ok {
+		return fmt.Errorf("unsupported protocol '%s'", b.protocol)
+	}
+
+	addr := b.addr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, strconv.Itoa(protocol.port))
+	}
+
+	fmt.Fprintf(b.logger, "Connecting to %s://%s\n", b.protocol, addr)
+
+	conn, err := net.DialTimeout("tcp", addr, b.timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(b.timeout))
+
+	// servers must not be able to make the check read until the deadline
+	limited := &replayConn{Conn: conn, reader: &limitedReader{reader: conn, remaining: 1 << 20}}
+
+	return protocol.greet(b, limited)
+}
+
+// smtp expects a 220 greeting and a 250 response to EHLO.
+func (b *bannercheck) smtp(conn net.Conn) error {
+	text := textproto.NewConn(conn)
+
+	_, greeting, err := text.ReadResponse(220)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(b.logger, "Received greeting '%s'\n", firstLine(greeting))
+
+	if err := text.PrintfLine("EHLO localhost"); err != nil {
+		return err
+	}
+
+	if _, _, err := text.ReadResponse(250); err != nil {
+		return err
+	}
+
+	text.PrintfLine("QUIT")
+	return nil
+}
+
+// ftp expects a 220 greeting. Servers that are not ready yet answer with 120.
+func (b *bannercheck) ftp(conn net.Conn) error {
+	text := textproto.NewConn(conn)
+
+	_, greeting, err := text.ReadResponse(220)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(b.logger, "Received greeting '%s'\n", firstLine(greeting))
+
+	text.PrintfLine("QUIT")
+	return nil
+}
+
+// errFingerprintVerified aborts the SSH handshake once the host key has been
+// verified, no login is attempted.
+var errFingerprintVerified = errors.New("fingerprint verified")
+
+// ssh expects an SSH 2.0 identification string and optionally verifies the
+// fingerprint of the host key.
+func (b *bannercheck) ssh(conn net.Conn) error {
+	reader := bufio.NewReader(conn)
+
+	// servers may send other lines before the identification string
+	var ident string
+	for !strings.HasPrefix(ident, "SSH-") {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		ident = line
+	}
+
+	version := strings.TrimRight(ident, "\r\n")
+	if !strings.HasPrefix(version, "SSH-2.0-") && !strings.HasPrefix(version, "SSH-1.99-") {
+		return fmt.Errorf("server does not support SSH 2.0: '%s'", version)
+	}
+
+	fmt.Fprintf(b.logger, "Received identification '%s'\n", version)
+
+	if b.fingerprint == "" {
+		return nil
+	}
+
+	var verified error
+
+	config := &ssh.ClientConfig{
+		User: "waitfor",
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			fingerprint := ssh.FingerprintSHA256(key)
+			if b.fingerprint != fingerprint && b.fingerprint != ssh.FingerprintLegacyMD5(key) {
+				verified = fmt.Errorf("host key %s has fingerprint %s, expected %s", key.Type(), fingerprint, b.fingerprint)
+			} else {
+				verified = errFingerprintVerified
+			}
+			return verified
+		},
+	}
+
+	// replay the identification string to the SSH client
+	replay := &replayConn{Conn: conn, reader: io.MultiReader(strings.NewReader(ident), reader)}
+
+	if _, _, _, err := ssh.NewClientConn(replay, conn.RemoteAddr().String(), config); verified == nil {
+		return err
+	}
+
+	if verified == errFingerprintVerified {
+		return nil
+	}
+
+	return verified
+}
+
+type replayConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c *replayConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// limitedReader fails once more than the remaining number of bytes have been
+// read.
+type limitedReader struct {
+	reader    io.Reader
+	remaining int
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		return 0, errors.New("response exceeds the limit of 1 MiB")
+	}
+
+	if len(p) > l.remaining {
+		p = p[:l.remaining]
+	}
+
+	n, err := l.reader.Read(p)
+	l.remaining -= n
+	return n, err
+}
+
+func firstLine(s string) string {
+	if i := strings.Index(s, "\n"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,247 @@
+package check_test
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("bannercheck", func() {
+	var (
+		listener net.Listener
+		addr     string
+		logger   *gbytes.Buffer
+		serve    func(net.Conn)
+	)
+
+	BeforeEach(func() {
+		var err error
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+
+		addr = listener.Addr().String()
+		logger = gbytes.NewBuffer()
+		serve = func(net.Conn) {}
+	})
+
+	JustBeforeEach(func() {
+		go serveBanner(listener, serve)
+	})
+
+	AfterEach(func() {
+		listener.Close()
+	})
+
+	It("knows about smtp, ssh and ftp", func() {
+		Expect(check.BannerProtocols()).To(Equal([]string{"ftp", "smtp", "ssh"}))
+	})
+
+	It("fails for unsupported protocols", func() {
+		Expect(check.Banner("gopher", addr).WithLogger(logger).IsReady()).To(BeFalse())
+		Expect(logger).To(gbytes.Say("unsupported protocol 'gopher'"))
+	})
+
+	It("uses the well-known port of the protocol", func() {
+		check.Banner("ftp", "127.0.0.1").WithTimeout(50 * time.Millisecond).WithLogger(logger).IsReady()
+		Expect(logger).To(gbytes.Say("Connecting to ftp://127.0.0.1:21"))
+	})
+
+	Describe("smtp", func() {
+		var (
+			greeting string
+			commands chan string
+		)
+
+		BeforeEach(func() {
+			greeting = "220-mail.example.com ESMTP Postfix\r\n220 ready\r\n"
+			commands = make(chan string, 2)
+
+			serve = func(conn net.Conn) {
+				conn.Write([]byte(greeting))
+
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+
+					commands <- line
+
+					if line == "EHLO localhost\r\n" {
+						conn.Write([]byte("250-mail.example.com\r\n250-PIPELINING\r\n250 8BITMIME\r\n"))
+					}
+				}
+			}
+		})
+
+		It("expects a 220 greeting and a 250 response to EHLO", func() {
+			Expect(check.Banner("smtp", addr).WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(commands).To(Receive(Equal("EHLO localhost\r\n")))
+			Eventually(commands).Should(Receive(Equal("QUIT\r\n")))
+			Expect(logger).To(gbytes.Say("Connecting to smtp://" + addr))
+			Expect(logger).To(gbytes.Say("Received greeting 'mail.example.com ESMTP Postfix'"))
+		})
+
+		Context("when the server is not accepting mail", func() {
+			BeforeEach(func() {
+				greeting = "554 no service\r\n"
+			})
+
+			It("fails", func() {
+				Expect(check.Banner("smtp", addr).WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say(`554 "no service"`))
+			})
+		})
+
+		Context("when the server does not answer EHLO", func() {
+			BeforeEach(func() {
+				serve = func(conn net.Conn) {
+					conn.Write([]byte(greeting))
+					time.Sleep(time.Second)
+				}
+			})
+
+			It("fails", func() {
+				Expect(check.Banner("smtp", addr).WithTimeout(50 * time.Millisecond).WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("i/o timeout"))
+			})
+		})
+	})
+
+	Describe("ftp", func() {
+		BeforeEach(func() {
+			serve = func(conn net.Conn) {
+				conn.Write([]byte("220 (vsFTPd 3.0.5)\r\n"))
+				bufio.NewReader(conn).ReadString('\n')
+			}
+		})
+
+		It("expects a 220 greeting", func() {
+			Expect(check.Banner("ftp", addr).WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(logger).To(gbytes.Say(`Received greeting '\(vsFTPd 3.0.5\)'`))
+		})
+
+		Context("when the server is not ready yet", func() {
+			BeforeEach(func() {
+				serve = func(conn net.Conn) {
+					conn.Write([]byte("120 Service ready in 5 minutes\r\n"))
+				}
+			})
+
+			It("fails", func() {
+				Expect(check.Banner("ftp", addr).WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say(`120 "Service ready in 5 minutes"`))
+			})
+		})
+	})
+
+	Describe("ssh", func() {
+		var hostKey ssh.Signer
+
+		BeforeEach(func() {
+			_, key, err := ed25519.GenerateKey(rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+
+			hostKey, err = ssh.NewSignerFromKey(key)
+			Expect(err).ToNot(HaveOccurred())
+
+			serve = func(conn net.Conn) {
+				config := &ssh.ServerConfig{NoClientAuth: true, ServerVersion: "SSH-2.0-OpenSSH_9.6"}
+				config.AddHostKey(hostKey)
+				ssh.NewServerConn(conn, config)
+			}
+		})
+
+		It("expects an SSH 2.0 identification string", func() {
+			Expect(check.Banner("ssh", addr).WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(logger).To(gbytes.Say("Received identification 'SSH-2.0-OpenSSH_9.6'"))
+		})
+
+		Context("when lines are sent before the identification string", func() {
+			BeforeEach(func() {
+				serve = func(conn net.Conn) {
+					conn.Write([]byte("Welcome to the bastion\r\nSSH-2.0-OpenSSH_9.6\r\n"))
+				}
+			})
+
+			It("skips them", func() {
+				Expect(check.Banner("ssh", addr).WithLogger(logger).IsReady()).To(BeTrue())
+			})
+		})
+
+		Context("when the server sends lines until the deadline", func() {
+			BeforeEach(func() {
+				serve = func(conn net.Conn) {
+					line := []byte(strings.Repeat("x", 1023) + "\n")
+					for {
+						if _, err := conn.Write(line); err != nil {
+							return
+						}
+					}
+				}
+			})
+
+			It("fails once the limit has been reached", func() {
+				Expect(check.Banner("ssh", addr).WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("response exceeds the limit of 1 MiB"))
+			})
+		})
+
+		Context("when the server speaks SSH 1", func() {
+			BeforeEach(func() {
+				serve = func(conn net.Conn) {
+					conn.Write([]byte("SSH-1.5-OpenSSH_2.0\r\n"))
+				}
+			})
+
+			It("fails", func() {
+				Expect(check.Banner("ssh", addr).WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("server does not support SSH 2.0: 'SSH-1.5-OpenSSH_2.0'"))
+			})
+		})
+
+		Describe(".WithFingerprint", func() {
+			It("succeeds if the host key matches", func() {
+				fingerprint := ssh.FingerprintSHA256(hostKey.PublicKey())
+				Expect(check.Banner("ssh", addr).WithFingerprint(fingerprint).WithLogger(logger).IsReady()).To(BeTrue())
+			})
+
+			It("accepts legacy MD5 fingerprints", func() {
+				fingerprint := ssh.FingerprintLegacyMD5(hostKey.PublicKey())
+				Expect(check.Banner("ssh", addr).WithFingerprint(fingerprint).WithLogger(logger).IsReady()).To(BeTrue())
+			})
+
+			It("fails if the host key does not match", func() {
+				Expect(check.Banner("ssh", addr).WithFingerprint("SHA256:nope").WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("host key ssh-ed25519 has fingerprint SHA256:.*, expected SHA256:nope"))
+			})
+		})
+	})
+})
+
+// serveBanner accepts connections and handles each of them using serve.
+func serveBanner(listener net.Listener, serve func(net.Conn)) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer GinkgoRecover()
+			defer conn.Close()
+			serve(conn)
+		}()
+	}
+}
@@ -1,12 +1,18 @@
 package check
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"regexp"
 	"strings"
 	"syscall"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/term"
 )
 
 type CommandCheck interface {
@@ -16,16 +22,24 @@ type CommandCheck interface {
 	MatchesOutput(*regexp.Regexp) bool
 
 	WithEnv([]string) CommandCheck
+	WithExtraEnv([]string) CommandCheck
+	WithDir(string) CommandCheck
 	WithLogger(io.Writer) CommandCheck
 	WithStdin(io.Reader) CommandCheck
+	WithContext(context.Context) CommandCheck
+	WithRunTimeout(time.Duration) CommandCheck
 }
 
 type cmdcheck struct {
-	cmd    string
-	args   []string
-	env    []string
-	stdin  io.Reader
-	logger io.Writer
+	cmd        string
+	args       []string
+	env        []string
+	extraEnv   []string
+	dir        string
+	stdin      io.Reader
+	logger     io.Writer
+	ctx        context.Context
+	runTimeout time.Duration
 }
 
 func Command(cmd string, args ...string) CommandCheck {
@@ -33,6 +47,7 @@ func Command(cmd string, args ...string) CommandCheck {
 		cmd:    cmd,
 		args:   args,
 		logger: DefaultLogger,
+		ctx:    context.Background(),
 	}
 }
 
@@ -41,16 +56,45 @@ func (c *cmdcheck) WithEnv(env []string) CommandCheck {
 	return c
 }
 
+// WithExtraEnv adds the given variables to the environment of the command
+// instead of replacing it. Variables that are already set are overridden.
+func (c *cmdcheck) WithExtraEnv(env []string) CommandCheck {
+	c.extraEnv = append(c.extraEnv, env...)
+	return c
+}
+
+func (c *cmdcheck) WithDir(dir string) CommandCheck {
+	c.dir = dir
+	return c
+}
+
 func (c *cmdcheck) WithLogger(w io.Writer) CommandCheck {
 	c.logger = w
 	return c
 }
 
+// WithStdin passes the given reader to the command. A terminal is not passed
+// on, the command runs in a process group of its own and reading from the
+// terminal in the background would stop it.
 func (c *cmdcheck) WithStdin(r io.Reader) CommandCheck {
 	c.stdin = r
 	return c
 }
 
+// WithContext makes the check kill the process group of a running command
+// once the given context is done, including any processes it has spawned.
+func (c *cmdcheck) WithContext(ctx context.Context) CommandCheck {
+	c.ctx = ctx
+	return c
+}
+
+// WithRunTimeout kills a command that is still running after the given
+// duration, which makes the current attempt fail.
+func (c *cmdcheck) WithRunTimeout(d time.Duration) CommandCheck {
+	c.runTimeout = d
+	return c
+}
+
 func (c *cmdcheck) Succeeds() bool {
 	_, err := c.exec()
 	return err == nil
@@ -91,11 +135,20 @@ func (c *cmdcheck) exec() ([]byte, error) {
 		cmd.Env = c.env
 	}
 
-	if c.stdin != nil {
+	if len(c.extraEnv) > 0 {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, c.extraEnv...)
+	}
+
+	cmd.Dir = c.dir
+
+	if c.stdin != nil && !isTerminal(c.stdin) {
 		cmd.Stdin = c.stdin
 	}
 
-	out, err := cmd.CombinedOutput()
+	out, err := c.run(cmd)
 
 	if len(out) > 0 {
 		fmt.Fprint(c.logger, string(out))
@@ -107,3 +160,51 @@ func (c *cmdcheck) exec() ([]byte, error) {
 
 	return out, err
 }
+
+// run starts the command in a process group of its own, so that it can be
+// killed together with its children if the context is done or the run timeout
+// is exceeded before it exits.
+func (c *cmdcheck) run(cmd *exec.Cmd) ([]byte, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var timedOut <-chan time.Time
+	if c.runTimeout > 0 {
+		timer := time.NewTimer(c.runTimeout)
+		defer timer.Stop()
+		timedOut = timer.C
+	}
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return out.Bytes(), err
+	case <-c.ctx.Done():
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-done
+		return out.Bytes(), c.ctx.Err()
+	case <-timedOut:
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-done
+		return out.Bytes(), fmt.Errorf("command timed out after %s", c.runTimeout)
+	}
+}
+
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
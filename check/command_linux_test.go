@@ -0,0 +1,58 @@
+package check_test
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("cmdcheck on a terminal", func() {
+	var master, slave *os.File
+
+	BeforeEach(func() {
+		var err error
+		master, err = os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+		if err != nil {
+			Skip("pseudo terminals are not available")
+		}
+
+		var unlock int32
+		_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock)))
+		Expect(errno).To(BeZero())
+
+		var n uint32
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n)))
+		Expect(errno).To(BeZero())
+
+		slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		slave.Close()
+		master.Close()
+	})
+
+	It("does not pass the terminal on as stdin", func() {
+		command := check.Command("sh", "-c", "test -t 0").WithStdin(slave).WithLogger(GinkgoWriter)
+		Expect(command.Fails()).To(BeTrue())
+	})
+
+	It("passes other files on as stdin", func() {
+		r, w, err := os.Pipe()
+		Expect(err).ToNot(HaveOccurred())
+		defer r.Close()
+
+		fmt.Fprint(w, "ping")
+		w.Close()
+
+		command := check.Command("grep", "-q", "ping").WithStdin(r).WithLogger(GinkgoWriter)
+		Expect(command.Succeeds()).To(BeTrue())
+	})
+})
@@ -3,14 +3,18 @@ package check_test
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/context"
 
 	"github.com/st3v/waitfor/check"
 )
@@ -178,6 +182,45 @@ var _ = Describe("cmdcheck", func() {
 		)
 	})
 
+	Context("when extra env is being set", func() {
+		var (
+			output  *gbytes.Buffer
+			command check.CommandCheck
+		)
+
+		BeforeEach(func() {
+			os.Setenv("FOO", "BAR")
+			os.Setenv("ONE", "0")
+			output = gbytes.NewBuffer()
+			logger := io.MultiWriter(GinkgoWriter, output)
+			command = check.Command(fakeBin, "--env").WithExtraEnv([]string{"ONE=1", "TWO=2"}).WithLogger(logger)
+		})
+
+		It("merges it with the env from the parent process", func() {
+			Expect(command.Succeeds()).To(BeTrue())
+			Expect(output).To(gbytes.Say("FOO=BAR"))
+			Expect(output).To(gbytes.Say("ONE=1"))
+			Expect(output).ToNot(gbytes.Say("ONE=0"))
+			Expect(string(output.Contents())).To(ContainSubstring("TWO=2"))
+		})
+	})
+
+	Context("when a working directory is being set", func() {
+		It("runs the command in it", func() {
+			dir, err := ioutil.TempDir("", "cmdcheck")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			dir, err = filepath.EvalSymlinks(dir)
+			Expect(err).ToNot(HaveOccurred())
+
+			output := gbytes.NewBuffer()
+			command := check.Command(fakeBin, "--pwd").WithDir(dir).WithLogger(output)
+
+			Expect(command.MatchesOutput(regexp.MustCompile("^" + regexp.QuoteMeta(dir) + "$"))).To(BeTrue())
+		})
+	})
+
 	Context("when stdin is being set", func() {
 		var (
 			output  *gbytes.Buffer
@@ -238,4 +281,49 @@ var _ = Describe("cmdcheck", func() {
 			),
 		)
 	})
+
+	Context("when the context is done while the command is running", func() {
+		It("kills the command together with the processes it spawned", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			command := check.Command("sh", "-c", "sleep 30 & wait").WithContext(ctx).WithLogger(GinkgoWriter)
+
+			done := make(chan bool)
+			go func() {
+				done <- command.Succeeds()
+			}()
+
+			time.Sleep(100 * time.Millisecond)
+			cancel()
+
+			Eventually(done, time.Second).Should(Receive(BeFalse()))
+		})
+	})
+
+	Context("when the run timeout is exceeded", func() {
+		It("kills the command together with the processes it spawned", func() {
+			output := gbytes.NewBuffer()
+			command := check.Command("sh", "-c", "sleep 30 & wait").WithRunTimeout(100 * time.Millisecond).WithLogger(output)
+
+			done := make(chan bool)
+			go func() {
+				done <- command.Succeeds()
+			}()
+
+			Eventually(done, time.Second).Should(Receive(BeFalse()))
+			Expect(output).To(gbytes.Say("command timed out after 100ms"))
+		})
+	})
+
+	Context("when the context is done before the command runs", func() {
+		It("does not run the command", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			output := gbytes.NewBuffer()
+			command := check.Command(fakeBin, "--out", "some-output").WithContext(ctx).WithLogger(output)
+
+			Expect(command.Succeeds()).To(BeFalse())
+			Expect(output).To(gbytes.Say("--out some-output\ncontext canceled\n"))
+		})
+	})
 })
@@ -21,8 +21,21 @@ type CurlCheck interface {
 	WithHeader(string, string) CurlCheck
 	WithData(io.Reader) CurlCheck
 	WithLogger(io.Writer) CurlCheck
+	WithTracer(Tracer) CurlCheck
 }
 
+// Tracer is notified about every request a CurlCheck sends and every error
+// it encounters, e.g. to propagate trace context to the checked service.
+type Tracer interface {
+	Inject(*http.Request)
+	Error(error)
+}
+
+type nopTracer struct{}
+
+func (nopTracer) Inject(*http.Request) {}
+func (nopTracer) Error(error)          {}
+
 type curlcheck struct {
 	url      string
 	method   string
@@ -31,6 +44,7 @@ type curlcheck struct {
 	headers  map[string]string
 	data     io.Reader
 	logger   io.Writer
+	tracer   Tracer
 }
 
 func Curl(url string) CurlCheck {
@@ -39,6 +53,7 @@ func Curl(url string) CurlCheck {
 		method:  DefaultCurlMethod,
 		headers: map[string]string{},
 		logger:  DefaultLogger,
+		tracer:  nopTracer{},
 	}
 }
 
@@ -68,6 +83,11 @@ func (c *curlcheck) WithLogger(w io.Writer) CurlCheck {
 	return c
 }
 
+func (c *curlcheck) WithTracer(t Tracer) CurlCheck {
+	c.tracer = t
+	return c
+}
+
 type matcher func(*http.Response, []byte) bool
 
 func (c *curlcheck) MatchBody(regex *regexp.Regexp) bool {
@@ -90,6 +110,7 @@ func (c *curlcheck) matchResponse(m matcher) bool {
 	resp, err := c.response()
 	if err != nil {
 		fmt.Fprintln(c.logger, err.Error())
+		c.tracer.Error(err)
 		return false
 	}
 	defer resp.Body.Close()
@@ -97,6 +118,7 @@ func (c *curlcheck) matchResponse(m matcher) bool {
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		fmt.Fprintln(c.logger, err.Error())
+		c.tracer.Error(err)
 		return false
 	}
 
@@ -129,5 +151,7 @@ func (c *curlcheck) request() (*http.Request, error) {
 		req.Header.Set(k, v)
 	}
 
+	c.tracer.Inject(req)
+
 	return req, nil
 }
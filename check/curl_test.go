@@ -253,6 +253,28 @@ var _ = Describe("curlcheck", func() {
 		})
 	})
 
+	Context("when a tracer is being passed", func() {
+		var tracer *fakeTracer
+
+		BeforeEach(func() {
+			tracer = &fakeTracer{}
+			curlcheck = check.Curl(fmt.Sprintf("%s/success", server.URL())).WithLogger(GinkgoWriter).WithTracer(tracer)
+		})
+
+		It("lets the tracer inject headers into the request", func() {
+			curlcheck.MatchResponseCode(200)
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+			Expect(server.ReceivedRequests()[0].Header.Get("traceparent")).To(Equal("00-trace-span-01"))
+		})
+
+		It("reports errors to the tracer", func() {
+			curlcheck = check.Curl("%invalid-url@").WithLogger(GinkgoWriter).WithTracer(tracer)
+			curlcheck.MatchResponseCode(200)
+			Expect(tracer.errs).To(HaveLen(1))
+			Expect(tracer.errs[0].Error()).To(ContainSubstring("invalid URL escape"))
+		})
+	})
+
 	Context("when a connection error occurs", func() {
 		BeforeEach(func() {
 			port, err := freeTcpPort()
@@ -313,3 +335,15 @@ var _ = Describe("curlcheck", func() {
 		})
 	})
 })
+
+type fakeTracer struct {
+	errs []error
+}
+
+func (t *fakeTracer) Inject(req *http.Request) {
+	req.Header.Set("traceparent", "00-trace-span-01")
+}
+
+func (t *fakeTracer) Error(err error) {
+	t.errs = append(t.errs, err)
+}
@@ -0,0 +1,9 @@
+package check
+
+// UnregisterProbe removes the probe registered under the given name.
+func UnregisterProbe(name string) {
+	probesMutex.Lock()
+	defer probesMutex.Unlock()
+
+	delete(probes, name)
+}
@@ -14,6 +14,7 @@ var (
 	err  = flag.String("err", "", "content to print on stderr")
 	echo = flag.Bool("echo", false, "redirect stdin to stdout")
 	env  = flag.Bool("env", false, "print environment variables")
+	pwd  = flag.Bool("pwd", false, "print working directory")
 )
 
 func main() {
@@ -34,5 +35,13 @@ func main() {
 		fmt.Fprint(os.Stdout, strings.Join(os.Environ(), "\n"))
 	}
 
+	if *pwd {
+		dir, err := os.Getwd()
+		if err != nil {
+			panic(fmt.Sprintf("Error getting working directory: %s", err.Error()))
+		}
+		fmt.Fprint(os.Stdout, dir)
+	}
+
 	os.Exit(*rc)
 }
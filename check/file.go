@@ -0,0 +1,46 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+type FileCheck interface {
+	Exists() bool
+	IsMissing() bool
+
+	WithLogger(io.Writer) FileCheck
+}
+
+type filecheck struct {
+	path   string
+	logger io.Writer
+}
+
+func File(path string) FileCheck {
+	return &filecheck{
+		path:   path,
+		logger: DefaultLogger,
+	}
+}
+
+func (f *filecheck) WithLogger(w io.Writer) FileCheck {
+	f.logger = w
+	return f
+}
+
+func (f *filecheck) Exists() bool {
+	fmt.Fprintf(f.logger, "Checking file %s\n", f.path)
+
+	if _, err := os.Stat(f.path); err != nil {
+		fmt.Fprintln(f.logger, err.Error())
+		return false
+	}
+
+	return true
+}
+
+func (f *filecheck) IsMissing() bool {
+	return !f.Exists()
+}
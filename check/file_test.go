@@ -0,0 +1,75 @@
+package check_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("filecheck", func() {
+	var (
+		dir       string
+		path      string
+		logger    *gbytes.Buffer
+		filecheck check.FileCheck
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "filecheck")
+		Expect(err).ToNot(HaveOccurred())
+
+		path = filepath.Join(dir, "ready")
+		logger = gbytes.NewBuffer()
+		filecheck = check.File(path).WithLogger(logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Context("when the file exists", func() {
+		BeforeEach(func() {
+			Expect(ioutil.WriteFile(path, []byte{}, 0644)).To(Succeed())
+		})
+
+		Describe(".Exists", func() {
+			It("returns true", func() {
+				Expect(filecheck.Exists()).To(BeTrue())
+			})
+		})
+
+		Describe(".IsMissing", func() {
+			It("returns false", func() {
+				Expect(filecheck.IsMissing()).To(BeFalse())
+			})
+		})
+	})
+
+	Context("when the file does not exist", func() {
+		Describe(".Exists", func() {
+			It("returns false", func() {
+				Expect(filecheck.Exists()).To(BeFalse())
+			})
+		})
+
+		Describe(".IsMissing", func() {
+			It("returns true", func() {
+				Expect(filecheck.IsMissing()).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("logging", func() {
+		It("provides logging", func() {
+			filecheck.Exists()
+			Expect(logger).To(gbytes.Say("Checking file " + path))
+		})
+	})
+})
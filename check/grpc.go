@@ -0,0 +1,146 @@
+package check
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type GRPCHealthCheck interface {
+	IsServing() bool
+
+	WithTLS(*tls.Config) GRPCHealthCheck
+	WithWatch() GRPCHealthCheck
+	WithTimeout(time.Duration) GRPCHealthCheck
+	WithLogger(io.Writer) GRPCHealthCheck
+}
+
+type grpchealthcheck struct {
+	addr      string
+	service   string
+	tlsConfig *tls.Config
+	watch     bool
+	timeout   time.Duration
+	logger    io.Writer
+}
+
+// GRPCHealth checks the status of a service using the standard gRPC health
+// checking protocol, i.e. grpc.health.v1.Health. An empty service refers to
+// the overall health of the server.
+func GRPCHealth(addr, service string) GRPCHealthCheck {
+	return &grpchealthcheck{
+		addr:    addr,
+		service: service,
+		timeout: DefaultTimeout,
+		logger:  DefaultLogger,
+	}
+}
+
+// WithTLS connects using TLS instead of plaintext.
+func (g *grpchealthcheck) WithTLS(config *tls.Config) GRPCHealthCheck {
+	g.tlsConfig = config
+	return g
+}
+
+// WithWatch uses the Watch streaming RPC instead of Check, so that a single
+// attempt returns as soon as the service becomes SERVING. Servers that do not
+// implement Watch are asked using Check.
+func (g *grpchealthcheck) WithWatch() GRPCHealthCheck {
+	g.watch = true
+	return g
+}
+
+// WithTimeout limits the time a single attempt, i.e. connecting and the RPC,
+// may take. It also limits how long an attempt watches the status.
+func (g *grpchealthcheck) WithTimeout(timeout time.Duration) GRPCHealthCheck {
+	g.timeout = timeout
+	return g
+}
+
+func (g *grpchealthcheck) WithLogger(w io.Writer) GRPCHealthCheck {
+	g.logger = w
+	return g
+}
+
+// IsServing returns true if the server reports the service as SERVING.
+func (g *grpchealthcheck) IsServing() bool {
+	if err := g.run(); err != nil {
+		fmt.Fprintln(g.logger, err.Error())
+		return false
+	}
+	return true
+}
+
+func (g *grpchealthcheck) run() error {
+	creds := insecure.NewCredentials()
+	if g.tlsConfig != nil {
+		creds = credentials.NewTLS(g.tlsConfig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	// the client connects on the first RPC, which is bound by the timeout
+	conn, err := grpc.NewClient(g.addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+	request := &healthpb.HealthCheckRequest{Service: g.service}
+
+	if g.watch {
+		fmt.Fprintf(g.logger, "Watching health of service '%s' on %s\n", g.service, g.addr)
+
+		err := g.watchStatus(ctx, client, request)
+		if status.Code(err) != codes.Unimplemented {
+			return err
+		}
+
+		fmt.Fprintln(g.logger, "Server does not implement Watch, falling back to Check")
+	}
+
+	fmt.Fprintf(g.logger, "Checking health of service '%s' on %s\n", g.service, g.addr)
+
+	response, err := client.Check(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	if response.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("service '%s' is %s", g.service, response.Status)
+	}
+
+	return nil
+}
+
+// watchStatus returns once the service is SERVING or the context is done.
+func (g *grpchealthcheck) watchStatus(ctx context.Context, client healthpb.HealthClient, request *healthpb.HealthCheckRequest) error {
+	stream, err := client.Watch(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	for {
+		response, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		if response.Status == healthpb.HealthCheckResponse_SERVING {
+			return nil
+		}
+
+		fmt.Fprintf(g.logger, "Service '%s' is %s\n", g.service, response.Status)
+	}
+}
@@ -0,0 +1,175 @@
+package check_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("grpchealthcheck", func() {
+	var (
+		server  *grpc.Server
+		status  *health.Server
+		service healthpb.HealthServer
+		options []grpc.ServerOption
+		addr    string
+		logger  *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		options = nil
+		status = health.NewServer()
+		service = status
+		logger = gbytes.NewBuffer()
+	})
+
+	JustBeforeEach(func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		addr = listener.Addr().String()
+
+		server = grpc.NewServer(options...)
+		if service != nil {
+			healthpb.RegisterHealthServer(server, service)
+		}
+
+		go server.Serve(listener)
+	})
+
+	AfterEach(func() {
+		server.Stop()
+	})
+
+	It("returns true if the server is serving", func() {
+		Expect(check.GRPCHealth(addr, "").WithLogger(logger).IsServing()).To(BeTrue())
+		Expect(logger).To(gbytes.Say("Checking health of service '' on " + addr))
+	})
+
+	It("returns true if the service is serving", func() {
+		status.SetServingStatus("app.Orders", healthpb.HealthCheckResponse_SERVING)
+		Expect(check.GRPCHealth(addr, "app.Orders").WithLogger(logger).IsServing()).To(BeTrue())
+	})
+
+	It("returns false if the service is not serving", func() {
+		status.SetServingStatus("app.Orders", healthpb.HealthCheckResponse_NOT_SERVING)
+		Expect(check.GRPCHealth(addr, "app.Orders").WithLogger(logger).IsServing()).To(BeFalse())
+		Expect(logger).To(gbytes.Say("service 'app.Orders' is NOT_SERVING"))
+	})
+
+	It("returns false if the service is unknown", func() {
+		Expect(check.GRPCHealth(addr, "app.Unknown").WithLogger(logger).IsServing()).To(BeFalse())
+		Expect(logger).To(gbytes.Say("code = NotFound"))
+	})
+
+	Context("when the server does not implement the health service", func() {
+		BeforeEach(func() {
+			service = nil
+		})
+
+		It("returns false", func() {
+			Expect(check.GRPCHealth(addr, "").WithLogger(logger).IsServing()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("code = Unimplemented"))
+		})
+	})
+
+	Context("when nothing is listening", func() {
+		It("returns false", func() {
+			server.Stop()
+			Expect(check.GRPCHealth(addr, "").WithTimeout(time.Second).WithLogger(logger).IsServing()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("code = Unavailable"))
+		})
+	})
+
+	Context("when the server does not complete the handshake", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("gives up after the timeout", func() {
+			start := time.Now()
+			Expect(check.GRPCHealth(listener.Addr().String(), "").WithTimeout(100 * time.Millisecond).WithLogger(logger).IsServing()).To(BeFalse())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			Expect(logger).To(gbytes.Say("code = DeadlineExceeded"))
+		})
+	})
+
+	Describe(".WithWatch", func() {
+		It("returns as soon as the service becomes serving", func() {
+			status.SetServingStatus("app.Orders", healthpb.HealthCheckResponse_NOT_SERVING)
+			time.AfterFunc(100*time.Millisecond, func() {
+				status.SetServingStatus("app.Orders", healthpb.HealthCheckResponse_SERVING)
+			})
+
+			start := time.Now()
+			Expect(check.GRPCHealth(addr, "app.Orders").WithWatch().WithLogger(logger).IsServing()).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			Expect(logger).To(gbytes.Say("Service 'app.Orders' is NOT_SERVING"))
+		})
+
+		It("returns false if the service does not become serving in time", func() {
+			status.SetServingStatus("app.Orders", healthpb.HealthCheckResponse_NOT_SERVING)
+			Expect(check.GRPCHealth(addr, "app.Orders").WithWatch().WithTimeout(100 * time.Millisecond).WithLogger(logger).IsServing()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("code = DeadlineExceeded"))
+		})
+
+		Context("when the server does not implement Watch", func() {
+			BeforeEach(func() {
+				service = checkOnlyHealth{}
+			})
+
+			It("falls back to Check", func() {
+				Expect(check.GRPCHealth(addr, "").WithWatch().WithLogger(logger).IsServing()).To(BeTrue())
+				Expect(logger).To(gbytes.Say("falling back to Check"))
+			})
+		})
+	})
+
+	Describe(".WithTLS", func() {
+		var pool *x509.CertPool
+
+		BeforeEach(func() {
+			cert, ca := fakeCertificate()
+			pool = x509.NewCertPool()
+			pool.AddCert(ca)
+
+			options = []grpc.ServerOption{grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}}))}
+		})
+
+		It("connects using TLS", func() {
+			Expect(check.GRPCHealth(addr, "").WithTLS(&tls.Config{RootCAs: pool}).WithLogger(logger).IsServing()).To(BeTrue())
+		})
+
+		It("fails to connect using plaintext", func() {
+			Expect(check.GRPCHealth(addr, "").WithTimeout(time.Second).WithLogger(logger).IsServing()).To(BeFalse())
+		})
+	})
+})
+
+// checkOnlyHealth implements Check but not Watch.
+type checkOnlyHealth struct {
+	healthpb.UnimplementedHealthServer
+}
+
+func (checkOnlyHealth) Check(context.Context, *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
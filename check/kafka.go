@@ -0,0 +1,367 @@
+package check
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+type KafkaCheck interface {
+	IsReady() bool
+
+	WithTopic(string) KafkaCheck
+	WithPartitions(int) KafkaCheck
+	WithTimeout(time.Duration) KafkaCheck
+	WithLogger(io.Writer) KafkaCheck
+}
+
+type kafkacheck struct {
+	brokers    []string
+	topics     []string
+	partitions int
+	timeout    time.Duration
+	logger     io.Writer
+}
+
+// Kafka checks whether a Kafka cluster is ready by sending ApiVersions and
+// Metadata requests to the given brokers, 'host[:port]', until one of them
+// answers. The cluster is ready once a controller has been elected.
+func Kafka(brokers ...string) KafkaCheck {
+	return &kafkacheck{
+		brokers: brokers,
+		timeout: DefaultTimeout,
+		logger:  DefaultLogger,
+	}
+}
+
+// WithTopic requires the given topic to exist and all of its partitions to
+// have a leader. It can be used multiple times.
+func (k *kafkacheck) WithTopic(topic string) KafkaCheck {
+	k.topics = append(k.topics, topic)
+	return k
+}
+
+// WithPartitions requires each topic to have exactly the given number of
+// partitions.
+func (k *kafkacheck) WithPartitions(partitions int) KafkaCheck {
+	k.partitions = partitions
+	return k
+}
+
+// WithTimeout limits the time an attempt to talk to a single broker may take.
+func (k *kafkacheck) WithTimeout(timeout time.Duration) KafkaCheck {
+	k.timeout = timeout
+	return k
+}
+
+func (k *kafkacheck) WithLogger(w io.Writer) KafkaCheck {
+	k.logger = w
+	return k
+}
+
+// IsReady returns true once one of the brokers reports an elected controller
+// and all topics are ready.
+func (k *kafkacheck) IsReady() bool {
+	if len(k.brokers) == 0 {
+		fmt.Fprintln(k.logger, "no brokers given")
+		return false
+	}
+
+	for _, broker := range k.brokers {
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			broker = net.JoinHostPort(broker, "9092")
+		}
+
+		err := k.check(broker)
+		if err == nil {
+			return true
+		}
+
+		fmt.Fprintln(k.logger, err.Error())
+	}
+
+	return false
+}
+
+func (k *kafkacheck) check(broker string) error {
+	fmt.Fprintf(k.logger, "Connecting to broker %s\n", broker)
+
+	raw, err := net.DialTimeout("tcp", broker, k.timeout)
+	if err != nil {
+		return err
+	}
+	defer raw.Close()
+
+	raw.SetDeadline(time.Now().Add(k.timeout))
+
+	conn := &kafkaConn{Conn: raw, reader: bufio.NewReader(raw)}
+
+	version, err := conn.metadataVersion()
+	if err != nil {
+		return err
+	}
+
+	metadata, err := conn.metadata(version, k.topics)
+	if err != nil {
+		return err
+	}
+
+	if metadata.controller < 0 {
+		return errors.New("no controller has been elected yet")
+	}
+
+	for _, name := range k.topics {
+		topic, ok := metadata.topics[name]
+		if !ok {
+			return fmt.Errorf("topic '%s' not found", name)
+		}
+
+		if topic.err != 0 {
+			return fmt.Errorf("topic '%s': %s", name, kafkaError(topic.err))
+		}
+
+		if k.partitions > 0 && len(topic.leaders) != k.partitions {
+			return fmt.Errorf("topic '%s' has %d partitions, expected %d", name, len(topic.leaders), k.partitions)
+		}
+
+		for partition, leader := range topic.leaders {
+			if leader < 0 {
+				return fmt.Errorf("partition %d of topic '%s' has no leader", partition, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+const (
+	kafkaMetadata    = 3
+	kafkaAPIVersions = 18
+)
+
+type kafkaMetadataResponse struct {
+	controller int32
+	topics     map[string]kafkaTopic
+}
+
+type kafkaTopic struct {
+	err     int16
+	leaders map[int32]int32
+}
+
+// kafkaConn speaks the client side of the Kafka protocol, limited to
+// non-flexible versions of the ApiVersions and Metadata requests.
+type kafkaConn struct {
+	net.Conn
+	reader      *bufio.Reader
+	correlation int32
+}
+
+// metadataVersion asks the broker for the supported API versions and returns
+// the version of the Metadata request to use, either 4, which does not create
+// topics automatically, or 1 for older brokers.
+func (c *kafkaConn) metadataVersion() (int16, error) {
+	response, err := c.call(kafkaAPIVersions, 0, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	r := &kafkaReader{response}
+
+	if code := r.int16(); code != 0 {
+		return 0, fmt.Errorf("ApiVersions: %s", kafkaError(code))
+	}
+
+	for n := r.int32(); n > 0 && r.err() == nil; n-- {
+		key, min, max := r.int16(), r.int16(), r.int16()
+		if key != kafkaMetadata {
+			continue
+		}
+
+		switch {
+		case min <= 4 && max >= 4:
+			return 4, nil
+		case min <= 1 && max >= 1:
+			return 1, nil
+		}
+
+		return 0, fmt.Errorf("broker does not support Metadata v1 or v4, only v%d to v%d", min, max)
+	}
+
+	if err := r.err(); err != nil {
+		return 0, err
+	}
+
+	return 0, errors.New("broker does not support Metadata requests")
+}
+
+func (c *kafkaConn) metadata(version int16, topics []string) (kafkaMetadataResponse, error) {
+	var request bytes.Buffer
+	binary.Write(&request, binary.BigEndian, int32(len(topics)))
+	for _, topic := range topics {
+		request.Write(kafkaString(topic))
+	}
+
+	if version >= 4 {
+		request.WriteByte(0) // allow_auto_topic_creation
+	}
+
+	metadata := kafkaMetadataResponse{topics: make(map[string]kafkaTopic)}
+
+	response, err := c.call(kafkaMetadata, version, request.Bytes())
+	if err != nil {
+		return metadata, err
+	}
+
+	r := &kafkaReader{response}
+
+	if version >= 3 {
+		r.int32() // throttle_time_ms
+	}
+
+	for n := r.int32(); n > 0 && r.err() == nil; n-- {
+		r.int32()  // node_id
+		r.string() // host
+		r.int32()  // port
+		r.string() // rack
+	}
+
+	if version >= 2 {
+		r.string() // cluster_id
+	}
+
+	metadata.controller = r.int32()
+
+	for n := r.int32(); n > 0 && r.err() == nil; n-- {
+		topic := kafkaTopic{err: r.int16(), leaders: make(map[int32]int32)}
+		name := r.string()
+		r.bytes(1) // is_internal
+
+		for p := r.int32(); p > 0 && r.err() == nil; p-- {
+			r.int16() // error_code
+			partition, leader := r.int32(), r.int32()
+			topic.leaders[partition] = leader
+
+			r.bytes(4 * int(r.int32())) // replica_nodes
+			r.bytes(4 * int(r.int32())) // isr_nodes
+		}
+
+		metadata.topics[name] = topic
+	}
+
+	return metadata, r.err()
+}
+
+// call sends a request with the given API key and version and returns the
+// body of the response.
+func (c *kafkaConn) call(key, version int16, body []byte) ([]byte, error) {
+	c.correlation++
+
+	var request bytes.Buffer
+	binary.Write(&request, binary.BigEndian, key)
+	binary.Write(&request, binary.BigEndian, version)
+	binary.Write(&request, binary.BigEndian, c.correlation)
+	request.Write(kafkaString("waitfor"))
+	request.Write(body)
+
+	size := make([]byte, 4)
+	binary.BigEndian.PutUint32(size, uint32(request.Len()))
+
+	if _, err := c.Write(append(size, request.Bytes()...)); err != nil {
+		return nil, err
+	}
+
+	if _, err := io.ReadFull(c.reader, size); err != nil {
+		return nil, err
+	}
+
+	length := binary.BigEndian.Uint32(size)
+	if length > 1<<20 {
+		return nil, fmt.Errorf("response of %d bytes exceeds the limit of 1 MiB", length)
+	}
+
+	response := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, response); err != nil {
+		return nil, err
+	}
+
+	if len(response) < 4 || int32(binary.BigEndian.Uint32(response)) != c.correlation {
+		return nil, errors.New("invalid response")
+	}
+
+	return response[4:], nil
+}
+
+func kafkaString(s string) []byte {
+	b := make([]byte, 2, 2+len(s))
+	binary.BigEndian.PutUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+var kafkaErrors = map[int16]string{
+	3:  "UNKNOWN_TOPIC_OR_PARTITION",
+	5:  "LEADER_NOT_AVAILABLE",
+	29: "TOPIC_AUTHORIZATION_FAILED",
+	35: "UNSUPPORTED_VERSION",
+}
+
+func kafkaError(code int16) string {
+	if name, ok := kafkaErrors[code]; ok {
+		return fmt.Sprintf("error %d (%s)", code, name)
+	}
+	return fmt.Sprintf("error %d", code)
+}
+
+// kafkaReader decodes the fields of a response. Reading beyond the end of the
+// response yields zero values and an error.
+type kafkaReader struct {
+	data []byte
+}
+
+func (r *kafkaReader) bytes(n int) []byte {
+	if n < 0 || n > len(r.data) {
+		r.data = nil
+		n = 0
+	}
+
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *kafkaReader) int16() int16 {
+	b := r.bytes(2)
+	if len(b) < 2 {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+func (r *kafkaReader) int32() int32 {
+	b := r.bytes(4)
+	if len(b) < 4 {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+// string reads a nullable string, returning an empty string for null.
+func (r *kafkaReader) string() string {
+	n := r.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(r.bytes(int(n)))
+}
+
+func (r *kafkaReader) err() error {
+	if r.data == nil {
+		return errors.New("invalid response")
+	}
+	return nil
+}
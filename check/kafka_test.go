@@ -0,0 +1,311 @@
+package check_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("kafkacheck", func() {
+	var (
+		broker *fakeKafka
+		logger *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		broker = newFakeKafka()
+		logger = gbytes.NewBuffer()
+	})
+
+	JustBeforeEach(func() {
+		broker.start()
+	})
+
+	AfterEach(func() {
+		broker.close()
+	})
+
+	It("sends ApiVersions and Metadata requests", func() {
+		Expect(check.Kafka(broker.addr()).WithLogger(logger).IsReady()).To(BeTrue())
+		Expect(broker.requests()).To(Equal([]string{"ApiVersions v0", "Metadata v4"}))
+		Expect(logger).To(gbytes.Say("Connecting to broker " + broker.addr()))
+	})
+
+	Context("when the broker is older", func() {
+		BeforeEach(func() {
+			broker.maxMetadata = 2
+		})
+
+		It("falls back to Metadata v1", func() {
+			Expect(check.Kafka(broker.addr()).WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(broker.requests()).To(Equal([]string{"ApiVersions v0", "Metadata v1"}))
+		})
+	})
+
+	Context("when no controller has been elected", func() {
+		BeforeEach(func() {
+			broker.controller = -1
+		})
+
+		It("fails", func() {
+			Expect(check.Kafka(broker.addr()).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("no controller has been elected yet"))
+		})
+	})
+
+	It("tries the next broker if one is not reachable", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		unreachable := listener.Addr().String()
+		listener.Close()
+
+		Expect(check.Kafka(unreachable, broker.addr()).WithLogger(logger).IsReady()).To(BeTrue())
+		Expect(logger).To(gbytes.Say("connection refused"))
+	})
+
+	Context("when no broker answers", func() {
+		BeforeEach(func() {
+			broker.hang = true
+		})
+
+		It("fails", func() {
+			Expect(check.Kafka(broker.addr()).WithTimeout(50 * time.Millisecond).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("i/o timeout"))
+		})
+	})
+
+	Context("when the response is too large", func() {
+		BeforeEach(func() {
+			broker.oversized = true
+		})
+
+		It("fails", func() {
+			Expect(check.Kafka(broker.addr()).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("response of 4294967295 bytes exceeds the limit of 1 MiB"))
+		})
+	})
+
+	Describe(".WithTopic", func() {
+		BeforeEach(func() {
+			broker.topics = map[string][]int32{"orders": {1, 2, 1}}
+		})
+
+		It("succeeds if the topic exists and all partitions have leaders", func() {
+			Expect(check.Kafka(broker.addr()).WithTopic("orders").WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(broker.requestedTopics()).To(Equal([]string{"orders"}))
+		})
+
+		It("fails if the topic does not exist", func() {
+			Expect(check.Kafka(broker.addr()).WithTopic("billing").WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say(`topic 'billing': error 3 \(UNKNOWN_TOPIC_OR_PARTITION\)`))
+		})
+
+		Context("when a partition has no leader", func() {
+			BeforeEach(func() {
+				broker.topics["orders"][2] = -1
+			})
+
+			It("fails", func() {
+				Expect(check.Kafka(broker.addr()).WithTopic("orders").WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("partition 2 of topic 'orders' has no leader"))
+			})
+		})
+
+		Describe(".WithPartitions", func() {
+			It("succeeds if the topic has the given number of partitions", func() {
+				Expect(check.Kafka(broker.addr()).WithTopic("orders").WithPartitions(3).WithLogger(logger).IsReady()).To(BeTrue())
+			})
+
+			It("fails if the topic has too few partitions", func() {
+				Expect(check.Kafka(broker.addr()).WithTopic("orders").WithPartitions(6).WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("topic 'orders' has 3 partitions, expected 6"))
+			})
+
+			It("fails if the topic has too many partitions", func() {
+				Expect(check.Kafka(broker.addr()).WithTopic("orders").WithPartitions(2).WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("topic 'orders' has 3 partitions, expected 2"))
+			})
+		})
+	})
+})
+
+// fakeKafka answers ApiVersions v0 and Metadata v1 and v4 requests. Its
+// settings must be changed before it is started.
+type fakeKafka struct {
+	listener net.Listener
+
+	maxMetadata int16
+	controller  int32
+	topics      map[string][]int32
+	oversized   bool
+	hang        bool
+
+	mutex    sync.Mutex
+	received []string
+	topicsIn []string
+}
+
+func newFakeKafka() *fakeKafka {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	return &fakeKafka{listener: listener, maxMetadata: 12, controller: 1}
+}
+
+func (b *fakeKafka) start() {
+	go func() {
+		for {
+			conn, err := b.listener.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+}
+
+func (b *fakeKafka) addr() string {
+	return b.listener.Addr().String()
+}
+
+func (b *fakeKafka) close() {
+	b.listener.Close()
+}
+
+func (b *fakeKafka) requests() []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.received
+}
+
+func (b *fakeKafka) requestedTopics() []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.topicsIn
+}
+
+func (b *fakeKafka) serve(conn net.Conn) {
+	defer GinkgoRecover()
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	for {
+		var size int32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return
+		}
+
+		request := make([]byte, size)
+		if _, err := io.ReadFull(r, request); err != nil {
+			return
+		}
+
+		if b.hang {
+			time.Sleep(time.Second)
+			return
+		}
+
+		if b.oversized {
+			conn.Write([]byte{0xff, 0xff, 0xff, 0xff})
+			return
+		}
+
+		in := bytes.NewBuffer(request)
+
+		var (
+			key, version, clientLen int16
+			correlation             int32
+		)
+		binary.Read(in, binary.BigEndian, &key)
+		binary.Read(in, binary.BigEndian, &version)
+		binary.Read(in, binary.BigEndian, &correlation)
+		binary.Read(in, binary.BigEndian, &clientLen)
+		Expect(string(in.Next(int(clientLen)))).To(Equal("waitfor"))
+
+		var out bytes.Buffer
+		write := func(values ...interface{}) {
+			for _, v := range values {
+				if s, ok := v.(string); ok {
+					binary.Write(&out, binary.BigEndian, int16(len(s)))
+					out.WriteString(s)
+					continue
+				}
+				binary.Write(&out, binary.BigEndian, v)
+			}
+		}
+
+		write(correlation)
+
+		switch key {
+		case 18:
+			b.record("ApiVersions v", version)
+			write(int16(0), int32(2), int16(0), int16(0), int16(9), int16(3), int16(0), b.maxMetadata)
+		case 3:
+			b.record("Metadata v", version)
+
+			var n int32
+			binary.Read(in, binary.BigEndian, &n)
+
+			var topics []string
+			for ; n > 0; n-- {
+				var l int16
+				binary.Read(in, binary.BigEndian, &l)
+				topics = append(topics, string(in.Next(int(l))))
+			}
+
+			b.mutex.Lock()
+			b.topicsIn = topics
+			b.mutex.Unlock()
+
+			if version >= 4 {
+				autoCreate, _ := in.ReadByte()
+				Expect(autoCreate).To(BeZero())
+			}
+
+			if version >= 3 {
+				write(int32(0))
+			}
+
+			write(int32(1), int32(1), "localhost", int32(9092), int16(-1))
+
+			if version >= 2 {
+				write("cluster")
+			}
+
+			write(b.controller, int32(len(topics)))
+
+			for _, topic := range topics {
+				leaders, ok := b.topics[topic]
+				if !ok {
+					write(int16(3), topic, false, int32(0))
+					continue
+				}
+
+				write(int16(0), topic, false, int32(len(leaders)))
+				for partition, leader := range leaders {
+					write(int16(0), int32(partition), leader, int32(1), int32(1), int32(1), int32(1))
+				}
+			}
+		}
+
+		binary.Write(conn, binary.BigEndian, int32(out.Len()))
+		conn.Write(out.Bytes())
+	}
+}
+
+func (b *fakeKafka) record(request string, version int16) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.received = append(b.received, request+string('0'+rune(version)))
+}
@@ -0,0 +1,454 @@
+package check
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+type MongoCheck interface {
+	IsReady() bool
+	IsWritablePrimary() bool
+	HasPrimary() bool
+
+	WithTLS(*tls.Config) MongoCheck
+	WithTimeout(time.Duration) MongoCheck
+	WithLogger(io.Writer) MongoCheck
+}
+
+type mongocheck struct {
+	uri       string
+	tlsConfig *tls.Config
+	timeout   time.Duration
+	logger    io.Writer
+}
+
+// Mongo checks whether a MongoDB server answers the hello command. The URI
+// has the form 'mongodb://host1[:port1][,host2[:port2]...][/db][?options]',
+// hosts are tried in order. The options 'tls' or 'ssl' and 'tlsInsecure' are
+// honoured, credentials are ignored as hello does not require a login.
+func Mongo(uri string) MongoCheck {
+	return &mongocheck{
+		uri:     uri,
+		timeout: DefaultTimeout,
+		logger:  DefaultLogger,
+	}
+}
+
+// WithTLS connects using TLS. The server name defaults to the host being
+// tried.
+func (m *mongocheck) WithTLS(config *tls.Config) MongoCheck {
+	m.tlsConfig = config
+	return m
+}
+
+// WithTimeout limits the time an attempt to talk to a single host may take.
+func (m *mongocheck) WithTimeout(timeout time.Duration) MongoCheck {
+	m.timeout = timeout
+	return m
+}
+
+func (m *mongocheck) WithLogger(w io.Writer) MongoCheck {
+	m.logger = w
+	return m
+}
+
+// IsReady returns true once one of the hosts answers the hello command.
+func (m *mongocheck) IsReady() bool {
+	return m.check(func(mongoHello) error { return nil })
+}
+
+// IsWritablePrimary returns true once one of the hosts reports to be the
+// writable primary, which is always the case for a standalone server.
+func (m *mongocheck) IsWritablePrimary() bool {
+	return m.check(func(hello mongoHello) error {
+		if hello.writablePrimary {
+			return nil
+		}
+
+		if hello.setName != "" {
+			return fmt.Errorf("node is not the primary of replica set '%s'", hello.setName)
+		}
+
+		return errors.New("node is not a writable primary")
+	})
+}
+
+// HasPrimary returns true once one of the hosts is or knows the primary of
+// its replica set, i.e. the replica set has finished electing.
+func (m *mongocheck) HasPrimary() bool {
+	return m.check(func(hello mongoHello) error {
+		if hello.writablePrimary || hello.primary != "" {
+			return nil
+		}
+
+		if hello.setName != "" {
+			return fmt.Errorf("replica set '%s' has no primary", hello.setName)
+		}
+
+		return errors.New("node is not a member of an initiated replica set")
+	})
+}
+
+func (m *mongocheck) check(assert func(mongoHello) error) bool {
+	cfg, err := parseMongoURI(m.uri)
+	if err != nil {
+		fmt.Fprintln(m.logger, err.Error())
+		return false
+	}
+
+	for _, host := range cfg.hosts {
+		hello, err := m.hello(cfg, host)
+		if err == nil {
+			err = assert(hello)
+		}
+
+		if err == nil {
+			return true
+		}
+
+		fmt.Fprintln(m.logger, err.Error())
+	}
+
+	return false
+}
+
+func (m *mongocheck) hello(cfg mongoConfig, host string) (mongoHello, error) {
+	fmt.Fprintf(m.logger, "Connecting to mongodb://%s\n", host)
+
+	dialer := &net.Dialer{Timeout: m.timeout}
+
+	var (
+		raw net.Conn
+		err error
+	)
+
+	if config := m.tlsConfig; config != nil || cfg.tls {
+		if config == nil {
+			config = &tls.Config{InsecureSkipVerify: cfg.insecure}
+		}
+
+		config = config.Clone()
+		if config.ServerName == "" {
+			config.ServerName, _, _ = net.SplitHostPort(host)
+		}
+
+		raw, err = tls.DialWithDialer(dialer, "tcp", host, config)
+	} else {
+		raw, err = dialer.Dial("tcp", host)
+	}
+
+	if err != nil {
+		return mongoHello{}, err
+	}
+	defer raw.Close()
+
+	raw.SetDeadline(time.Now().Add(m.timeout))
+
+	conn := &mongoConn{Conn: raw, reader: bufio.NewReader(raw)}
+
+	reply, err := conn.command("hello")
+	if code, _ := bsonNumber(reply["code"]); err != nil && code == mongoCommandNotFound {
+		fmt.Fprintln(m.logger, "Server does not know hello, falling back to isMaster")
+		reply, err = conn.command("isMaster")
+	}
+
+	if err != nil {
+		return mongoHello{}, err
+	}
+
+	hello := mongoHello{}
+	hello.writablePrimary, _ = reply["isWritablePrimary"].(bool)
+	if ismaster, ok := reply["ismaster"].(bool); ok {
+		hello.writablePrimary = ismaster
+	}
+	hello.setName, _ = reply["setName"].(string)
+	hello.primary, _ = reply["primary"].(string)
+
+	return hello, nil
+}
+
+type mongoHello struct {
+	writablePrimary bool
+	setName         string
+	primary         string
+}
+
+type mongoConfig struct {
+	hosts    []string
+	tls      bool
+	insecure bool
+}
+
+func parseMongoURI(uri string) (mongoConfig, error) {
+	cfg := mongoConfig{}
+
+	rest := strings.TrimPrefix(uri, "mongodb://")
+	if rest == uri {
+		return cfg, errors.New("invalid URI: must start with 'mongodb://'")
+	}
+
+	var query string
+	if i := strings.Index(rest, "?"); i >= 0 {
+		rest, query = rest[:i], rest[i+1:]
+	}
+
+	if i := strings.Index(rest, "/"); i >= 0 {
+		rest = rest[:i]
+	}
+
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		rest = rest[i+1:]
+	}
+
+	if rest == "" {
+		rest = "localhost"
+	}
+
+	for _, host := range strings.Split(rest, ",") {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(strings.Trim(host, "[]"), "27017")
+		}
+		cfg.hosts = append(cfg.hosts, host)
+	}
+
+	options, err := neturl.ParseQuery(query)
+	if err != nil {
+		return cfg, fmt.Errorf("invalid URI: %s", err)
+	}
+
+	cfg.tls = options.Get("tls") == "true" || options.Get("ssl") == "true"
+	cfg.insecure = options.Get("tlsInsecure") == "true"
+
+	return cfg, nil
+}
+
+const (
+	mongoOpMsg           = 2013
+	mongoCommandNotFound = 59
+
+	// mongoMaxMessageSize is the default maxMessageSizeBytes of the server.
+	mongoMaxMessageSize = 48000000
+
+	// bsonMaxDepth is the nesting limit of documents the server enforces.
+	bsonMaxDepth = 100
+)
+
+// mongoConn speaks the client side of the OP_MSG wire protocol, available
+// since MongoDB 3.6.
+type mongoConn struct {
+	net.Conn
+	reader    *bufio.Reader
+	requestID int32
+}
+
+// command runs the given command against the admin database and returns the
+// reply. Replies other than ok are turned into errors.
+func (c *mongoConn) command(name string) (map[string]interface{}, error) {
+	c.requestID++
+
+	var body bytes.Buffer
+	binary.Write(&body, binary.LittleEndian, uint32(0)) // flagBits
+	body.WriteByte(0)                                   // section kind body
+	body.Write(bsonDocument(name, int32(1), "$db", "admin"))
+
+	var header bytes.Buffer
+	binary.Write(&header, binary.LittleEndian, int32(16+body.Len()))
+	binary.Write(&header, binary.LittleEndian, c.requestID)
+	binary.Write(&header, binary.LittleEndian, int32(0)) // responseTo
+	binary.Write(&header, binary.LittleEndian, int32(mongoOpMsg))
+
+	if _, err := c.Write(append(header.Bytes(), body.Bytes()...)); err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 16)
+	if _, err := io.ReadFull(c.reader, raw); err != nil {
+		return nil, err
+	}
+
+	length := int32(binary.LittleEndian.Uint32(raw))
+	responseTo := int32(binary.LittleEndian.Uint32(raw[8:]))
+	opCode := int32(binary.LittleEndian.Uint32(raw[12:]))
+
+	if length < 21 || responseTo != c.requestID || opCode != mongoOpMsg {
+		return nil, errors.New("invalid response")
+	}
+
+	if length > mongoMaxMessageSize {
+		return nil, fmt.Errorf("response of %d bytes exceeds the limit of %d bytes", length, mongoMaxMessageSize)
+	}
+
+	message := make([]byte, length-16)
+	if _, err := io.ReadFull(c.reader, message); err != nil {
+		return nil, err
+	}
+
+	// skip flagBits and expect a body section
+	if message[4] != 0 {
+		return nil, errors.New("invalid response")
+	}
+
+	reply, err := bsonDecode(message[5:], 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok, _ := bsonNumber(reply["ok"]); ok != 1 {
+		code, _ := bsonNumber(reply["code"])
+		errmsg, _ := reply["errmsg"].(string)
+		return reply, fmt.Errorf("%s failed: %s (code %d)", name, errmsg, int(code))
+	}
+
+	return reply, nil
+}
+
+// bsonDocument encodes the given pairs of names and values, which must be
+// strings or int32s, as a BSON document.
+func bsonDocument(pairs ...interface{}) []byte {
+	var elements bytes.Buffer
+
+	for i := 0; i+1 < len(pairs); i += 2 {
+		name := pairs[i].(string)
+
+		switch value := pairs[i+1].(type) {
+		case string:
+			elements.WriteByte(0x02)
+			elements.WriteString(name)
+			elements.WriteByte(0)
+			binary.Write(&elements, binary.LittleEndian, int32(len(value)+1))
+			elements.WriteString(value)
+			elements.WriteByte(0)
+		case int32:
+			elements.WriteByte(0x10)
+			elements.WriteString(name)
+			elements.WriteByte(0)
+			binary.Write(&elements, binary.LittleEndian, value)
+		}
+	}
+
+	doc := make([]byte, 4, 5+elements.Len())
+	binary.LittleEndian.PutUint32(doc, uint32(5+elements.Len()))
+	doc = append(doc, elements.Bytes()...)
+	return append(doc, 0)
+}
+
+// bsonDecode decodes a BSON document nested at the given depth into a map.
+// Embedded documents and arrays become maps themselves, values of types the
+// check does not need are skipped.
+func bsonDecode(data []byte, depth int) (map[string]interface{}, error) {
+	invalid := errors.New("invalid BSON document")
+
+	if depth > bsonMaxDepth {
+		return nil, fmt.Errorf("BSON document exceeds the nesting limit of %d", bsonMaxDepth)
+	}
+
+	if len(data) < 5 {
+		return nil, invalid
+	}
+
+	length := int(binary.LittleEndian.Uint32(data))
+	if length < 5 || length > len(data) || data[length-1] != 0 {
+		return nil, invalid
+	}
+
+	doc := make(map[string]interface{})
+	data = data[4 : length-1]
+
+	for len(data) > 0 {
+		kind := data[0]
+
+		end := bytes.IndexByte(data[1:], 0)
+		if end < 0 {
+			return nil, invalid
+		}
+
+		name := string(data[1 : 1+end])
+		data = data[2+end:]
+
+		var size int
+		switch kind {
+		case 0x01: // double
+			size = 8
+			if len(data) >= size {
+				doc[name] = math.Float64frombits(binary.LittleEndian.Uint64(data))
+			}
+		case 0x02: // string
+			if len(data) < 4 {
+				return nil, invalid
+			}
+			size = 4 + int(int32(binary.LittleEndian.Uint32(data)))
+			if size > 4 && len(data) >= size {
+				doc[name] = string(data[4 : size-1])
+			}
+		case 0x03, 0x04: // document, array
+			if len(data) < 4 {
+				return nil, invalid
+			}
+			size = int(int32(binary.LittleEndian.Uint32(data)))
+			embedded, err := bsonDecode(data, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			doc[name] = embedded
+		case 0x05: // binary
+			if len(data) < 4 {
+				return nil, invalid
+			}
+			size = 5 + int(int32(binary.LittleEndian.Uint32(data)))
+		case 0x07: // object id
+			size = 12
+		case 0x08: // boolean
+			size = 1
+			if len(data) >= size {
+				doc[name] = data[0] == 1
+			}
+		case 0x09, 0x11, 0x12: // datetime, timestamp, int64
+			size = 8
+			if len(data) >= size {
+				doc[name] = int64(binary.LittleEndian.Uint64(data))
+			}
+		case 0x0a: // null
+			doc[name] = nil
+		case 0x10: // int32
+			size = 4
+			if len(data) >= size {
+				doc[name] = int32(binary.LittleEndian.Uint32(data))
+			}
+		case 0x13: // decimal128
+			size = 16
+		default:
+			return nil, fmt.Errorf("unsupported BSON type 0x%02x of field '%s'", kind, name)
+		}
+
+		if size < 0 || size > len(data) {
+			return nil, invalid
+		}
+
+		data = data[size:]
+	}
+
+	return doc, nil
+}
+
+// bsonNumber returns the value of a numeric BSON field as a float.
+func bsonNumber(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
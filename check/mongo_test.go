@@ -0,0 +1,396 @@
+package check_test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("mongocheck", func() {
+	var (
+		server *fakeMongo
+		logger *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		server = newFakeMongo(nil)
+		logger = gbytes.NewBuffer()
+	})
+
+	JustBeforeEach(func() {
+		server.start()
+	})
+
+	AfterEach(func() {
+		server.close()
+	})
+
+	Describe(".IsReady", func() {
+		It("sends the hello command", func() {
+			Expect(check.Mongo("mongodb://" + server.addr()).WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(server.commands()).To(Equal([]string{"hello admin"}))
+			Expect(logger).To(gbytes.Say("Connecting to mongodb://" + server.addr()))
+		})
+
+		It("ignores credentials, the database and options", func() {
+			uri := "mongodb://app:secret@" + server.addr() + "/orders?replicaSet=rs0&authSource=admin"
+			Expect(check.Mongo(uri).WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(server.commands()).To(Equal([]string{"hello admin"}))
+		})
+
+		Context("when the server is older", func() {
+			BeforeEach(func() {
+				server.legacy = true
+			})
+
+			It("falls back to isMaster", func() {
+				Expect(check.Mongo("mongodb://" + server.addr()).WithLogger(logger).IsReady()).To(BeTrue())
+				Expect(server.commands()).To(Equal([]string{"hello admin", "isMaster admin"}))
+				Expect(logger).To(gbytes.Say("falling back to isMaster"))
+			})
+		})
+
+		It("tries the next host if one is not reachable", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			unreachable := listener.Addr().String()
+			listener.Close()
+
+			Expect(check.Mongo("mongodb://" + unreachable + "," + server.addr()).WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(logger).To(gbytes.Say("connection refused"))
+		})
+
+		Context("when the server does not answer", func() {
+			BeforeEach(func() {
+				server.hang = true
+			})
+
+			It("fails", func() {
+				Expect(check.Mongo("mongodb://" + server.addr()).WithTimeout(50 * time.Millisecond).WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("i/o timeout"))
+			})
+		})
+
+		Context("when the response is too large", func() {
+			BeforeEach(func() {
+				server.oversized = true
+			})
+
+			It("fails", func() {
+				Expect(check.Mongo("mongodb://" + server.addr()).WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("response of 48000001 bytes exceeds the limit of 48000000 bytes"))
+			})
+		})
+
+		Context("when the response is nested too deeply", func() {
+			BeforeEach(func() {
+				server.nested = 200
+			})
+
+			It("fails", func() {
+				Expect(check.Mongo("mongodb://" + server.addr()).WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("BSON document exceeds the nesting limit of 100"))
+			})
+		})
+
+		It("fails if the URI is invalid", func() {
+			Expect(check.Mongo("http://" + server.addr()).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("invalid URI: must start with 'mongodb://'"))
+		})
+	})
+
+	Describe(".IsWritablePrimary", func() {
+		It("succeeds for a standalone server", func() {
+			Expect(check.Mongo("mongodb://" + server.addr()).WithLogger(logger).IsWritablePrimary()).To(BeTrue())
+		})
+
+		Context("when the server is the primary of a replica set", func() {
+			BeforeEach(func() {
+				server.setName = "rs0"
+				server.primary = server.addr()
+			})
+
+			It("succeeds", func() {
+				Expect(check.Mongo("mongodb://" + server.addr()).WithLogger(logger).IsWritablePrimary()).To(BeTrue())
+			})
+		})
+
+		Context("when the server is older", func() {
+			BeforeEach(func() {
+				server.legacy = true
+			})
+
+			It("honours ismaster", func() {
+				Expect(check.Mongo("mongodb://" + server.addr()).WithLogger(logger).IsWritablePrimary()).To(BeTrue())
+			})
+		})
+
+		Context("when the server is a secondary", func() {
+			BeforeEach(func() {
+				server.secondary = true
+				server.setName = "rs0"
+				server.primary = "mongo-0:27017"
+			})
+
+			It("fails", func() {
+				Expect(check.Mongo("mongodb://" + server.addr()).WithLogger(logger).IsWritablePrimary()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("node is not the primary of replica set 'rs0'"))
+			})
+		})
+	})
+
+	Describe(".HasPrimary", func() {
+		BeforeEach(func() {
+			server.secondary = true
+			server.setName = "rs0"
+		})
+
+		Context("when the secondary knows the primary", func() {
+			BeforeEach(func() {
+				server.primary = "mongo-0:27017"
+			})
+
+			It("succeeds", func() {
+				Expect(check.Mongo("mongodb://" + server.addr()).WithLogger(logger).HasPrimary()).To(BeTrue())
+			})
+		})
+
+		It("fails while the replica set is electing", func() {
+			Expect(check.Mongo("mongodb://" + server.addr()).WithLogger(logger).HasPrimary()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("replica set 'rs0' has no primary"))
+		})
+
+		Context("when the replica set has not been initiated", func() {
+			BeforeEach(func() {
+				server.setName = ""
+			})
+
+			It("fails", func() {
+				Expect(check.Mongo("mongodb://" + server.addr()).WithLogger(logger).HasPrimary()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("node is not a member of an initiated replica set"))
+			})
+		})
+	})
+
+	Describe(".WithTLS", func() {
+		var pool *x509.CertPool
+
+		BeforeEach(func() {
+			server.close()
+
+			cert, ca := fakeCertificate()
+			pool = x509.NewCertPool()
+			pool.AddCert(ca)
+
+			server = newFakeMongo(&tls.Config{Certificates: []tls.Certificate{cert}})
+		})
+
+		It("connects using TLS", func() {
+			Expect(check.Mongo("mongodb://" + server.addr()).WithTLS(&tls.Config{RootCAs: pool}).WithLogger(logger).IsReady()).To(BeTrue())
+		})
+
+		It("honours the tls and tlsInsecure options", func() {
+			Expect(check.Mongo("mongodb://" + server.addr() + "/?tls=true&tlsInsecure=true").WithLogger(logger).IsReady()).To(BeTrue())
+		})
+
+		It("verifies the certificate", func() {
+			Expect(check.Mongo("mongodb://" + server.addr() + "/?tls=true").WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("certificate"))
+		})
+	})
+})
+
+// fakeMongo answers the hello and isMaster commands sent using OP_MSG. Its
+// settings must be changed before it is started.
+type fakeMongo struct {
+	listener net.Listener
+
+	legacy    bool
+	secondary bool
+	setName   string
+	primary   string
+	oversized bool
+	nested    int
+	hang      bool
+
+	mutex    sync.Mutex
+	received []string
+}
+
+func newFakeMongo(config *tls.Config) *fakeMongo {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	if config != nil {
+		listener = tls.NewListener(listener, config)
+	}
+
+	return &fakeMongo{listener: listener}
+}
+
+func (s *fakeMongo) start() {
+	go func() {
+		for {
+			conn, err := s.listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+}
+
+func (s *fakeMongo) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeMongo) close() {
+	s.listener.Close()
+}
+
+func (s *fakeMongo) commands() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.received
+}
+
+func (s *fakeMongo) serve(conn net.Conn) {
+	defer GinkgoRecover()
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	for {
+		header := make([]byte, 16)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return
+		}
+
+		Expect(binary.LittleEndian.Uint32(header[12:])).To(BeEquivalentTo(2013))
+
+		message := make([]byte, binary.LittleEndian.Uint32(header)-16)
+		if _, err := io.ReadFull(r, message); err != nil {
+			return
+		}
+
+		if s.hang {
+			time.Sleep(time.Second)
+			return
+		}
+
+		if s.oversized {
+			reply := make([]byte, 16)
+			binary.LittleEndian.PutUint32(reply, 48000001)
+			copy(reply[8:], header[4:8])
+			binary.LittleEndian.PutUint32(reply[12:], 2013)
+			conn.Write(reply)
+			return
+		}
+
+		// flagBits, section kind and the document, whose first element is
+		// the command, followed by '$db'
+		Expect(message[4]).To(BeZero())
+		doc := message[9:]
+
+		command := string(doc[1:bytes.IndexByte(doc, 0)])
+		db := string(doc[bytes.Index(doc, []byte("$db\x00"))+8 : len(doc)-2])
+
+		s.mutex.Lock()
+		s.received = append(s.received, command+" "+db)
+		s.mutex.Unlock()
+
+		var reply []byte
+		switch {
+		case command == "hello" && s.legacy:
+			reply = fakeBSON("ok", 0.0, "errmsg", "no such command: 'hello'", "code", int32(59))
+		case command == "hello":
+			reply = s.reply("isWritablePrimary")
+		case command == "isMaster":
+			reply = s.reply("ismaster")
+		}
+
+		var out bytes.Buffer
+		binary.Write(&out, binary.LittleEndian, int32(16+5+len(reply)))
+		binary.Write(&out, binary.LittleEndian, int32(1))
+		out.Write(header[4:8])
+		binary.Write(&out, binary.LittleEndian, int32(2013))
+		binary.Write(&out, binary.LittleEndian, uint32(0))
+		out.WriteByte(0)
+		out.Write(reply)
+
+		conn.Write(out.Bytes())
+	}
+}
+
+func (s *fakeMongo) reply(writable string) []byte {
+	pairs := []interface{}{writable, !s.secondary, "secondary", s.secondary, "maxWireVersion", int32(17)}
+
+	if s.setName != "" {
+		pairs = append(pairs, "setName", s.setName, "hosts", fakeBSON("0", s.addr()))
+	}
+
+	if s.primary != "" {
+		pairs = append(pairs, "primary", s.primary)
+	}
+
+	if s.nested > 0 {
+		nested := fakeBSON()
+		for i := 0; i < s.nested; i++ {
+			nested = fakeBSON("0", nested)
+		}
+		pairs = append(pairs, "nested", nested)
+	}
+
+	return fakeBSON(append(pairs, "ok", 1.0)...)
+}
+
+// fakeBSON encodes pairs of names and values as a BSON document. Values that
+// are byte slices are embedded as arrays.
+func fakeBSON(pairs ...interface{}) []byte {
+	var elements bytes.Buffer
+
+	for i := 0; i < len(pairs); i += 2 {
+		var kind byte
+		var value bytes.Buffer
+
+		switch v := pairs[i+1].(type) {
+		case string:
+			kind = 0x02
+			binary.Write(&value, binary.LittleEndian, int32(len(v)+1))
+			value.WriteString(v + "\x00")
+		case bool:
+			kind = 0x08
+			binary.Write(&value, binary.LittleEndian, v)
+		case float64:
+			kind = 0x01
+			binary.Write(&value, binary.LittleEndian, math.Float64bits(v))
+		case int32:
+			kind = 0x10
+			binary.Write(&value, binary.LittleEndian, v)
+		case []byte:
+			kind = 0x04
+			value.Write(v)
+		}
+
+		elements.WriteByte(kind)
+		elements.WriteString(pairs[i].(string) + "\x00")
+		elements.Write(value.Bytes())
+	}
+
+	doc := make([]byte, 4)
+	binary.LittleEndian.PutUint32(doc, uint32(elements.Len()+5))
+	return append(append(doc, elements.Bytes()...), 0)
+}
@@ -0,0 +1,534 @@
+package check
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+type MySQLCheck interface {
+	IsReady() bool
+
+	WithPing() MySQLCheck
+	WithQuery(string) MySQLCheck
+	WithTimeout(time.Duration) MySQLCheck
+	WithLogger(io.Writer) MySQLCheck
+}
+
+type mysqlcheck struct {
+	dsn     string
+	ping    bool
+	query   string
+	timeout time.Duration
+	logger  io.Writer
+}
+
+// MySQL checks whether a MySQL or MariaDB server accepts logins. The DSN is
+// either in the format of the Go MySQL driver, e.g.
+// 'user:secret@tcp(db:3306)/app', or a URL, e.g. 'mysql://user:secret@db/app'.
+func MySQL(dsn string) MySQLCheck {
+	return &mysqlcheck{
+		dsn:     dsn,
+		timeout: DefaultTimeout,
+		logger:  DefaultLogger,
+	}
+}
+
+// WithPing sends a ping once logged in.
+func (m *mysqlcheck) WithPing() MySQLCheck {
+	m.ping = true
+	return m
+}
+
+// WithQuery runs the given query, e.g. 'SELECT 1', once logged in. The check
+// fails if the query fails.
+func (m *mysqlcheck) WithQuery(query string) MySQLCheck {
+	m.query = query
+	return m
+}
+
+// WithTimeout limits the time a single attempt, i.e. connecting, the
+// handshake and any commands, may take.
+func (m *mysqlcheck) WithTimeout(timeout time.Duration) MySQLCheck {
+	m.timeout = timeout
+	return m
+}
+
+func (m *mysqlcheck) WithLogger(w io.Writer) MySQLCheck {
+	m.logger = w
+	return m
+}
+
+// IsReady returns true once the server accepts the login and the ping and
+// query, if any, succeed.
+func (m *mysqlcheck) IsReady() bool {
+	if err := m.run(); err != nil {
+		fmt.Fprintln(m.logger, err.Error())
+		return false
+	}
+	return true
+}
+
+func (m *mysqlcheck) run() error {
+	cfg, err := parseMySQLDSN(m.dsn)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(m.logger, "Connecting to mysql://%s@%s/%s\n", cfg.user, cfg.addr, cfg.database)
+
+	raw, err := net.DialTimeout(cfg.network, cfg.addr, m.timeout)
+	if err != nil {
+		return err
+	}
+	defer raw.Close()
+
+	raw.SetDeadline(time.Now().Add(m.timeout))
+
+	conn := &mysqlConn{Conn: raw, reader: bufio.NewReader(raw)}
+
+	if err := conn.login(cfg); err != nil {
+		return err
+	}
+	defer conn.command(mysqlQuit, nil)
+
+	if m.ping {
+		fmt.Fprintln(m.logger, "Sending ping")
+		if err := conn.exec(mysqlPing, nil); err != nil {
+			return err
+		}
+	}
+
+	if m.query != "" {
+		fmt.Fprintf(m.logger, "Running query '%s'\n", m.query)
+		if err := conn.exec(mysqlQuery, []byte(m.query)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type mysqlConfig struct {
+	network  string
+	addr     string
+	user     string
+	password string
+	database string
+}
+
+func parseMySQLDSN(dsn string) (mysqlConfig, error) {
+	cfg := mysqlConfig{network: "tcp", addr: "127.0.0.1:3306", user: "root"}
+
+	if strings.HasPrefix(dsn, "mysql://") || strings.HasPrefix(dsn, "mariadb://") {
+		u, err := neturl.Parse(dsn)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid DSN: %s", err)
+		}
+
+		if u.Host != "" {
+			cfg.addr = u.Host
+			if u.Port() == "" {
+				cfg.addr = net.JoinHostPort(u.Hostname(), "3306")
+			}
+		}
+
+		if u.User != nil {
+			cfg.user = u.User.Username()
+			cfg.password, _ = u.User.Password()
+		}
+
+		cfg.database = strings.TrimPrefix(u.Path, "/")
+		return cfg, nil
+	}
+
+	// [user[:password]@][net[(addr)]]/dbname[?param1=value1&paramN=valueN]
+	slash := strings.LastIndex(dsn, "/")
+	if slash < 0 {
+		return cfg, errors.New("invalid DSN: missing '/' before the database name")
+	}
+
+	cfg.database = dsn[slash+1:]
+	if i := strings.Index(cfg.database, "?"); i >= 0 {
+		cfg.database = cfg.database[:i]
+	}
+
+	rest := dsn[:slash]
+	if at := strings.LastIndex(rest, "@"); at >= 0 {
+		credentials := rest[:at]
+		rest = rest[at+1:]
+
+		cfg.user = credentials
+		if i := strings.Index(credentials, ":"); i >= 0 {
+			cfg.user, cfg.password = credentials[:i], credentials[i+1:]
+		}
+	}
+
+	if rest == "" {
+		return cfg, nil
+	}
+
+	open := strings.Index(rest, "(")
+	if open < 0 {
+		cfg.network = rest
+	} else {
+		if !strings.HasSuffix(rest, ")") {
+			return cfg, errors.New("invalid DSN: missing ')' after the address")
+		}
+		cfg.network, cfg.addr = rest[:open], rest[open+1:len(rest)-1]
+	}
+
+	switch cfg.network {
+	case "tcp", "tcp4", "tcp6":
+		if _, _, err := net.SplitHostPort(cfg.addr); err != nil {
+			cfg.addr = net.JoinHostPort(cfg.addr, "3306")
+		}
+	case "unix":
+	default:
+		return cfg, fmt.Errorf("invalid DSN: unsupported network '%s'", cfg.network)
+	}
+
+	return cfg, nil
+}
+
+const (
+	mysqlClientLongPassword     = 0x00000001
+	mysqlClientConnectWithDB    = 0x00000008
+	mysqlClientProtocol41       = 0x00000200
+	mysqlClientSecureConnection = 0x00008000
+	mysqlClientPluginAuth       = 0x00080000
+
+	mysqlQuit  = 0x01
+	mysqlQuery = 0x03
+	mysqlPing  = 0x0e
+
+	mysqlNativePassword = "mysql_native_password"
+	mysqlCachingSHA2    = "caching_sha2_password"
+)
+
+// mysqlConn speaks the client side of the MySQL client/server protocol.
+type mysqlConn struct {
+	net.Conn
+	reader *bufio.Reader
+	seq    byte
+}
+
+// login reads the initial handshake of the server and authenticates.
+func (c *mysqlConn) login(cfg mysqlConfig) error {
+	handshake, err := c.receive()
+	if err != nil {
+		return err
+	}
+
+	if handshake[0] == 0xff {
+		return mysqlError(handshake)
+	}
+
+	if handshake[0] != 10 {
+		return fmt.Errorf("unsupported protocol version %d", handshake[0])
+	}
+
+	r := bytes.NewBuffer(handshake[1:])
+	if _, err := r.ReadString(0); err != nil {
+		return errors.New("invalid handshake")
+	}
+
+	// connection id, first part of the scramble and filler
+	fixed := r.Next(13)
+	if len(fixed) < 13 {
+		return errors.New("invalid handshake")
+	}
+	scramble := append([]byte{}, fixed[4:12]...)
+
+	lower := r.Next(2)
+	if len(lower) < 2 {
+		return errors.New("invalid handshake")
+	}
+
+	capabilities := uint32(binary.LittleEndian.Uint16(lower))
+	plugin := mysqlNativePassword
+
+	// character set, status, upper capabilities, scramble length, reserved
+	if rest := r.Next(16); len(rest) == 16 {
+		capabilities |= uint32(binary.LittleEndian.Uint16(rest[3:5])) << 16
+
+		if capabilities&mysqlClientSecureConnection != 0 {
+			n := int(rest[5]) - 9
+			if n < 12 {
+				n = 12
+			}
+			scramble = append(scramble, r.Next(n)...)
+			r.Next(1)
+		}
+
+		if capabilities&mysqlClientPluginAuth != 0 {
+			if name, err := r.ReadString(0); err == nil {
+				plugin = strings.TrimSuffix(name, "\x00")
+			}
+		}
+	}
+
+	if capabilities&mysqlClientProtocol41 == 0 {
+		return errors.New("server does not support protocol 4.1")
+	}
+
+	flags := uint32(mysqlClientLongPassword | mysqlClientProtocol41 | mysqlClientSecureConnection | mysqlClientPluginAuth)
+	if cfg.database != "" {
+		flags |= mysqlClientConnectWithDB
+	}
+
+	authResponse, err := mysqlAuthResponse(plugin, cfg.password, scramble)
+	if err != nil {
+		return err
+	}
+
+	var response bytes.Buffer
+	binary.Write(&response, binary.LittleEndian, flags)
+	binary.Write(&response, binary.LittleEndian, uint32(1<<24-1))
+	response.WriteByte(45) // utf8mb4_general_ci
+	response.Write(make([]byte, 23))
+	response.Write(cstring(cfg.user))
+	response.WriteByte(byte(len(authResponse)))
+	response.Write(authResponse)
+	if cfg.database != "" {
+		response.Write(cstring(cfg.database))
+	}
+	response.Write(cstring(plugin))
+
+	if err := c.send(response.Bytes()); err != nil {
+		return err
+	}
+
+	return c.authenticate(cfg, plugin, scramble)
+}
+
+// authenticate handles the responses of the server to the login until it is
+// either accepted or rejected.
+func (c *mysqlConn) authenticate(cfg mysqlConfig, plugin string, scramble []byte) error {
+	for {
+		packet, err := c.receive()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case packet[0] == 0x00:
+			return nil
+		case packet[0] == 0xff:
+			return mysqlError(packet)
+		case packet[0] == 0xfe:
+			// auth switch request
+			r := bytes.NewBuffer(packet[1:])
+			name, err := r.ReadString(0)
+			if err != nil {
+				return errors.New("invalid auth switch request")
+			}
+
+			plugin = strings.TrimSuffix(name, "\x00")
+			scramble = bytes.TrimSuffix(r.Bytes(), []byte{0})
+
+			response, err := mysqlAuthResponse(plugin, cfg.password, scramble)
+			if err != nil {
+				return err
+			}
+
+			if err := c.send(response); err != nil {
+				return err
+			}
+		case packet[0] == 0x01 && plugin == mysqlCachingSHA2 && len(packet) == 2 && packet[1] == 0x03:
+			// fast authentication succeeded, the OK packet follows
+		case packet[0] == 0x01 && plugin == mysqlCachingSHA2 && len(packet) == 2 && packet[1] == 0x04:
+			// full authentication requires the password encrypted with the
+			// public key of the server
+			if err := c.send([]byte{0x02}); err != nil {
+				return err
+			}
+		case packet[0] == 0x01 && plugin == mysqlCachingSHA2:
+			encrypted, err := mysqlEncryptPassword(cfg.password, scramble, packet[1:])
+			if err != nil {
+				return err
+			}
+
+			if err := c.send(encrypted); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected packet 0x%02x during authentication", packet[0])
+		}
+	}
+}
+
+func mysqlAuthResponse(plugin, password string, scramble []byte) ([]byte, error) {
+	if password == "" {
+		return nil, nil
+	}
+
+	if len(scramble) < 20 {
+		return nil, errors.New("invalid scramble")
+	}
+
+	switch plugin {
+	case mysqlNativePassword:
+		// SHA1(password) XOR SHA1(scramble + SHA1(SHA1(password)))
+		stage1 := sha1.Sum([]byte(password))
+		stage2 := sha1.Sum(stage1[:])
+
+		h := sha1.New()
+		h.Write(scramble[:20])
+		h.Write(stage2[:])
+
+		return xor(stage1[:], h.Sum(nil)), nil
+	case mysqlCachingSHA2:
+		// SHA256(password) XOR SHA256(SHA256(SHA256(password)) + scramble)
+		stage1 := sha256.Sum256([]byte(password))
+		stage2 := sha256.Sum256(stage1[:])
+
+		h := sha256.New()
+		h.Write(stage2[:])
+		h.Write(scramble[:20])
+
+		return xor(stage1[:], h.Sum(nil)), nil
+	}
+
+	return nil, fmt.Errorf("unsupported authentication plugin '%s'", plugin)
+}
+
+func mysqlEncryptPassword(password string, scramble, key []byte) ([]byte, error) {
+	if len(scramble) == 0 {
+		return nil, errors.New("invalid scramble")
+	}
+
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, errors.New("invalid public key")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaKey, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("invalid public key")
+	}
+
+	plain := cstring(password)
+	for i := range plain {
+		plain[i] ^= scramble[i%len(scramble)]
+	}
+
+	return rsa.EncryptOAEP(sha1.New(), rand.Reader, rsaKey, plain, nil)
+}
+
+// exec sends a command and reads its response, skipping any result set.
+func (c *mysqlConn) exec(command byte, data []byte) error {
+	if err := c.command(command, data); err != nil {
+		return err
+	}
+
+	packet, err := c.receive()
+	if err != nil {
+		return err
+	}
+
+	switch packet[0] {
+	case 0x00:
+		return nil
+	case 0xff:
+		return mysqlError(packet)
+	}
+
+	// column definitions and rows, each terminated by an EOF packet
+	for eofs := 0; eofs < 2; {
+		packet, err := c.receive()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case packet[0] == 0xff:
+			return mysqlError(packet)
+		case packet[0] == 0xfe && len(packet) < 9:
+			eofs++
+		}
+	}
+
+	return nil
+}
+
+func (c *mysqlConn) command(command byte, data []byte) error {
+	c.seq = 0
+	return c.send(append([]byte{command}, data...))
+}
+
+func (c *mysqlConn) send(payload []byte) error {
+	header := make([]byte, 4)
+	header[0] = byte(len(payload))
+	header[1] = byte(len(payload) >> 8)
+	header[2] = byte(len(payload) >> 16)
+	header[3] = c.seq
+	c.seq++
+
+	_, err := c.Write(append(header, payload...))
+	return err
+}
+
+func (c *mysqlConn) receive() ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return nil, err
+	}
+
+	size := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+	c.seq = header[3] + 1
+
+	if size == 0 {
+		return nil, errors.New("empty packet")
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// mysqlError turns an ERR packet into an error, e.g. 'Error 1045 (28000):
+// Access denied for user'.
+func mysqlError(packet []byte) error {
+	if len(packet) < 3 {
+		return errors.New("invalid error packet")
+	}
+
+	code := binary.LittleEndian.Uint16(packet[1:3])
+	msg := string(packet[3:])
+
+	if strings.HasPrefix(msg, "#") && len(msg) >= 6 {
+		return fmt.Errorf("Error %d (%s): %s", code, msg[1:6], msg[6:])
+	}
+
+	return fmt.Errorf("Error %d: %s", code, msg)
+}
+
+func xor(a, b []byte) []byte {
+	result := make([]byte, len(a))
+	for i := range a {
+		result[i] = a[i] ^ b[i]
+	}
+	return result
+}
@@ -0,0 +1,389 @@
+package check_test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("mysqlcheck", func() {
+	var (
+		server *fakeMySQL
+		logger *gbytes.Buffer
+		dsn    string
+	)
+
+	BeforeEach(func() {
+		server = newFakeMySQL()
+		logger = gbytes.NewBuffer()
+		dsn = fmt.Sprintf("app:secret@tcp(%s)/appdb", server.addr())
+	})
+
+	JustBeforeEach(func() {
+		server.start()
+	})
+
+	AfterEach(func() {
+		server.close()
+	})
+
+	for _, plugin := range []string{"mysql_native_password", "caching_sha2_password"} {
+		plugin := plugin
+
+		Context(fmt.Sprintf("when the server uses %s", plugin), func() {
+			BeforeEach(func() {
+				server.plugin = plugin
+			})
+
+			It("logs in", func() {
+				Expect(check.MySQL(dsn).WithLogger(logger).IsReady()).To(BeTrue())
+				Expect(server.login()).To(Equal([]string{"app", "appdb"}))
+			})
+
+			It("fails if the password is wrong", func() {
+				dsn = strings.Replace(dsn, "secret", "wrong", 1)
+				Expect(check.MySQL(dsn).WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say(`Error 1045 \(28000\): Access denied for user 'app'`))
+			})
+		})
+	}
+
+	Context("when the server requires full caching_sha2_password authentication", func() {
+		BeforeEach(func() {
+			server.plugin = "caching_sha2_password"
+			server.fullAuth = true
+		})
+
+		It("sends the password encrypted with the public key of the server", func() {
+			Expect(check.MySQL(dsn).WithLogger(logger).IsReady()).To(BeTrue())
+		})
+	})
+
+	Context("when the server asks to switch the authentication plugin", func() {
+		BeforeEach(func() {
+			server.plugin = "caching_sha2_password"
+			server.switchTo = "mysql_native_password"
+		})
+
+		It("authenticates with the requested plugin", func() {
+			Expect(check.MySQL(dsn).WithLogger(logger).IsReady()).To(BeTrue())
+		})
+	})
+
+	Context("when the server switches the authentication plugin without a scramble", func() {
+		BeforeEach(func() {
+			server.plugin = "caching_sha2_password"
+			server.switchTo = "caching_sha2_password"
+			server.noScramble = true
+			server.fullAuth = true
+			server.password = ""
+			dsn = fmt.Sprintf("app@tcp(%s)/appdb", server.addr())
+		})
+
+		It("returns false", func() {
+			Expect(check.MySQL(dsn).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("invalid scramble"))
+		})
+	})
+
+	Context("when the DSN is a URL", func() {
+		It("uses the given settings", func() {
+			dsn = fmt.Sprintf("mysql://app:secret@%s/other", server.addr())
+			Expect(check.MySQL(dsn).WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(server.login()).To(Equal([]string{"app", "other"}))
+		})
+	})
+
+	Context("when the DSN is invalid", func() {
+		It("returns false", func() {
+			Expect(check.MySQL("app@udp(db)/app").WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("invalid DSN: unsupported network 'udp'"))
+		})
+	})
+
+	Context("when the server rejects the connection", func() {
+		BeforeEach(func() {
+			server.reject = true
+		})
+
+		It("returns false", func() {
+			Expect(check.MySQL(dsn).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say(`Error 1040 \(08004\): Too many connections`))
+		})
+	})
+
+	Context("when nothing is listening", func() {
+		It("returns false", func() {
+			server.close()
+			Expect(check.MySQL(dsn).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("Connecting to mysql://app@" + server.addr() + "/appdb"))
+		})
+	})
+
+	Describe(".WithPing", func() {
+		It("sends a ping", func() {
+			Expect(check.MySQL(dsn).WithPing().WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(server.commands()).To(ContainElement("ping"))
+		})
+	})
+
+	Describe(".WithQuery", func() {
+		It("runs the query", func() {
+			Expect(check.MySQL(dsn).WithQuery("SELECT 1").WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(server.commands()).To(ContainElement("SELECT 1"))
+		})
+
+		It("fails if the query fails", func() {
+			Expect(check.MySQL(dsn).WithQuery("SELECT broken").WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say(`Error 1054 \(42S22\): Unknown column 'broken'`))
+		})
+	})
+})
+
+var fakeMySQLKey *rsa.PrivateKey
+
+// fakeMySQL speaks just enough of the server side of the MySQL protocol to
+// test the check. Its settings must be changed before it is started.
+type fakeMySQL struct {
+	listener net.Listener
+
+	plugin     string
+	switchTo   string
+	noScramble bool
+	fullAuth   bool
+	reject     bool
+	password   string
+
+	mutex    sync.Mutex
+	user     string
+	database string
+	received []string
+}
+
+func newFakeMySQL() *fakeMySQL {
+	if fakeMySQLKey == nil {
+		var err error
+		fakeMySQLKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	return &fakeMySQL{listener: listener, plugin: "mysql_native_password", password: "secret"}
+}
+
+func (s *fakeMySQL) start() {
+	go func() {
+		for {
+			conn, err := s.listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+}
+
+func (s *fakeMySQL) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeMySQL) close() {
+	s.listener.Close()
+}
+
+func (s *fakeMySQL) login() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return []string{s.user, s.database}
+}
+
+func (s *fakeMySQL) commands() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.received
+}
+
+func (s *fakeMySQL) serve(conn net.Conn) {
+	defer GinkgoRecover()
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	seq := byte(0)
+
+	send := func(payload []byte) {
+		header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), seq}
+		seq++
+		conn.Write(append(header, payload...))
+	}
+
+	receive := func() []byte {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil
+		}
+		seq = header[3] + 1
+		payload := make([]byte, int(header[0])|int(header[1])<<8|int(header[2])<<16)
+		io.ReadFull(r, payload)
+		return payload
+	}
+
+	fail := func(code uint16, state, msg string) {
+		payload := []byte{0xff, byte(code), byte(code >> 8)}
+		send(append(payload, "#"+state+msg...))
+	}
+
+	if s.reject {
+		fail(1040, "08004", "Too many connections")
+		return
+	}
+
+	scramble := []byte("abcdefghijklmnopqrst")
+
+	var handshake bytes.Buffer
+	handshake.WriteByte(10)
+	handshake.WriteString("8.0.36\x00")
+	handshake.Write([]byte{1, 0, 0, 0})
+	handshake.Write(scramble[:8])
+	handshake.WriteByte(0)
+	handshake.Write([]byte{0x00, 0x82}) // protocol 41, secure connection
+	handshake.WriteByte(45)
+	handshake.Write([]byte{2, 0})
+	handshake.Write([]byte{0x08, 0x00}) // plugin auth
+	handshake.WriteByte(21)
+	handshake.Write(make([]byte, 10))
+	handshake.Write(scramble[8:])
+	handshake.WriteByte(0)
+	handshake.WriteString(s.plugin + "\x00")
+	send(handshake.Bytes())
+
+	response := bytes.NewBuffer(receive()[32:])
+	user, _ := response.ReadString(0)
+	authLen, _ := response.ReadByte()
+	auth := response.Next(int(authLen))
+	database, _ := response.ReadString(0)
+
+	s.mutex.Lock()
+	s.user = strings.TrimSuffix(user, "\x00")
+	s.database = strings.TrimSuffix(database, "\x00")
+	s.mutex.Unlock()
+
+	plugin := s.plugin
+	if s.switchTo != "" {
+		plugin = s.switchTo
+		if s.noScramble {
+			scramble = nil
+		}
+		send(append([]byte("\xfe"+plugin+"\x00"), append(scramble, 0)...))
+		auth = receive()
+	}
+
+	ok := bytes.Equal(auth, fakeMySQLAuth(plugin, s.password, scramble))
+	if s.password == "" {
+		ok = len(auth) == 0
+	}
+
+	if ok && plugin == "caching_sha2_password" {
+		if !s.fullAuth {
+			send([]byte{0x01, 0x03})
+		} else {
+			send([]byte{0x01, 0x04})
+			Expect(receive()).To(Equal([]byte{0x02}))
+
+			der, err := x509.MarshalPKIXPublicKey(&fakeMySQLKey.PublicKey)
+			Expect(err).ToNot(HaveOccurred())
+			send(append([]byte{0x01}, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})...))
+
+			encrypted := receive()
+			if encrypted == nil {
+				return
+			}
+
+			plain, err := rsa.DecryptOAEP(sha1.New(), nil, fakeMySQLKey, encrypted, nil)
+			Expect(err).ToNot(HaveOccurred())
+			for i := range plain {
+				plain[i] ^= scramble[i%len(scramble)]
+			}
+			ok = string(plain) == s.password+"\x00"
+		}
+	}
+
+	if !ok {
+		fail(1045, "28000", fmt.Sprintf("Access denied for user '%s'@'localhost' (using password: YES)", s.user))
+		return
+	}
+
+	send([]byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00})
+
+	for {
+		packet := receive()
+		if packet == nil || packet[0] == 0x01 {
+			return
+		}
+
+		command := "ping"
+		if packet[0] == 0x03 {
+			command = string(packet[1:])
+		}
+
+		s.mutex.Lock()
+		s.received = append(s.received, command)
+		s.mutex.Unlock()
+
+		switch command {
+		case "ping":
+			send([]byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00})
+		case "SELECT broken":
+			fail(1054, "42S22", "Unknown column 'broken' in 'field list'")
+		default:
+			eof := []byte{0xfe, 0x00, 0x00, 0x02, 0x00}
+			send([]byte{0x01})
+			send([]byte("\x03def\x00\x00\x00\x011\x00\x0c\x3f\x00\x01\x00\x00\x00\x08\x81\x00\x00\x00\x00"))
+			send(eof)
+			send([]byte{0x01, '1'})
+			send(eof)
+		}
+	}
+}
+
+func fakeMySQLAuth(plugin, password string, scramble []byte) []byte {
+	xor := func(a, b []byte) []byte {
+		for i := range a {
+			a[i] ^= b[i]
+		}
+		return a
+	}
+
+	if plugin == "mysql_native_password" {
+		stage1 := sha1.Sum([]byte(password))
+		stage2 := sha1.Sum(stage1[:])
+		return xor(stage1[:], sha1Sum(append(append([]byte{}, scramble...), stage2[:]...)))
+	}
+
+	stage1 := sha256.Sum256([]byte(password))
+	stage2 := sha256.Sum256(stage1[:])
+	hash := sha256.Sum256(append(stage2[:], scramble...))
+	return xor(stage1[:], hash[:])
+}
+
+func sha1Sum(data []byte) []byte {
+	sum := sha1.Sum(data)
+	return sum[:]
+}
@@ -5,12 +5,20 @@ import (
 	"io"
 	"io/ioutil"
 	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
 var (
-	DefaultHost    = "127.0.0.1"
-	DefaultNetwork = "tcp"
-	DefaultLogger  = ioutil.Discard
+	DefaultHost         = "127.0.0.1"
+	DefaultNetwork      = "tcp"
+	DefaultLogger       = ioutil.Discard
+	DefaultPayload      = []byte{}
+	DefaultReplyTimeout = 1 * time.Second
+	DefaultTimeout      = 5 * time.Second
 )
 
 type PortCheck interface {
@@ -19,22 +27,38 @@ type PortCheck interface {
 
 	OnHost(string) PortCheck
 	ForNetwork(string) PortCheck
+	WithPayload([]byte) PortCheck
+	WithReplyTimeout(time.Duration) PortCheck
+	WithNoReplyOK(bool) PortCheck
 	WithLogger(io.Writer) PortCheck
 }
 
 type portcheck struct {
-	port    int
-	host    string
-	network string
-	logger  io.Writer
+	port         int
+	host         string
+	network      string
+	payload      []byte
+	replyTimeout time.Duration
+	noReplyOK    bool
+	logger       io.Writer
 }
 
+type portState int
+
+const (
+	portOpen portState = iota
+	portClosed
+	portUnknown
+)
+
 func Port(p int) PortCheck {
 	return &portcheck{
-		port:    p,
-		host:    DefaultHost,
-		network: DefaultNetwork,
-		logger:  DefaultLogger,
+		port:         p,
+		host:         DefaultHost,
+		network:      DefaultNetwork,
+		payload:      DefaultPayload,
+		replyTimeout: DefaultReplyTimeout,
+		logger:       DefaultLogger,
 	}
 }
 
@@ -48,27 +72,112 @@ func (p *portcheck) ForNetwork(network string) PortCheck {
 	return p
 }
 
+func (p *portcheck) WithPayload(payload []byte) PortCheck {
+	p.payload = payload
+	return p
+}
+
+func (p *portcheck) WithReplyTimeout(timeout time.Duration) PortCheck {
+	p.replyTimeout = timeout
+	return p
+}
+
+// WithNoReplyOK treats a UDP probe that goes unanswered within the reply
+// timeout as open, i.e. open|filtered, unless the port is positively closed.
+// Services like StatsD or syslog never reply to datagrams.
+func (p *portcheck) WithNoReplyOK(ok bool) PortCheck {
+	p.noReplyOK = ok
+	return p
+}
+
 func (p *portcheck) WithLogger(w io.Writer) PortCheck {
 	p.logger = w
 	return p
 }
 
 func (p *portcheck) IsOpen() bool {
+	return p.state() == portOpen
+}
+
+// IsClosed only returns true if the port has been positively identified as
+// closed. For UDP this means the probe was rejected with an ICMP port
+// unreachable, a probe that goes unanswered is neither open nor closed.
+func (p *portcheck) IsClosed() bool {
+	return p.state() == portClosed
+}
+
+func (p *portcheck) state() portState {
 	fmt.Fprintf(p.logger, "Dialing %s://%s\n", p.network, p.addr())
 
 	conn, err := net.Dial(p.network, p.addr())
 	if err != nil {
-		return false
+		fmt.Fprintln(p.logger, err.Error())
+		return portClosed
+	}
+	defer conn.Close()
+
+	if !p.isUDP() {
+		return portOpen
 	}
 
-	conn.Close()
-	return true
+	return p.probe(conn)
 }
 
-func (p *portcheck) IsClosed() bool {
-	return !p.IsOpen()
+// probe sends the payload as a single datagram and waits for a reply. UDP is
+// connectionless, a successful dial therefore does not tell us anything about
+// the remote port. A closed port results in an ICMP port unreachable message
+// which surfaces as ECONNREFUSED on either the write or the subsequent read.
+func (p *portcheck) probe(conn net.Conn) portState {
+	fmt.Fprintf(p.logger, "Sending %d byte probe to %s://%s\n", len(p.payload), p.network, p.addr())
+
+	if _, err := conn.Write(p.payload); err != nil {
+		return p.probeErr(err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(p.replyTimeout)); err != nil {
+		return p.probeErr(err)
+	}
+
+	buf := make([]byte, 1)
+	if _, err := conn.Read(buf); err != nil {
+		return p.probeErr(err)
+	}
+
+	fmt.Fprintln(p.logger, "Received reply")
+	return portOpen
+}
+
+func (p *portcheck) probeErr(err error) portState {
+	fmt.Fprintln(p.logger, err.Error())
+
+	if isConnRefused(err) {
+		return portClosed
+	}
+
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() && p.noReplyOK {
+		fmt.Fprintln(p.logger, "No reply, assuming open|filtered")
+		return portOpen
+	}
+
+	return portUnknown
+}
+
+func (p *portcheck) isUDP() bool {
+	return strings.HasPrefix(p.network, "udp")
 }
 
 func (p *portcheck) addr() string {
-	return fmt.Sprintf("%s:%d", p.host, p.port)
+	return net.JoinHostPort(p.host, strconv.Itoa(p.port))
+}
+
+func isConnRefused(err error) bool {
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+
+	if sysErr, ok := err.(*os.SyscallError); ok {
+		err = sysErr.Err
+	}
+
+	return err == syscall.ECONNREFUSED
 }
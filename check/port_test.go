@@ -5,6 +5,7 @@ import (
 	"net"
 	"net/url"
 	"strconv"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -137,6 +138,141 @@ var _ = Describe("portcheck", func() {
 		})
 	})
 
+	Context("when the network is udp", func() {
+		var (
+			server   *net.UDPConn
+			received chan []byte
+		)
+
+		BeforeEach(func() {
+			network = "udp"
+			host = "127.0.0.1"
+		})
+
+		Context("and the port is open", func() {
+			BeforeEach(func() {
+				var err error
+				server, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP(host)})
+				Expect(err).ToNot(HaveOccurred())
+				port = server.LocalAddr().(*net.UDPAddr).Port
+
+				received = make(chan []byte, 1)
+				go func() {
+					buf := make([]byte, 512)
+					n, addr, err := server.ReadFromUDP(buf)
+					if err != nil {
+						return
+					}
+					received <- buf[:n]
+					server.WriteToUDP([]byte("pong"), addr)
+				}()
+			})
+
+			AfterEach(func() {
+				server.Close()
+			})
+
+			It("sends the payload", func() {
+				check.Port(port).OnHost(host).ForNetwork(network).WithPayload([]byte("ping")).IsOpen()
+				Eventually(received).Should(Receive(Equal([]byte("ping"))))
+			})
+
+			It("is open if the server replies", func() {
+				portcheck = check.Port(port).OnHost(host).ForNetwork(network).WithLogger(logger)
+				Expect(portcheck.IsOpen()).To(BeTrue())
+				Expect(logger).To(gbytes.Say("Received reply"))
+			})
+
+			It("is not closed if the server replies", func() {
+				portcheck = check.Port(port).OnHost(host).ForNetwork(network)
+				Expect(portcheck.IsClosed()).To(BeFalse())
+			})
+		})
+
+		Context("and the server does not reply", func() {
+			BeforeEach(func() {
+				var err error
+				server, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP(host)})
+				Expect(err).ToNot(HaveOccurred())
+				port = server.LocalAddr().(*net.UDPAddr).Port
+			})
+
+			AfterEach(func() {
+				server.Close()
+			})
+
+			JustBeforeEach(func() {
+				portcheck = check.Port(port).OnHost(host).ForNetwork(network).WithReplyTimeout(10 * time.Millisecond)
+			})
+
+			It("is not open", func() {
+				Expect(portcheck.IsOpen()).To(BeFalse())
+			})
+
+			It("is not closed", func() {
+				Expect(portcheck.IsClosed()).To(BeFalse())
+			})
+
+			Context("when no reply is ok", func() {
+				JustBeforeEach(func() {
+					portcheck.WithNoReplyOK(true).WithLogger(logger)
+				})
+
+				It("is open", func() {
+					Expect(portcheck.IsOpen()).To(BeTrue())
+					Expect(logger).To(gbytes.Say("No reply, assuming open|filtered"))
+				})
+			})
+		})
+
+		Context("and the port is closed", func() {
+			BeforeEach(func() {
+				var err error
+				port, err = freeUdpPort()
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			JustBeforeEach(func() {
+				portcheck = check.Port(port).OnHost(host).ForNetwork(network)
+			})
+
+			It("is not open", func() {
+				Expect(portcheck.IsOpen()).To(BeFalse())
+			})
+
+			It("is closed", func() {
+				Expect(portcheck.IsClosed()).To(BeTrue())
+			})
+
+			It("is not open even if no reply is ok", func() {
+				Expect(portcheck.WithNoReplyOK(true).IsOpen()).To(BeFalse())
+			})
+		})
+	})
+
+	Context("when the host is an IPv6 address", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp6", "[::1]:0")
+			if err != nil {
+				Skip("IPv6 is not available")
+			}
+			port = listener.Addr().(*net.TCPAddr).Port
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("dials it in brackets", func() {
+			portcheck = check.Port(port).OnHost("::1").WithLogger(logger)
+			Expect(portcheck.IsOpen()).To(BeTrue())
+			Expect(logger).To(gbytes.Say(fmt.Sprintf(`Dialing tcp://\[::1\]:%d`, port)))
+		})
+	})
+
 	Describe("logging", func() {
 		BeforeEach(func() {
 			portcheck = check.Port(port).OnHost(host).ForNetwork(network).WithLogger(logger)
@@ -147,6 +283,11 @@ var _ = Describe("portcheck", func() {
 			expected := fmt.Sprintf("Dialing %s://%s:%d", network, host, port)
 			Expect(logger).To(gbytes.Say(expected))
 		})
+
+		It("logs dial errors", func() {
+			check.Port(port).OnHost(host).ForNetwork("bogus").WithLogger(logger).IsOpen()
+			Expect(logger).To(gbytes.Say("unknown network bogus"))
+		})
 	})
 })
 
@@ -165,6 +306,16 @@ func freeTcpPort() (int, error) {
 	return l.Addr().(*net.TCPAddr).Port, nil
 }
 
+func freeUdpPort() (int, error) {
+	l, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+
+	return l.LocalAddr().(*net.UDPAddr).Port, nil
+}
+
 func hostPort(server *ghttp.Server) (host string, port int) {
 	url, err := url.Parse(server.URL())
 	Expect(err).ToNot(HaveOccurred())
@@ -0,0 +1,569 @@
+package check
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type PostgresCheck interface {
+	IsReady() bool
+	IsPrimary() bool
+
+	WithQuery(string) PostgresCheck
+	WithTimeout(time.Duration) PostgresCheck
+	WithLogger(io.Writer) PostgresCheck
+}
+
+type postgrescheck struct {
+	dsn     string
+	query   string
+	timeout time.Duration
+	logger  io.Writer
+}
+
+// Postgres checks whether a PostgreSQL server accepts connections by
+// performing the startup handshake of the wire protocol. The DSN is either a
+// URL, e.g. 'postgres://user:secret@db:5432/app?sslmode=disable', or a list of
+// keywords, e.g. 'host=db user=user password=secret dbname=app'. Settings
+// missing from the DSN are taken from the PGHOST, PGPORT, PGUSER, PGPASSWORD,
+// PGDATABASE and PGSSLMODE environment variables.
+func Postgres(dsn string) PostgresCheck {
+	return &postgrescheck{
+		dsn:     dsn,
+		timeout: DefaultTimeout,
+		logger:  DefaultLogger,
+	}
+}
+
+// WithQuery runs the given query, e.g. 'SELECT 1', once connected. The check
+// fails if the query fails.
+func (p *postgrescheck) WithQuery(query string) PostgresCheck {
+	p.query = query
+	return p
+}
+
+// WithTimeout limits the time a single attempt, i.e. connecting, the
+// handshake and any queries, may take.
+func (p *postgrescheck) WithTimeout(timeout time.Duration) PostgresCheck {
+	p.timeout = timeout
+	return p
+}
+
+func (p *postgrescheck) WithLogger(w io.Writer) PostgresCheck {
+	p.logger = w
+	return p
+}
+
+// IsReady returns true once the server accepts connections and the query, if
+// any, succeeds. A server that is still starting up or recovering rejects
+// connections with an error, e.g. 'the database system is starting up'.
+func (p *postgrescheck) IsReady() bool {
+	return p.check(false)
+}
+
+// IsPrimary additionally requires the server not to be in recovery, i.e. to
+// be neither a standby nor replaying WAL after a restart.
+func (p *postgrescheck) IsPrimary() bool {
+	return p.check(true)
+}
+
+func (p *postgrescheck) check(primary bool) bool {
+	if err := p.run(primary); err != nil {
+		fmt.Fprintln(p.logger, err.Error())
+		return false
+	}
+	return true
+}
+
+func (p *postgrescheck) run(primary bool) error {
+	cfg, err := parsePostgresDSN(p.dsn)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(p.logger, "Connecting to postgres://%s@%s/%s\n", cfg.user, cfg.addr(), cfg.database)
+
+	conn, err := dialPostgres(cfg, p.timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.close()
+
+	if p.query != "" {
+		fmt.Fprintf(p.logger, "Running query '%s'\n", p.query)
+		if _, err := conn.query(p.query); err != nil {
+			return err
+		}
+	}
+
+	if primary {
+		inRecovery, err := conn.query("SELECT pg_is_in_recovery()")
+		if err != nil {
+			return err
+		}
+
+		if inRecovery != "f" {
+			return errors.New("server is in recovery")
+		}
+	}
+
+	return nil
+}
+
+type postgresConfig struct {
+	host     string
+	port     string
+	user     string
+	password string
+	database string
+	sslmode  string
+}
+
+func (c postgresConfig) network() string {
+	if strings.HasPrefix(c.host, "/") {
+		return "unix"
+	}
+	return "tcp"
+}
+
+func (c postgresConfig) addr() string {
+	if c.network() == "unix" {
+		return filepath.Join(c.host, ".s.PGSQL."+c.port)
+	}
+	return net.JoinHostPort(c.host, c.port)
+}
+
+func parsePostgresDSN(dsn string) (postgresConfig, error) {
+	cfg := postgresConfig{
+		host:     os.Getenv("PGHOST"),
+		port:     os.Getenv("PGPORT"),
+		user:     os.Getenv("PGUSER"),
+		password: os.Getenv("PGPASSWORD"),
+		database: os.Getenv("PGDATABASE"),
+		sslmode:  os.Getenv("PGSSLMODE"),
+	}
+
+	var (
+		settings map[string]string
+		err      error
+	)
+
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		settings, err = postgresURLSettings(dsn)
+	} else {
+		settings, err = postgresKeywordSettings(dsn)
+	}
+
+	if err != nil {
+		return cfg, fmt.Errorf("invalid DSN: %s", err)
+	}
+
+	for key, value := range settings {
+		switch key {
+		case "host":
+			cfg.host = value
+		case "port":
+			cfg.port = value
+		case "user":
+			cfg.user = value
+		case "password":
+			cfg.password = value
+		case "dbname":
+			cfg.database = value
+		case "sslmode":
+			cfg.sslmode = value
+		}
+	}
+
+	if cfg.host == "" {
+		cfg.host = "localhost"
+	}
+
+	if cfg.port == "" {
+		cfg.port = "5432"
+	}
+
+	if cfg.user == "" {
+		cfg.user = os.Getenv("USER")
+	}
+
+	if cfg.user == "" {
+		cfg.user = "postgres"
+	}
+
+	if cfg.database == "" {
+		cfg.database = cfg.user
+	}
+
+	switch cfg.sslmode {
+	case "":
+		cfg.sslmode = "prefer"
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		return cfg, fmt.Errorf("invalid DSN: unsupported sslmode '%s'", cfg.sslmode)
+	}
+
+	return cfg, nil
+}
+
+func postgresURLSettings(dsn string) (map[string]string, error) {
+	u, err := neturl.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := map[string]string{}
+
+	if host := u.Hostname(); host != "" {
+		settings["host"] = host
+	}
+
+	if port := u.Port(); port != "" {
+		settings["port"] = port
+	}
+
+	if u.User != nil {
+		settings["user"] = u.User.Username()
+		if password, ok := u.User.Password(); ok {
+			settings["password"] = password
+		}
+	}
+
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		settings["dbname"] = db
+	}
+
+	for key, values := range u.Query() {
+		settings[key] = values[0]
+	}
+
+	return settings, nil
+}
+
+// postgresKeywordSettings parses 'key=value' pairs separated by whitespace.
+// Values may be single-quoted and contain escaped quotes and backslashes.
+func postgresKeywordSettings(dsn string) (map[string]string, error) {
+	settings := map[string]string{}
+
+	s := strings.TrimSpace(dsn)
+	for s != "" {
+		eq := strings.Index(s, "=")
+		if eq < 1 {
+			return nil, fmt.Errorf("missing '=' after '%s'", s)
+		}
+
+		key := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t\n")
+
+		var value bytes.Buffer
+		quoted := strings.HasPrefix(s, "'")
+		if quoted {
+			s = s[1:]
+		}
+
+		i := 0
+		for ; i < len(s); i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				value.WriteByte(s[i])
+				continue
+			}
+
+			if quoted && s[i] == '\'' {
+				break
+			}
+
+			if !quoted && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n') {
+				break
+			}
+
+			value.WriteByte(s[i])
+		}
+
+		if quoted {
+			if i == len(s) {
+				return nil, fmt.Errorf("unterminated quoted value for '%s'", key)
+			}
+			i++
+		}
+
+		settings[key] = value.String()
+		s = strings.TrimSpace(s[i:])
+	}
+
+	return settings, nil
+}
+
+const (
+	postgresProtocolVersion = 196608
+	postgresSSLRequest      = 80877103
+)
+
+// postgresConn speaks the frontend side of version 3.0 of the PostgreSQL
+// wire protocol.
+type postgresConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func dialPostgres(cfg postgresConfig, timeout time.Duration) (*postgresConn, error) {
+	raw, err := net.DialTimeout(cfg.network(), cfg.addr(), timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	raw.SetDeadline(time.Now().Add(timeout))
+
+	conn := &postgresConn{Conn: raw, reader: bufio.NewReader(raw)}
+
+	if cfg.network() == "tcp" && cfg.sslmode != "disable" && cfg.sslmode != "allow" {
+		if err := conn.startTLS(cfg); err != nil {
+			raw.Close()
+			return nil, err
+		}
+	}
+
+	if err := conn.startup(cfg); err != nil {
+		conn.Conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// startTLS asks the server to switch to TLS. Unless the sslmode requires
+// TLS, the connection continues unencrypted if the server declines.
+func (c *postgresConn) startTLS(cfg postgresConfig) error {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:], 8)
+	binary.BigEndian.PutUint32(request[4:], postgresSSLRequest)
+
+	if _, err := c.Write(request); err != nil {
+		return err
+	}
+
+	answer, err := c.reader.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	if answer != 'S' {
+		if cfg.sslmode == "prefer" {
+			return nil
+		}
+		return errors.New("server does not support SSL")
+	}
+
+	config := &tls.Config{
+		ServerName:         cfg.host,
+		InsecureSkipVerify: cfg.sslmode == "prefer" || cfg.sslmode == "require",
+	}
+
+	tlsConn := tls.Client(c.Conn, config)
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+
+	c.Conn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+	return nil
+}
+
+func (c *postgresConn) startup(cfg postgresConfig) error {
+	var params bytes.Buffer
+	for _, p := range []string{"user", cfg.user, "database", cfg.database, "application_name", "waitfor"} {
+		params.WriteString(p)
+		params.WriteByte(0)
+	}
+	params.WriteByte(0)
+
+	msg := make([]byte, 8, 8+params.Len())
+	binary.BigEndian.PutUint32(msg[0:], uint32(8+params.Len()))
+	binary.BigEndian.PutUint32(msg[4:], postgresProtocolVersion)
+
+	if _, err := c.Write(append(msg, params.Bytes()...)); err != nil {
+		return err
+	}
+
+	var sasl *scram
+
+	for {
+		typ, body, err := c.receive()
+		if err != nil {
+			return err
+		}
+
+		switch typ {
+		case 'R':
+			if len(body) < 4 {
+				return errors.New("invalid authentication request")
+			}
+
+			sasl, err = c.authenticate(cfg, binary.BigEndian.Uint32(body), body[4:], sasl)
+			if err != nil {
+				return err
+			}
+		case 'Z':
+			return nil
+		}
+	}
+}
+
+func (c *postgresConn) authenticate(cfg postgresConfig, method uint32, data []byte, sasl *scram) (*scram, error) {
+	switch method {
+	case 0:
+		return sasl, nil
+	case 3:
+		return sasl, c.send('p', cstring(cfg.password))
+	case 5:
+		if len(data) < 4 {
+			return sasl, errors.New("invalid MD5 salt")
+		}
+		inner := md5.Sum([]byte(cfg.password + cfg.user))
+		outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), data[:4]...))
+		return sasl, c.send('p', cstring("md5"+hex.EncodeToString(outer[:])))
+	case 10:
+		if !bytes.Contains(data, []byte("SCRAM-SHA-256\x00")) {
+			return sasl, errors.New("unsupported SASL mechanisms")
+		}
+
+		sasl, err := newSCRAM(sha256.New, "", cfg.password)
+		if err != nil {
+			return nil, err
+		}
+
+		first := sasl.clientFirst()
+		body := cstring("SCRAM-SHA-256")
+		body = append(body, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(body[len(body)-4:], uint32(len(first)))
+		body = append(body, first...)
+
+		return sasl, c.send('p', body)
+	case 11:
+		if sasl == nil {
+			return nil, errors.New("unexpected SASL challenge")
+		}
+
+		final, err := sasl.clientFinal(string(data))
+		if err != nil {
+			return sasl, err
+		}
+		return sasl, c.send('p', []byte(final))
+	case 12:
+		if sasl == nil {
+			return nil, errors.New("unexpected SASL outcome")
+		}
+		return sasl, sasl.verify(string(data))
+	}
+
+	return sasl, fmt.Errorf("unsupported authentication method %d", method)
+}
+
+// query runs a simple query and returns the first column of the first row
+// of its result, if any.
+func (c *postgresConn) query(sql string) (string, error) {
+	if err := c.send('Q', cstring(sql)); err != nil {
+		return "", err
+	}
+
+	var (
+		result   string
+		received bool
+	)
+
+	for {
+		typ, body, err := c.receive()
+		if err != nil {
+			return "", err
+		}
+
+		switch typ {
+		case 'D':
+			if !received && len(body) >= 6 && binary.BigEndian.Uint16(body) > 0 {
+				size := int32(binary.BigEndian.Uint32(body[2:]))
+				if size >= 0 && int(size) <= len(body)-6 {
+					result = string(body[6 : 6+size])
+				}
+				received = true
+			}
+		case 'Z':
+			return result, nil
+		}
+	}
+}
+
+func (c *postgresConn) close() {
+	c.send('X', nil)
+	c.Conn.Close()
+}
+
+func (c *postgresConn) send(typ byte, body []byte) error {
+	msg := make([]byte, 5, 5+len(body))
+	msg[0] = typ
+	binary.BigEndian.PutUint32(msg[1:], uint32(4+len(body)))
+
+	_, err := c.Write(append(msg, body...))
+	return err
+}
+
+// receive returns the next message from the server, skipping notices and
+// turning error responses into errors.
+func (c *postgresConn) receive() (byte, []byte, error) {
+	for {
+		header := make([]byte, 5)
+		if _, err := io.ReadFull(c.reader, header); err != nil {
+			return 0, nil, err
+		}
+
+		size := binary.BigEndian.Uint32(header[1:])
+		if size < 4 || size > 1<<24 {
+			return 0, nil, fmt.Errorf("invalid message length %d", size)
+		}
+
+		body := make([]byte, size-4)
+		if _, err := io.ReadFull(c.reader, body); err != nil {
+			return 0, nil, err
+		}
+
+		switch header[0] {
+		case 'E':
+			return 0, nil, postgresError(body)
+		case 'N':
+			continue
+		}
+
+		return header[0], body, nil
+	}
+}
+
+func postgresError(body []byte) error {
+	fields := map[byte]string{}
+	for len(body) > 1 {
+		end := bytes.IndexByte(body[1:], 0)
+		if end < 0 {
+			break
+		}
+		fields[body[0]] = string(body[1 : 1+end])
+		body = body[end+2:]
+	}
+
+	return fmt.Errorf("%s: %s (SQLSTATE %s)", fields['S'], fields['M'], fields['C'])
+}
+
+func cstring(s string) []byte {
+	return append([]byte(s), 0)
+}
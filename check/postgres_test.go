@@ -0,0 +1,423 @@
+package check_test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("postgrescheck", func() {
+	var (
+		server *fakePostgres
+		logger *gbytes.Buffer
+		dsn    string
+	)
+
+	BeforeEach(func() {
+		server = newFakePostgres()
+		logger = gbytes.NewBuffer()
+		dsn = fmt.Sprintf("postgres://app:secret@%s/appdb?sslmode=prefer", server.addr())
+	})
+
+	JustBeforeEach(func() {
+		server.start()
+	})
+
+	AfterEach(func() {
+		server.close()
+	})
+
+	for _, auth := range []string{"trust", "cleartext", "md5", "scram"} {
+		auth := auth
+
+		Context(fmt.Sprintf("when the server uses %s authentication", auth), func() {
+			BeforeEach(func() {
+				server.auth = auth
+			})
+
+			It("completes the handshake", func() {
+				Expect(check.Postgres(dsn).WithLogger(logger).IsReady()).To(BeTrue())
+				Expect(server.startup()).To(HaveKeyWithValue("user", "app"))
+				Expect(server.startup()).To(HaveKeyWithValue("database", "appdb"))
+			})
+
+			It("fails if the password is wrong", func() {
+				dsn = strings.Replace(dsn, "secret", "wrong", 1)
+				if auth == "trust" {
+					Expect(check.Postgres(dsn).WithLogger(logger).IsReady()).To(BeTrue())
+					return
+				}
+
+				Expect(check.Postgres(dsn).WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("FATAL: password authentication failed for user \"app\" \\(SQLSTATE 28P01\\)"))
+			})
+		})
+	}
+
+	Context("when the server does not add to the SCRAM nonce", func() {
+		BeforeEach(func() {
+			server.auth = "scram"
+			server.serverNonce = ""
+		})
+
+		It("returns false", func() {
+			Expect(check.Postgres(dsn).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("invalid SCRAM nonce"))
+		})
+	})
+
+	Context("when the server demands too many SCRAM iterations", func() {
+		BeforeEach(func() {
+			server.auth = "scram"
+			server.iterations = 1<<20 + 1
+		})
+
+		It("returns false", func() {
+			Expect(check.Postgres(dsn).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("SCRAM iteration count of 1048577 exceeds the limit of 1048576"))
+		})
+	})
+
+	Context("when the DSN is a list of keywords", func() {
+		BeforeEach(func() {
+			host, port, _ := net.SplitHostPort(server.addr())
+			dsn = fmt.Sprintf("host=%s port=%s user=app password='se\\'cret' dbname=other sslmode=disable", host, port)
+			server.password = "se'cret"
+			server.auth = "cleartext"
+		})
+
+		It("uses the given settings", func() {
+			Expect(check.Postgres(dsn).WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(server.startup()).To(HaveKeyWithValue("database", "other"))
+		})
+	})
+
+	Context("when the DSN is invalid", func() {
+		It("returns false", func() {
+			Expect(check.Postgres("host=db sslmode=bogus").WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("invalid DSN: unsupported sslmode 'bogus'"))
+		})
+	})
+
+	Context("when the server is starting up", func() {
+		BeforeEach(func() {
+			server.startupError = "the database system is starting up"
+		})
+
+		It("returns false", func() {
+			Expect(check.Postgres(dsn).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("FATAL: the database system is starting up \\(SQLSTATE 57P03\\)"))
+		})
+	})
+
+	Context("when nothing is listening", func() {
+		It("returns false", func() {
+			server.close()
+			Expect(check.Postgres(dsn).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("Connecting to postgres://app@" + server.addr() + "/appdb"))
+		})
+	})
+
+	Context("when the server does not respond", func() {
+		BeforeEach(func() {
+			server.hang = true
+		})
+
+		It("gives up after the timeout", func() {
+			start := time.Now()
+			Expect(check.Postgres(dsn).WithTimeout(100 * time.Millisecond).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		})
+	})
+
+	Describe(".WithQuery", func() {
+		It("runs the query", func() {
+			Expect(check.Postgres(dsn).WithQuery("SELECT 1").WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(server.queries()).To(ContainElement("SELECT 1"))
+		})
+
+		It("fails if the query fails", func() {
+			Expect(check.Postgres(dsn).WithQuery("SELECT broken").WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("ERROR: relation \"broken\" does not exist \\(SQLSTATE 42P01\\)"))
+		})
+	})
+
+	Describe(".IsPrimary", func() {
+		It("returns true if the server is not in recovery", func() {
+			Expect(check.Postgres(dsn).WithLogger(logger).IsPrimary()).To(BeTrue())
+			Expect(server.queries()).To(ContainElement("SELECT pg_is_in_recovery()"))
+		})
+
+		Context("when the server is in recovery", func() {
+			BeforeEach(func() {
+				server.inRecovery = true
+			})
+
+			It("returns false", func() {
+				Expect(check.Postgres(dsn).WithLogger(logger).IsPrimary()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("server is in recovery"))
+			})
+		})
+	})
+})
+
+// fakePostgres speaks just enough of the backend side of the PostgreSQL wire
+// protocol to test the check. Its settings must be changed before it is
+// started.
+type fakePostgres struct {
+	listener net.Listener
+
+	auth         string
+	password     string
+	startupError string
+	inRecovery   bool
+	hang         bool
+	serverNonce  string
+	iterations   int
+
+	mutex    sync.Mutex
+	params   map[string]string
+	received []string
+}
+
+func newFakePostgres() *fakePostgres {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	return &fakePostgres{listener: listener, auth: "trust", password: "secret", serverNonce: "server-nonce", iterations: 4096}
+}
+
+func (s *fakePostgres) start() {
+	go func() {
+		for {
+			conn, err := s.listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+}
+
+func (s *fakePostgres) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakePostgres) close() {
+	s.listener.Close()
+}
+
+func (s *fakePostgres) startup() map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.params
+}
+
+func (s *fakePostgres) queries() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.received
+}
+
+func (s *fakePostgres) serve(conn net.Conn) {
+	defer GinkgoRecover()
+	defer conn.Close()
+
+	if s.hang {
+		io.Copy(ioutil.Discard, conn)
+		return
+	}
+
+	r := bufio.NewReader(conn)
+
+	body := s.readStartup(r)
+	if binary.BigEndian.Uint32(body) == 80877103 {
+		conn.Write([]byte{'N'})
+		body = s.readStartup(r)
+	}
+
+	params := map[string]string{}
+	fields := strings.Split(string(body[4:]), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		params[fields[i]] = fields[i+1]
+	}
+
+	s.mutex.Lock()
+	s.params = params
+	s.mutex.Unlock()
+
+	if s.startupError != "" {
+		s.fail(conn, "FATAL", "57P03", s.startupError)
+		return
+	}
+
+	if !s.authenticate(conn, r, params["user"]) {
+		s.fail(conn, "FATAL", "28P01", fmt.Sprintf("password authentication failed for user \"%s\"", params["user"]))
+		return
+	}
+
+	s.send(conn, 'R', []byte{0, 0, 0, 0})
+	s.send(conn, 'S', []byte("server_version\x0016.0\x00"))
+	s.send(conn, 'Z', []byte{'I'})
+
+	for {
+		typ, body, err := s.read(r)
+		if err != nil || typ == 'X' {
+			return
+		}
+
+		query := strings.TrimSuffix(string(body), "\x00")
+
+		s.mutex.Lock()
+		s.received = append(s.received, query)
+		s.mutex.Unlock()
+
+		switch query {
+		case "SELECT broken":
+			s.fail(conn, "ERROR", "42P01", "relation \"broken\" does not exist")
+		case "SELECT pg_is_in_recovery()":
+			value := "f"
+			if s.inRecovery {
+				value = "t"
+			}
+			s.row(conn, value)
+		default:
+			s.row(conn, "1")
+		}
+
+		s.send(conn, 'Z', []byte{'I'})
+	}
+}
+
+func (s *fakePostgres) authenticate(conn net.Conn, r *bufio.Reader, user string) bool {
+	switch s.auth {
+	case "cleartext":
+		s.send(conn, 'R', []byte{0, 0, 0, 3})
+		_, body, _ := s.read(r)
+		return string(body) == s.password+"\x00"
+	case "md5":
+		salt := []byte{1, 2, 3, 4}
+		s.send(conn, 'R', append([]byte{0, 0, 0, 5}, salt...))
+		_, body, _ := s.read(r)
+
+		inner := md5.Sum([]byte(s.password + user))
+		outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), salt...))
+		return string(body) == "md5"+hex.EncodeToString(outer[:])+"\x00"
+	case "scram":
+		s.send(conn, 'R', []byte("\x00\x00\x00\x0aSCRAM-SHA-256\x00\x00"))
+		_, body, _ := s.read(r)
+
+		mechanism := body[:bytes.IndexByte(body, 0)]
+		Expect(string(mechanism)).To(Equal("SCRAM-SHA-256"))
+		clientFirst := string(body[len(mechanism)+5:])
+		clientFirstBare := strings.TrimPrefix(clientFirst, "n,,")
+
+		salt := []byte("some-salt")
+		nonce := scramAttr(clientFirstBare, "r") + s.serverNonce
+		serverFirst := fmt.Sprintf("r=%s,s=%s,i=%d", nonce, base64.StdEncoding.EncodeToString(salt), s.iterations)
+		s.send(conn, 'R', append([]byte{0, 0, 0, 11}, serverFirst...))
+
+		_, body, err := s.read(r)
+		if err != nil {
+			return false
+		}
+		clientFinal := string(body)
+		withoutProof := clientFinal[:strings.Index(clientFinal, ",p=")]
+		proof, _ := base64.StdEncoding.DecodeString(scramAttr(clientFinal, "p"))
+
+		salted := pbkdf2.Key([]byte(s.password), salt, 4096, sha256.Size, sha256.New)
+		clientKey := testHMAC(salted, "Client Key")
+		storedKey := sha256.Sum256(clientKey)
+		authMessage := clientFirstBare + "," + serverFirst + "," + withoutProof
+		signature := testHMAC(storedKey[:], authMessage)
+
+		for i := range proof {
+			proof[i] ^= signature[i]
+		}
+
+		if actual := sha256.Sum256(proof); !hmac.Equal(actual[:], storedKey[:]) {
+			return false
+		}
+
+		serverSignature := testHMAC(testHMAC(salted, "Server Key"), authMessage)
+		s.send(conn, 'R', append([]byte{0, 0, 0, 12}, "v="+base64.StdEncoding.EncodeToString(serverSignature)...))
+	}
+
+	return true
+}
+
+func (s *fakePostgres) row(conn net.Conn, value string) {
+	s.send(conn, 'T', []byte("\x00\x01col\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x19\xff\xff\xff\xff\xff\xff\x00\x00"))
+
+	row := make([]byte, 6)
+	binary.BigEndian.PutUint16(row, 1)
+	binary.BigEndian.PutUint32(row[2:], uint32(len(value)))
+	s.send(conn, 'D', append(row, value...))
+
+	s.send(conn, 'C', []byte("SELECT 1\x00"))
+}
+
+func (s *fakePostgres) fail(conn net.Conn, severity, code, msg string) {
+	s.send(conn, 'E', []byte(fmt.Sprintf("S%s\x00C%s\x00M%s\x00\x00", severity, code, msg)))
+}
+
+func (s *fakePostgres) readStartup(r *bufio.Reader) []byte {
+	header := make([]byte, 4)
+	io.ReadFull(r, header)
+
+	body := make([]byte, binary.BigEndian.Uint32(header)-4)
+	io.ReadFull(r, body)
+	return body
+}
+
+func (s *fakePostgres) read(r *bufio.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+
+	body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+	_, err := io.ReadFull(r, body)
+	return header[0], body, err
+}
+
+func (s *fakePostgres) send(conn net.Conn, typ byte, body []byte) {
+	msg := make([]byte, 5)
+	msg[0] = typ
+	binary.BigEndian.PutUint32(msg[1:], uint32(len(body)+4))
+	conn.Write(append(msg, body...))
+}
+
+func scramAttr(msg, key string) string {
+	for _, attr := range strings.Split(msg, ",") {
+		if strings.HasPrefix(attr, key+"=") {
+			return attr[len(key)+1:]
+		}
+	}
+	return ""
+}
+
+func testHMAC(key []byte, msg string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msg))
+	return mac.Sum(nil)
+}
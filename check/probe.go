@@ -0,0 +1,180 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type ProbeCheck interface {
+	IsReady() bool
+
+	WithTimeout(time.Duration) ProbeCheck
+	WithLogger(io.Writer) ProbeCheck
+}
+
+// TextProbe describes how to ask a server speaking a simple text protocol
+// whether it is ready.
+type TextProbe struct {
+	// Port is used if the address does not specify one.
+	Port int
+
+	// Request is sent once connected. It can be empty for servers that greet
+	// their clients.
+	Request string
+
+	// Response must match what the server sends back.
+	Response *regexp.Regexp
+}
+
+// probesMutex guards probes, which can be registered while checks run.
+var probesMutex sync.RWMutex
+
+var probes = map[string]TextProbe{
+	"zookeeper": {
+		Port:     2181,
+		Request:  "ruok",
+		Response: regexp.MustCompile(`^imok`),
+	},
+	"memcached": {
+		Port:     11211,
+		Request:  "version\r\n",
+		Response: regexp.MustCompile(`^VERSION \S+\r\n`),
+	},
+	"nats": {
+		Port:     4222,
+		Response: regexp.MustCompile(`^INFO \{.*\}\r\n`),
+	},
+	"beanstalkd": {
+		Port:     11300,
+		Request:  "stats\r\n",
+		Response: regexp.MustCompile(`^OK \d+\r\n`),
+	},
+}
+
+// RegisterProbe makes the given probe available under the given name,
+// replacing any probe registered under the same name.
+func RegisterProbe(name string, probe TextProbe) {
+	probesMutex.Lock()
+	defer probesMutex.Unlock()
+
+	probes[name] = probe
+}
+
+// Probes returns the names of all registered probes in alphabetical order.
+func Probes() []string {
+	probesMutex.RLock()
+	defer probesMutex.RUnlock()
+
+	var names []string
+	for name := range probes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type probecheck struct {
+	kind    string
+	addr    string
+	timeout time.Duration
+	logger  io.Writer
+}
+
+// Probe checks whether the server at the given address, 'host[:port]',
+// answers the registered probe of the given kind, e.g. 'zookeeper'.
+func Probe(kind, addr string) ProbeCheck {
+	return &probecheck{
+		kind:    kind,
+		addr:    addr,
+		timeout: DefaultTimeout,
+		logger:  DefaultLogger,
+	}
+}
+
+// WithTimeout limits the time a single attempt, i.e. connecting, sending the
+// request and receiving the response, may take.
+func (p *probecheck) WithTimeout(timeout time.Duration) ProbeCheck {
+	p.timeout = timeout
+	return p
+}
+
+func (p *probecheck) WithLogger(w io.Writer) ProbeCheck {
+	p.logger = w
+	return p
+}
+
+// IsReady returns true once the response of the server matches the one
+// expected by the probe.
+func (p *probecheck) IsReady() bool {
+	if err := p.run(); err != nil {
+		fmt.Fprintln(p.logger, err.Error())
+		return false
+	}
+	return true
+}
+
+func (p *probecheck) run() error {
+	probesMutex.RLock()
+	probe, ok := probes[p.kind]
+	probesMutex.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("unknown probe '%s'", p.kind)
+	}
+
+	addr := p.addr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, strconv.Itoa(probe.Port))
+	}
+
+	fmt.Fprintf(p.logger, "Probing %s on %s\n", p.kind, addr)
+
+	conn, err := net.DialTimeout("tcp", addr, p.timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(p.timeout))
+
+	if probe.Request != "" {
+		if _, err := conn.Write([]byte(probe.Request)); err != nil {
+			return err
+		}
+	}
+
+	// read until the response matches, the server closes the connection, the
+	// response grows too large or the deadline expires
+	var (
+		response bytes.Buffer
+		buf      = make([]byte, 512)
+	)
+
+	for {
+		n, err := conn.Read(buf)
+		response.Write(buf[:n])
+
+		if probe.Response.Match(response.Bytes()) {
+			return nil
+		}
+
+		if response.Len() > 1<<20 {
+			return fmt.Errorf("response of more than %d bytes exceeds the limit of 1 MiB", response.Len())
+		}
+
+		if err == io.EOF {
+			return fmt.Errorf("expected response matching '%s', got %q", probe.Response, response.String())
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
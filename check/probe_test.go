@@ -0,0 +1,182 @@
+package check_test
+
+import (
+	"bufio"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("probecheck", func() {
+	var (
+		listener net.Listener
+		addr     string
+		logger   *gbytes.Buffer
+		requests chan string
+		greeting string
+		replies  map[string]string
+	)
+
+	BeforeEach(func() {
+		var err error
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+
+		addr = listener.Addr().String()
+		logger = gbytes.NewBuffer()
+		requests = make(chan string, 10)
+		greeting = ""
+		replies = map[string]string{}
+	})
+
+	JustBeforeEach(func() {
+		go serveProbe(listener, greeting, replies, requests)
+	})
+
+	AfterEach(func() {
+		listener.Close()
+	})
+
+	It("knows about the built-in probes", func() {
+		Expect(check.Probes()).To(ContainElement("beanstalkd"))
+		Expect(check.Probes()).To(ContainElement("memcached"))
+		Expect(check.Probes()).To(ContainElement("nats"))
+		Expect(check.Probes()).To(ContainElement("zookeeper"))
+	})
+
+	Context("when zookeeper is ok", func() {
+		BeforeEach(func() {
+			replies["ruok"] = "imok"
+		})
+
+		It("sends ruok", func() {
+			Expect(check.Probe("zookeeper", addr).WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(requests).To(Receive(Equal("ruok")))
+			Expect(logger).To(gbytes.Say("Probing zookeeper on " + addr))
+		})
+	})
+
+	Context("when memcached answers version", func() {
+		BeforeEach(func() {
+			replies["version\r\n"] = "VERSION 1.6.21\r\n"
+		})
+
+		It("succeeds", func() {
+			Expect(check.Probe("memcached", addr).WithLogger(logger).IsReady()).To(BeTrue())
+		})
+	})
+
+	Context("when beanstalkd answers stats", func() {
+		BeforeEach(func() {
+			replies["stats\r\n"] = "OK 912\r\n---\ncurrent-jobs-urgent: 0\n"
+		})
+
+		It("succeeds", func() {
+			Expect(check.Probe("beanstalkd", addr).WithLogger(logger).IsReady()).To(BeTrue())
+		})
+	})
+
+	Context("when nats greets with INFO", func() {
+		BeforeEach(func() {
+			greeting = "INFO {\"server_id\":\"abc\",\"version\":\"2.10.0\"}\r\n"
+		})
+
+		It("succeeds", func() {
+			Expect(check.Probe("nats", addr).WithLogger(logger).IsReady()).To(BeTrue())
+		})
+	})
+
+	Context("when the response does not match", func() {
+		BeforeEach(func() {
+			replies["ruok"] = ""
+		})
+
+		It("fails", func() {
+			Expect(check.Probe("zookeeper", addr).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say(`expected response matching '\^imok', got ""`))
+		})
+	})
+
+	Context("when the response is too large", func() {
+		BeforeEach(func() {
+			replies["ruok"] = strings.Repeat("x", 2<<20)
+		})
+
+		It("fails", func() {
+			Expect(check.Probe("zookeeper", addr).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("exceeds the limit of 1 MiB"))
+		})
+	})
+
+	It("fails if the server does not answer in time", func() {
+		Expect(check.Probe("memcached", addr).WithTimeout(50 * time.Millisecond).WithLogger(logger).IsReady()).To(BeFalse())
+		Expect(logger).To(gbytes.Say("i/o timeout"))
+	})
+
+	It("uses the default port of the probe", func() {
+		check.Probe("zookeeper", "127.0.0.1").WithTimeout(50 * time.Millisecond).WithLogger(logger).IsReady()
+		Expect(logger).To(gbytes.Say("Probing zookeeper on 127.0.0.1:2181"))
+	})
+
+	It("fails for unknown probes", func() {
+		Expect(check.Probe("gopher", addr).WithLogger(logger).IsReady()).To(BeFalse())
+		Expect(logger).To(gbytes.Say("unknown probe 'gopher'"))
+	})
+
+	Describe("RegisterProbe", func() {
+		BeforeEach(func() {
+			replies["ping\n"] = "pong\n"
+		})
+
+		AfterEach(func() {
+			check.UnregisterProbe("echo")
+		})
+
+		It("adds a probe", func() {
+			check.RegisterProbe("echo", check.TextProbe{Request: "ping\n", Response: regexp.MustCompile(`^pong`)})
+
+			Expect(check.Probes()).To(ContainElement("echo"))
+			Expect(check.Probe("echo", addr).WithLogger(logger).IsReady()).To(BeTrue())
+		})
+	})
+})
+
+// serveProbe answers requests with the given replies, or greets every client
+// if a greeting is given.
+func serveProbe(listener net.Listener, greeting string, replies map[string]string, requests chan<- string) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer conn.Close()
+
+			if greeting != "" {
+				conn.Write([]byte(greeting))
+				return
+			}
+
+			// zookeeper's ruok is not terminated by a newline
+			request := make([]byte, 64)
+			n, _ := bufio.NewReader(conn).Read(request)
+			requests <- string(request[:n])
+
+			reply, ok := replies[string(request[:n])]
+			if !ok {
+				time.Sleep(time.Second)
+				return
+			}
+
+			conn.Write([]byte(reply))
+		}()
+	}
+}
@@ -0,0 +1,317 @@
+package check_test
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("redischeck", func() {
+	var (
+		server *fakeRedis
+		logger *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		server = newFakeRedis(nil)
+		logger = gbytes.NewBuffer()
+	})
+
+	JustBeforeEach(func() {
+		server.start()
+	})
+
+	AfterEach(func() {
+		server.close()
+	})
+
+	It("sends a ping", func() {
+		Expect(check.Redis(server.addr()).WithLogger(logger).IsReady()).To(BeTrue())
+		Expect(server.commands()).To(Equal([]string{"PING"}))
+		Expect(logger).To(gbytes.Say("Connecting to " + server.addr()))
+	})
+
+	It("defaults to port 6379", func() {
+		check.Redis("127.0.0.1").WithTimeout(100 * time.Millisecond).WithLogger(logger).IsReady()
+		Expect(logger).To(gbytes.Say("Connecting to 127.0.0.1:6379"))
+	})
+
+	It("defaults to port 6379 for IPv6 hosts", func() {
+		for _, addr := range []string{"::1", "[::1]", "redis://[::1]"} {
+			check.Redis(addr).WithTimeout(100 * time.Millisecond).WithLogger(logger).IsReady()
+			Expect(logger).To(gbytes.Say(`Connecting to \[::1\]:6379\n`))
+		}
+	})
+
+	Context("when the server is loading its dataset", func() {
+		BeforeEach(func() {
+			server.loading = true
+		})
+
+		It("returns false", func() {
+			Expect(check.Redis(server.addr()).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("LOADING Redis is loading the dataset in memory"))
+		})
+	})
+
+	Context("when the server requires a password", func() {
+		BeforeEach(func() {
+			server.password = "secret"
+		})
+
+		It("authenticates", func() {
+			Expect(check.Redis(server.addr()).WithPassword("secret").WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(server.commands()).To(Equal([]string{"AUTH secret", "PING"}))
+		})
+
+		It("authenticates as ACL user", func() {
+			Expect(check.Redis(server.addr()).WithUsername("app").WithPassword("secret").WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(server.commands()).To(Equal([]string{"AUTH app secret", "PING"}))
+		})
+
+		It("takes the credentials from a URL", func() {
+			Expect(check.Redis("redis://app:secret@" + server.addr()).WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(server.commands()).To(Equal([]string{"AUTH app secret", "PING"}))
+		})
+
+		It("fails if the password is wrong", func() {
+			Expect(check.Redis(server.addr()).WithPassword("wrong").WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("WRONGPASS invalid username-password pair"))
+		})
+
+		It("fails without password", func() {
+			Expect(check.Redis(server.addr()).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("NOAUTH Authentication required"))
+		})
+
+		It("fails if a username is given without password", func() {
+			Expect(check.Redis("redis://app@" + server.addr()).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("username 'app' requires a password"))
+			Expect(server.commands()).To(BeEmpty())
+		})
+	})
+
+	Describe(".WithInfo", func() {
+		BeforeEach(func() {
+			server.info = "# Persistence\r\nloading:0\r\n\r\n# Replication\r\nrole:slave\r\nmaster_link_status:down\r\n"
+		})
+
+		It("succeeds if the fields match", func() {
+			Expect(check.Redis(server.addr()).WithInfo("loading:0").WithInfo("role:slave").WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(server.commands()).To(Equal([]string{"PING", "INFO"}))
+		})
+
+		It("fails if a field does not match", func() {
+			Expect(check.Redis(server.addr()).WithInfo("loading:0").WithInfo("master_link_status:up").WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("expected INFO field master_link_status:up, got master_link_status:down"))
+		})
+
+		It("fails if a field is missing", func() {
+			Expect(check.Redis(server.addr()).WithInfo("connected_slaves:1").WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("INFO does not contain field 'connected_slaves'"))
+		})
+
+		Context("when the reply is too large", func() {
+			BeforeEach(func() {
+				server.info = strings.Repeat("x", 1<<20+1)
+			})
+
+			It("fails", func() {
+				Expect(check.Redis(server.addr()).WithInfo("loading:0").WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("reply of 1048577 bytes exceeds the limit of 1 MiB"))
+			})
+		})
+	})
+
+	Describe(".WithTLS", func() {
+		var pool *x509.CertPool
+
+		BeforeEach(func() {
+			server.close()
+
+			cert, ca := fakeCertificate()
+			pool = x509.NewCertPool()
+			pool.AddCert(ca)
+
+			server = newFakeRedis(&tls.Config{Certificates: []tls.Certificate{cert}})
+		})
+
+		It("connects using TLS", func() {
+			Expect(check.Redis(server.addr()).WithTLS(&tls.Config{RootCAs: pool}).WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(server.commands()).To(Equal([]string{"PING"}))
+		})
+
+		It("verifies the certificate of the server", func() {
+			Expect(check.Redis("rediss://" + server.addr()).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("certificate"))
+		})
+	})
+
+	Context("when the server does not answer", func() {
+		BeforeEach(func() {
+			server.hang = true
+		})
+
+		It("times out", func() {
+			Expect(check.Redis(server.addr()).WithTimeout(50 * time.Millisecond).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("i/o timeout"))
+		})
+	})
+})
+
+// fakeRedis answers the RESP commands used by the check. Its settings must be
+// changed before it is started.
+type fakeRedis struct {
+	listener net.Listener
+
+	password string
+	loading  bool
+	hang     bool
+	info     string
+
+	mutex    sync.Mutex
+	received []string
+}
+
+func newFakeRedis(config *tls.Config) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	if config != nil {
+		listener = tls.NewListener(listener, config)
+	}
+
+	return &fakeRedis{listener: listener}
+}
+
+func (s *fakeRedis) start() {
+	go func() {
+		for {
+			conn, err := s.listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+}
+
+func (s *fakeRedis) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeRedis) close() {
+	s.listener.Close()
+}
+
+func (s *fakeRedis) commands() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.received
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	authenticated := s.password == ""
+
+	for {
+		args, err := readRedisCommand(r)
+		if err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		s.received = append(s.received, strings.Join(args, " "))
+		s.mutex.Unlock()
+
+		if s.hang {
+			time.Sleep(time.Second)
+			return
+		}
+
+		switch {
+		case args[0] == "AUTH" && args[len(args)-1] == s.password:
+			authenticated = true
+			io.WriteString(conn, "+OK\r\n")
+		case args[0] == "AUTH":
+			io.WriteString(conn, "-WRONGPASS invalid username-password pair or user is disabled.\r\n")
+		case !authenticated:
+			io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+		case s.loading:
+			io.WriteString(conn, "-LOADING Redis is loading the dataset in memory\r\n")
+		case args[0] == "PING":
+			io.WriteString(conn, "+PONG\r\n")
+		case args[0] == "INFO":
+			fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(s.info), s.info)
+		default:
+			io.WriteString(conn, "-ERR unknown command\r\n")
+		}
+	}
+}
+
+func readRedisCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+
+	return args, nil
+}
+
+// fakeCertificate returns a self-signed certificate for 127.0.0.1.
+func fakeCertificate() (tls.Certificate, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+
+	ca, err := x509.ParseCertificate(der)
+	Expect(err).ToNot(HaveOccurred())
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, ca
+}
@@ -0,0 +1,128 @@
+package check
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// scramMaxIterations limits the work the server can make the client do.
+const scramMaxIterations = 1 << 20
+
+// scram implements the client side of the SCRAM authentication mechanism
+// described in RFC 5802, without channel binding. The messages are exchanged
+// by the protocol the mechanism is used with.
+type scram struct {
+	hash     func() hash.Hash
+	password string
+
+	nonce           string
+	clientFirstBare string
+	serverSignature []byte
+}
+
+func newSCRAM(h func() hash.Hash, user, password string) (*scram, error) {
+	raw := make([]byte, 18)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+
+	nonce := base64.StdEncoding.EncodeToString(raw)
+	user = strings.NewReplacer("=", "=3D", ",", "=2C").Replace(user)
+
+	return &scram{
+		hash:            h,
+		password:        password,
+		nonce:           nonce,
+		clientFirstBare: fmt.Sprintf("n=%s,r=%s", user, nonce),
+	}, nil
+}
+
+func (s *scram) clientFirst() string {
+	return "n,," + s.clientFirstBare
+}
+
+// clientFinal answers the challenge of the server with a proof of the
+// password.
+func (s *scram) clientFinal(serverFirst string) (string, error) {
+	attrs := scramAttributes(serverFirst)
+
+	nonce := attrs["r"]
+	if len(nonce) <= len(s.nonce) || !strings.HasPrefix(nonce, s.nonce) {
+		return "", errors.New("invalid SCRAM nonce")
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(attrs["s"])
+	if err != nil {
+		return "", fmt.Errorf("invalid SCRAM salt: %s", err)
+	}
+
+	iterations, err := strconv.Atoi(attrs["i"])
+	if err != nil || iterations < 1 {
+		return "", fmt.Errorf("invalid SCRAM iteration count '%s'", attrs["i"])
+	}
+
+	// the key is derived outside of any deadline
+	if iterations > scramMaxIterations {
+		return "", fmt.Errorf("SCRAM iteration count of %d exceeds the limit of %d", iterations, scramMaxIterations)
+	}
+
+	salted := pbkdf2.Key([]byte(s.password), salt, iterations, s.hash().Size(), s.hash)
+	clientKey := s.hmac(salted, "Client Key")
+	serverKey := s.hmac(salted, "Server Key")
+
+	h := s.hash()
+	h.Write(clientKey)
+	storedKey := h.Sum(nil)
+
+	withoutProof := "c=biws,r=" + nonce
+	authMessage := s.clientFirstBare + "," + serverFirst + "," + withoutProof
+
+	proof := s.hmac(storedKey, authMessage)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+
+	s.serverSignature = s.hmac(serverKey, authMessage)
+
+	return withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+// verify makes sure the server knows the password as well.
+func (s *scram) verify(serverFinal string) error {
+	attrs := scramAttributes(serverFinal)
+
+	if e, ok := attrs["e"]; ok {
+		return fmt.Errorf("SCRAM authentication failed: %s", e)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(attrs["v"])
+	if err != nil || !hmac.Equal(signature, s.serverSignature) {
+		return errors.New("invalid SCRAM server signature")
+	}
+
+	return nil
+}
+
+func (s *scram) hmac(key []byte, msg string) []byte {
+	mac := hmac.New(s.hash, key)
+	mac.Write([]byte(msg))
+	return mac.Sum(nil)
+}
+
+func scramAttributes(msg string) map[string]string {
+	attrs := map[string]string{}
+	for _, attr := range strings.Split(msg, ",") {
+		if len(attr) > 1 && attr[1] == '=' {
+			attrs[attr[:1]] = attr[2:]
+		}
+	}
+	return attrs
+}
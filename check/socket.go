@@ -0,0 +1,50 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"net"
+)
+
+type SocketCheck interface {
+	IsOpen() bool
+	IsClosed() bool
+
+	WithLogger(io.Writer) SocketCheck
+}
+
+type socketcheck struct {
+	path   string
+	logger io.Writer
+}
+
+// Socket checks whether something is listening on the unix domain socket at
+// the given path.
+func Socket(path string) SocketCheck {
+	return &socketcheck{
+		path:   path,
+		logger: DefaultLogger,
+	}
+}
+
+func (s *socketcheck) WithLogger(w io.Writer) SocketCheck {
+	s.logger = w
+	return s
+}
+
+func (s *socketcheck) IsOpen() bool {
+	fmt.Fprintf(s.logger, "Dialing unix://%s\n", s.path)
+
+	conn, err := net.Dial("unix", s.path)
+	if err != nil {
+		fmt.Fprintln(s.logger, err.Error())
+		return false
+	}
+
+	conn.Close()
+	return true
+}
+
+func (s *socketcheck) IsClosed() bool {
+	return !s.IsOpen()
+}
@@ -0,0 +1,84 @@
+package check_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("socketcheck", func() {
+	var (
+		dir         string
+		path        string
+		logger      *gbytes.Buffer
+		socketcheck check.SocketCheck
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "socketcheck")
+		Expect(err).ToNot(HaveOccurred())
+
+		path = filepath.Join(dir, "app.sock")
+		logger = gbytes.NewBuffer()
+		socketcheck = check.Socket(path).WithLogger(logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Context("when something listens on the socket", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("unix", path)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		Describe(".IsOpen", func() {
+			It("returns true", func() {
+				Expect(socketcheck.IsOpen()).To(BeTrue())
+			})
+		})
+
+		Describe(".IsClosed", func() {
+			It("returns false", func() {
+				Expect(socketcheck.IsClosed()).To(BeFalse())
+			})
+		})
+	})
+
+	Context("when nothing listens on the socket", func() {
+		Describe(".IsOpen", func() {
+			It("returns false", func() {
+				Expect(socketcheck.IsOpen()).To(BeFalse())
+			})
+		})
+
+		Describe(".IsClosed", func() {
+			It("returns true", func() {
+				Expect(socketcheck.IsClosed()).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("logging", func() {
+		It("provides logging", func() {
+			socketcheck.IsOpen()
+			Expect(logger).To(gbytes.Say("Dialing unix://" + path))
+		})
+	})
+})
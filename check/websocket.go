@@ -0,0 +1,390 @@
+package check
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type WebSocketCheck interface {
+	IsReady() bool
+
+	WithHeader(string, string) WebSocketCheck
+	WithMessage(string) WebSocketCheck
+	WithMatch(*regexp.Regexp) WebSocketCheck
+	WithTLS(*tls.Config) WebSocketCheck
+	WithTimeout(time.Duration) WebSocketCheck
+	WithLogger(io.Writer) WebSocketCheck
+}
+
+type websocketcheck struct {
+	url       string
+	headers   map[string]string
+	message   string
+	match     *regexp.Regexp
+	tlsConfig *tls.Config
+	timeout   time.Duration
+	logger    io.Writer
+}
+
+// WebSocket checks whether the server at the given URL, 'ws://' or 'wss://',
+// completes the opening handshake of RFC 6455.
+func WebSocket(url string) WebSocketCheck {
+	return &websocketcheck{
+		url:     url,
+		headers: map[string]string{},
+		timeout: DefaultTimeout,
+		logger:  DefaultLogger,
+	}
+}
+
+// WithHeader adds a header to the upgrade request, e.g. 'Origin'.
+func (w *websocketcheck) WithHeader(key, value string) WebSocketCheck {
+	w.headers[key] = value
+	return w
+}
+
+// WithMessage sends the given text message once connected.
+func (w *websocketcheck) WithMessage(message string) WebSocketCheck {
+	w.message = message
+	return w
+}
+
+// WithMatch waits for a message from the server that matches the given
+// regular expression.
+func (w *websocketcheck) WithMatch(match *regexp.Regexp) WebSocketCheck {
+	w.match = match
+	return w
+}
+
+// WithTLS configures the connection to 'wss://' URLs. The server name
+// defaults to the host of the URL.
+func (w *websocketcheck) WithTLS(config *tls.Config) WebSocketCheck {
+	w.tlsConfig = config
+	return w
+}
+
+// WithTimeout limits the time a single attempt, i.e. connecting, the
+// handshake and waiting for a matching message, may take.
+func (w *websocketcheck) WithTimeout(timeout time.Duration) WebSocketCheck {
+	w.timeout = timeout
+	return w
+}
+
+func (w *websocketcheck) WithLogger(logger io.Writer) WebSocketCheck {
+	w.logger = logger
+	return w
+}
+
+// IsReady returns true once the server has accepted the upgrade and, if
+// requested, sent a matching message.
+func (w *websocketcheck) IsReady() bool {
+	if err := w.run(); err != nil {
+		fmt.Fprintln(w.logger, err.Error())
+		return false
+	}
+	return true
+}
+
+func (w *websocketcheck) run() error {
+	u, err := neturl.Parse(w.url)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %s", err)
+	}
+
+	var port string
+	switch u.Scheme {
+	case "ws":
+		port = "80"
+	case "wss":
+		port = "443"
+	default:
+		return fmt.Errorf("invalid URL: unsupported scheme '%s'", u.Scheme)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	fmt.Fprintf(w.logger, "Connecting to %s\n", w.url)
+
+	dialer := &net.Dialer{Timeout: w.timeout}
+
+	var raw net.Conn
+	if u.Scheme == "wss" {
+		config := w.tlsConfig
+		if config == nil {
+			config = &tls.Config{}
+		}
+
+		config = config.Clone()
+		if config.ServerName == "" {
+			config.ServerName = u.Hostname()
+		}
+
+		// the upgrade is an HTTP/1.1 mechanism
+		config.NextProtos = []string{"http/1.1"}
+
+		raw, err = tls.DialWithDialer(dialer, "tcp", addr, config)
+	} else {
+		raw, err = dialer.Dial("tcp", addr)
+	}
+
+	if err != nil {
+		return err
+	}
+	defer raw.Close()
+
+	raw.SetDeadline(time.Now().Add(w.timeout))
+
+	conn := &websocketConn{Conn: raw, reader: bufio.NewReader(raw)}
+
+	if err := conn.upgrade(u, w.headers); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w.logger, "Upgraded connection to WebSocket")
+
+	if w.message != "" {
+		fmt.Fprintf(w.logger, "Sending message '%s'\n", w.message)
+		if err := conn.writeFrame(websocketText, []byte(w.message)); err != nil {
+			return err
+		}
+	}
+
+	if w.match != nil {
+		if err := w.awaitMatch(conn); err != nil {
+			return err
+		}
+	}
+
+	conn.close(1000)
+	return nil
+}
+
+// awaitMatch reads messages until one of them matches.
+func (w *websocketcheck) awaitMatch(conn *websocketConn) error {
+	for {
+		message, err := conn.readMessage()
+		if err != nil {
+			return err
+		}
+
+		if w.match.Match(message) {
+			fmt.Fprintf(w.logger, "Received matching message '%s'\n", message)
+			return nil
+		}
+
+		fmt.Fprintf(w.logger, "Received message '%s' not matching '%s'\n", message, w.match)
+	}
+}
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	websocketContinuation = 0x0
+	websocketText         = 0x1
+	websocketBinary       = 0x2
+	websocketClose        = 0x8
+	websocketPing         = 0x9
+	websocketPong         = 0xa
+)
+
+// websocketConn speaks the client side of RFC 6455.
+type websocketConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// upgrade sends the opening handshake and verifies the response.
+func (c *websocketConn) upgrade(u *neturl.URL, headers map[string]string) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	target := *u
+	target.Scheme = strings.Replace(u.Scheme, "ws", "http", 1)
+
+	req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+
+	if err := req.Write(c); err != nil {
+		return err
+	}
+
+	resp, err := http.ReadResponse(c.reader, req)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		resp.Body.Close()
+		return fmt.Errorf("expected status 101 Switching Protocols, got %s", resp.Status)
+	}
+
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") {
+		return fmt.Errorf("server upgraded to '%s' instead of 'websocket'", resp.Header.Get("Upgrade"))
+	}
+
+	hash := sha1.Sum([]byte(key + websocketGUID))
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != base64.StdEncoding.EncodeToString(hash[:]) {
+		return fmt.Errorf("invalid Sec-WebSocket-Accept '%s'", accept)
+	}
+
+	return nil
+}
+
+// readMessage returns the next text or binary message, reassembling
+// fragments and answering pings along the way.
+func (c *websocketConn) readMessage() ([]byte, error) {
+	var message []byte
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case websocketPing:
+			if err := c.writeFrame(websocketPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case websocketPong:
+			continue
+		case websocketClose:
+			return nil, websocketCloseError(payload)
+		case websocketText, websocketBinary, websocketContinuation:
+			if len(message)+len(payload) > 1<<20 {
+				return nil, fmt.Errorf("message of more than %d bytes exceeds the limit of 1 MiB", len(message)+len(payload))
+			}
+			message = append(message, payload...)
+		default:
+			return nil, fmt.Errorf("unexpected opcode 0x%x", opcode)
+		}
+
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+func (c *websocketConn) readFrame() (bool, byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin, opcode, masked := header[0]&0x80 != 0, header[0]&0x0f, header[1]&0x80 != 0
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+
+	if length > 1<<20 {
+		return false, 0, nil, fmt.Errorf("frame of %d bytes exceeds the limit of 1 MiB", length)
+	}
+
+	mask := make([]byte, 4)
+	if masked {
+		if _, err := io.ReadFull(c.reader, mask); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// writeFrame sends a single, masked frame as required for clients.
+func (c *websocketConn) writeFrame(opcode byte, payload []byte) error {
+	var frame bytes.Buffer
+	frame.WriteByte(0x80 | opcode)
+
+	switch {
+	case len(payload) < 126:
+		frame.WriteByte(0x80 | byte(len(payload)))
+	case len(payload) <= 0xffff:
+		frame.WriteByte(0x80 | 126)
+		binary.Write(&frame, binary.BigEndian, uint16(len(payload)))
+	default:
+		frame.WriteByte(0x80 | 127)
+		binary.Write(&frame, binary.BigEndian, uint64(len(payload)))
+	}
+
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	frame.Write(mask)
+
+	for i, b := range payload {
+		frame.WriteByte(b ^ mask[i%4])
+	}
+
+	_, err := c.Write(frame.Bytes())
+	return err
+}
+
+// close sends a close frame with the given status code without waiting for
+// the server to answer.
+func (c *websocketConn) close(code uint16) {
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, code)
+	c.writeFrame(websocketClose, payload)
+}
+
+func websocketCloseError(payload []byte) error {
+	if len(payload) < 2 {
+		return errors.New("connection closed by server")
+	}
+	return fmt.Errorf("connection closed by server: %d %s", binary.BigEndian.Uint16(payload), payload[2:])
+}
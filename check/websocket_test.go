@@ -0,0 +1,191 @@
+package check_test
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/websocket"
+
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("websocketcheck", func() {
+	var (
+		server   *httptest.Server
+		handler  http.Handler
+		url      string
+		logger   *gbytes.Buffer
+		received chan string
+		headers  chan http.Header
+	)
+
+	BeforeEach(func() {
+		logger = gbytes.NewBuffer()
+		received = make(chan string, 10)
+		headers = make(chan http.Header, 1)
+
+		// echo every message, prefixed by a greeting that does not match
+		handler = websocket.Server{Handler: func(ws *websocket.Conn) {
+			headers <- ws.Request().Header
+
+			websocket.Message.Send(ws, "welcome")
+
+			for {
+				var message string
+				if err := websocket.Message.Receive(ws, &message); err != nil {
+					return
+				}
+
+				received <- message
+				websocket.Message.Send(ws, "echo: "+message)
+			}
+		}}
+	})
+
+	JustBeforeEach(func() {
+		server = httptest.NewServer(handler)
+		url = "ws" + strings.TrimPrefix(server.URL, "http") + "/realtime"
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("performs the upgrade", func() {
+		Expect(check.WebSocket(url).WithLogger(logger).IsReady()).To(BeTrue())
+		Expect(logger).To(gbytes.Say("Connecting to " + url))
+		Expect(logger).To(gbytes.Say("Upgraded connection to WebSocket"))
+	})
+
+	It("sends the given headers", func() {
+		Expect(check.WebSocket(url).WithHeader("Authorization", "Bearer token").WithLogger(logger).IsReady()).To(BeTrue())
+
+		var header http.Header
+		Eventually(headers).Should(Receive(&header))
+		Expect(header.Get("Authorization")).To(Equal("Bearer token"))
+	})
+
+	It("fails if the URL is invalid", func() {
+		Expect(check.WebSocket("http://localhost").WithLogger(logger).IsReady()).To(BeFalse())
+		Expect(logger).To(gbytes.Say("invalid URL: unsupported scheme 'http'"))
+	})
+
+	Context("when the server does not upgrade", func() {
+		BeforeEach(func() {
+			handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "not a websocket endpoint", http.StatusBadRequest)
+			})
+		})
+
+		It("returns false", func() {
+			Expect(check.WebSocket(url).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("expected status 101 Switching Protocols, got 400 Bad Request"))
+		})
+	})
+
+	Context("when the server answers with an invalid accept key", func() {
+		BeforeEach(func() {
+			handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Upgrade", "websocket")
+				w.Header().Set("Connection", "Upgrade")
+				w.Header().Set("Sec-WebSocket-Accept", "bogus")
+				w.WriteHeader(http.StatusSwitchingProtocols)
+			})
+		})
+
+		It("returns false", func() {
+			Expect(check.WebSocket(url).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("invalid Sec-WebSocket-Accept 'bogus'"))
+		})
+	})
+
+	Describe(".WithMessage and .WithMatch", func() {
+		It("send a text message and wait for a matching reply", func() {
+			Expect(check.WebSocket(url).WithMessage("ping").WithMatch(regexp.MustCompile(`^echo: ping$`)).WithLogger(logger).IsReady()).To(BeTrue())
+			Expect(received).To(Receive(Equal("ping")))
+			Expect(logger).To(gbytes.Say("Received message 'welcome' not matching"))
+			Expect(logger).To(gbytes.Say("Received matching message 'echo: ping'"))
+		})
+
+		It("time out if no message matches", func() {
+			Expect(check.WebSocket(url).WithMatch(regexp.MustCompile(`^ready$`)).WithTimeout(100 * time.Millisecond).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("i/o timeout"))
+		})
+
+		Context("when the server closes the connection", func() {
+			BeforeEach(func() {
+				handler = websocket.Server{Handler: func(ws *websocket.Conn) {
+					ws.WriteClose(1013)
+				}}
+			})
+
+			It("returns false", func() {
+				Expect(check.WebSocket(url).WithMatch(regexp.MustCompile(`.`)).WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("connection closed by server: 1013"))
+			})
+		})
+
+		Context("when a fragmented message exceeds 1 MiB", func() {
+			BeforeEach(func() {
+				handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+
+					sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+
+					conn, rw, err := w.(http.Hijacker).Hijack()
+					Expect(err).ToNot(HaveOccurred())
+					defer conn.Close()
+
+					rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+					rw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+
+					fragment := bytes.Repeat([]byte("a"), 600*1024)
+					for _, first := range []byte{0x01, 0x80} {
+						rw.Write([]byte{first, 127, 0, 0, 0, 0, 0, byte(len(fragment) >> 16), byte(len(fragment) >> 8), byte(len(fragment))})
+						rw.Write(fragment)
+					}
+					rw.Flush()
+
+					ioutil.ReadAll(conn)
+				})
+			})
+
+			It("returns false", func() {
+				Expect(check.WebSocket(url).WithMatch(regexp.MustCompile(`.`)).WithLogger(logger).IsReady()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("exceeds the limit of 1 MiB"))
+			})
+		})
+	})
+
+	Describe(".WithTLS", func() {
+		JustBeforeEach(func() {
+			server.Close()
+			server = httptest.NewTLSServer(handler)
+			url = "wss" + strings.TrimPrefix(server.URL, "https") + "/realtime"
+		})
+
+		It("connects to wss URLs", func() {
+			pool := x509.NewCertPool()
+			pool.AddCert(server.Certificate())
+
+			Expect(check.WebSocket(url).WithTLS(&tls.Config{RootCAs: pool}).WithMessage("ping").WithMatch(regexp.MustCompile(`ping`)).WithLogger(logger).IsReady()).To(BeTrue())
+		})
+
+		It("verifies the certificate", func() {
+			Expect(check.WebSocket(url).WithLogger(logger).IsReady()).To(BeFalse())
+			Expect(logger).To(gbytes.Say("certificate"))
+		})
+	})
+})
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+)
+
+// conditionBuilders turn the context of a command into the names and
+// conditions it waits for, so that the command can be part of an invocation
+// of 'all' or 'any'.
+var conditionBuilders = map[string]func(*cli.Context) ([]string, []waitfor.Check){
+	"port":     portConditions,
+	"curl":     curlConditions,
+	"sh":       shellConditions,
+	"on":       targets,
+	"postgres": postgresConditions,
+	"mysql":    mysqlConditions,
+	"redis":    redisConditions,
+	"grpc":     grpcConditions,
+	"amqp":     amqpConditions,
+	"kafka":    kafkaConditions,
+	"mongo":    mongoConditions,
+	"probe":    probeConditions,
+	"ws":       webSocketConditions,
+}
+
+var allCommand = cli.Command{
+	Name:  "all",
+	Usage: "wait for multiple commands separated by '--' concurrently, e.g. 'all -- port 5432 -- curl http://api/health'",
+
+	ArgsUsage: "-- <command> [arguments...] [-- <command> [arguments...]]...",
+
+	HideHelp: true,
+
+	Flags: []cli.Flag{
+		timeoutFlag,
+		intervalFlag,
+		verboseFlag,
+		strictFlag,
+		traceFlag,
+		quietFlag,
+	},
+
+	Action: invocationsAction,
+}
+
+var anyCommand = cli.Command{
+	Name:  "any",
+	Usage: "wait for one of multiple commands separated by '--', e.g. 'any -- port 5432 -- port 5433'",
+
+	ArgsUsage: allCommand.ArgsUsage,
+
+	HideHelp: true,
+
+	Flags: allCommand.Flags,
+
+	Action: invocationsAction,
+}
+
+// invocationsAction splits the positional arguments on '--' into invocations
+// of other commands and waits for the conditions of all of them under a
+// single timeout. Flags of the invocations configure their checks, but the
+// timeout and interval of 'all' and 'any' apply.
+func invocationsAction(c *cli.Context) error {
+	invocations := splitInvocations(positional(c))
+	if len(invocations) == 0 {
+		cli.ShowCommandHelp(c, c.Command.Name)
+		fmt.Fprintln(c.App.Writer, "must specify commands")
+		exitWith(c, exitUsage)
+		return usageError(errors.New("must specify commands"))
+	}
+
+	// Commands run by sh checks are killed once the timeout has been exceeded.
+	ctx, cancel := context.WithTimeout(waitContext(c), c.Duration("timeout"))
+	defer cancel()
+	c.App.Metadata[contextKey] = ctx
+
+	var (
+		names      []string
+		conditions []waitfor.Check
+	)
+
+	for _, args := range invocations {
+		n, cs, err := invoke(c, args)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range n {
+			names = append(names, fmt.Sprintf("%s %s", args[0], name))
+		}
+		conditions = append(conditions, cs...)
+	}
+
+	return waitForConditions(c, names, conditions, "checks", "ready")
+}
+
+// invoke runs the command named by the first argument with the remaining
+// arguments and returns the names and conditions of its checks instead of
+// waiting for them.
+func invoke(c *cli.Context, args []string) ([]string, []waitfor.Check, error) {
+	command := c.App.Command(args[0])
+	if command == nil {
+		return nil, nil, unsupportedInvocation(c, args[0])
+	}
+
+	build, ok := conditionBuilders[command.Name]
+	if !ok {
+		return nil, nil, unsupportedInvocation(c, args[0])
+	}
+
+	var (
+		names      []string
+		conditions []waitfor.Check
+	)
+
+	cmd := *command
+	cmd.Action = func(c *cli.Context) error {
+		names, conditions = build(c)
+		return nil
+	}
+
+	// The invoked command parses its flags from the tail of the arguments.
+	set := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+	set.Parse(args)
+
+	if err := cmd.Run(cli.NewContext(c.App, set, c)); err != nil {
+		return nil, nil, err
+	}
+
+	return names, conditions, nil
+}
+
+func unsupportedInvocation(c *cli.Context, name string) error {
+	var names []string
+	for n := range conditionBuilders {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	err := fmt.Errorf("unsupported command '%s', must be one of %s", name, strings.Join(names, ", "))
+	fmt.Fprintln(c.App.Writer, err)
+	exitWith(c, exitUsage)
+	return usageError(err)
+}
+
+// splitInvocations splits the given arguments on '--', skipping empty
+// invocations, e.g. a leading '--'.
+func splitInvocations(args []string) [][]string {
+	var (
+		result  [][]string
+		current []string
+	)
+
+	for _, arg := range append(args, "--") {
+		if arg != "--" {
+			current = append(current, arg)
+			continue
+		}
+
+		if len(current) > 0 {
+			result = append(result, current)
+		}
+		current = nil
+	}
+
+	return result
+}
+
+// waitForAny returns true if waiting for one of multiple conditions is
+// sufficient.
+func waitForAny(c *cli.Context) bool {
+	return c.Bool("any") || c.Command.Name == "any"
+}
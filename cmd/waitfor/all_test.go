@@ -0,0 +1,241 @@
+package main
+
+import (
+	"io"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/ghttp"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+	"github.com/st3v/waitfor/cmd/waitfor/fake"
+)
+
+var _ = Describe("all and any commands", func() {
+	var (
+		portcheck *fake.PortCheck
+		server    *ghttp.Server
+		command   string
+		args      []string
+
+		actualPorts    []int
+		actualResults  []bool
+		actualInterval time.Duration
+		actualTimeout  time.Duration
+		actualAny      bool
+		actualOutput   *gbytes.Buffer
+		actualErr      error
+	)
+
+	wait := func(checks []waitfor.Check, interval, timeout time.Duration, ctx context.Context) []error {
+		actualInterval = interval
+		actualTimeout = timeout
+
+		errs := make([]error, len(checks))
+		for i, check := range checks {
+			result := check()
+			actualResults = append(actualResults, result)
+			if !result {
+				errs[i] = waitfor.ErrTimeoutExceeded
+			}
+		}
+		return errs
+	}
+
+	BeforeEach(func() {
+		portcheck = new(fake.PortCheck)
+		portcheck.OnHostReturns(portcheck)
+		portcheck.ForNetworkReturns(portcheck)
+		portcheck.WithPayloadReturns(portcheck)
+		portcheck.WithReplyTimeoutReturns(portcheck)
+		portcheck.WithNoReplyOKReturns(portcheck)
+		portcheck.WithLoggerReturns(portcheck)
+		portcheck.IsOpenReturns(true)
+		portCheckProvider = func(port int) check.PortCheck {
+			actualPorts = append(actualPorts, port)
+			return portcheck
+		}
+		curlCheckProvider = check.Curl
+
+		server = ghttp.NewServer()
+		server.RouteToHandler("GET", "/health", ghttp.RespondWith(200, "ok"))
+
+		command = "all"
+		args = []string{}
+		actualPorts = nil
+		actualResults = nil
+		actualAny = false
+		actualOutput = gbytes.NewBuffer()
+
+		waitForAllWithTimeout = wait
+		waitForAnyWithTimeout = func(checks []waitfor.Check, interval, timeout time.Duration, ctx context.Context) []error {
+			actualAny = true
+			return wait(checks, interval, timeout, ctx)
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	JustBeforeEach(func() {
+		app := app()
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		actualErr = app.Run(append([]string{"waitfor", command}, args...))
+	})
+
+	Context("when invocations of different commands have been specified", func() {
+		BeforeEach(func() {
+			args = []string{
+				"-t", "1m", "-i", "2s",
+				"--", "port", "5432", "-h", "db",
+				"--", "curl", server.URL() + "/health",
+				"--", "sh", "false",
+			}
+		})
+
+		It("waits for all of them under a single timeout", func() {
+			Expect(actualAny).To(BeFalse())
+			Expect(actualResults).To(Equal([]bool{true, true, false}))
+			Expect(actualInterval).To(Equal(2 * time.Second))
+			Expect(actualTimeout).To(Equal(time.Minute))
+		})
+
+		It("applies the flags of each invocation", func() {
+			Expect(actualPorts).To(Equal([]int{5432}))
+			Expect(portcheck.OnHostArgsForCall(0)).To(Equal("db"))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("reports the status of each invocation", func() {
+			Expect(actualOutput).To(gbytes.Say("Waiting for all of 3 checks to be ready"))
+			Expect(actualOutput).To(gbytes.Say("port tcp://db:5432: ready"))
+			Expect(actualOutput).To(gbytes.Say("curl " + server.URL() + "/health: ready"))
+			Expect(actualOutput).To(gbytes.Say("sh false: timeout exceeded"))
+			Expect(actualOutput).To(gbytes.Say("1 of 3 checks not ready"))
+		})
+
+		It("returns an error", func() {
+			Expect(actualErr).To(HaveOccurred())
+		})
+
+		Context("when using the any command", func() {
+			BeforeEach(func() {
+				command = "any"
+			})
+
+			It("waits for any of them", func() {
+				Expect(actualAny).To(BeTrue())
+				Expect(actualOutput).To(gbytes.Say("Waiting for any of 3 checks to be ready"))
+				Expect(actualOutput).To(gbytes.Say("Success: 2 of 3 checks ready"))
+				Expect(actualErr).ToNot(HaveOccurred())
+			})
+		})
+	})
+
+	Context("when an invocation runs the on command", func() {
+		BeforeEach(func() {
+			args = []string{"--", "on", "tcp://db:5432", "cache:6379"}
+		})
+
+		It("waits for all of its targets", func() {
+			Expect(actualPorts).To(Equal([]int{5432, 6379}))
+			Expect(actualOutput).To(gbytes.Say("on tcp://db:5432: ready"))
+			Expect(actualOutput).To(gbytes.Say("on tcp://cache:6379: ready"))
+		})
+	})
+
+	Describe("invalid usage", func() {
+		var exitCode int
+
+		BeforeEach(func() {
+			exitCode = 0
+			exit = func(rc int) {
+				exitCode = rc
+			}
+		})
+
+		AfterEach(func() {
+			exit = os.Exit
+		})
+
+		Context("when no invocations have been specified", func() {
+			BeforeEach(func() {
+				args = []string{"--"}
+			})
+
+			It("exits with the exit code for invalid usage", func() {
+				Expect(exitCode).To(Equal(exitUsage))
+				Expect(actualOutput).To(gbytes.Say("must specify commands"))
+			})
+		})
+
+		Context("when an invocation runs an unsupported command", func() {
+			BeforeEach(func() {
+				args = []string{"--", "port", "5432", "--", "serve"}
+			})
+
+			It("exits with the exit code for invalid usage", func() {
+				Expect(exitCode).To(Equal(exitUsage))
+				Expect(actualOutput).To(gbytes.Say("unsupported command 'serve', must be one of .*curl, .*port, .*sh"))
+			})
+
+			It("does not wait", func() {
+				Expect(actualResults).To(BeEmpty())
+			})
+		})
+
+		Context("when an invocation has invalid flags", func() {
+			BeforeEach(func() {
+				args = []string{"--", "port", "--unknown", "5432"}
+			})
+
+			It("exits with the exit code for invalid usage", func() {
+				Expect(actualErr).To(BeAssignableToTypeOf(&exitError{}))
+				Expect(actualErr.(*exitError).code).To(Equal(exitUsage))
+				Expect(actualOutput).To(gbytes.Say("Incorrect Usage"))
+			})
+		})
+	})
+})
+
+var _ = Describe("splitCommand", func() {
+	split := func(args ...string) ([]string, []string) {
+		return splitCommand(app(), args)
+	}
+
+	It("splits off the command following '--'", func() {
+		args, command := split("waitfor", "port", "5432", "--", "app", "--flag")
+		Expect(args).To(Equal([]string{"waitfor", "port", "5432"}))
+		Expect(command).To(Equal([]string{"app", "--flag"}))
+	})
+
+	It("skips global flags", func() {
+		args, command := split("waitfor", "-t", "1m", "port", "5432", "--", "app")
+		Expect(args).To(Equal([]string{"waitfor", "-t", "1m", "port", "5432"}))
+		Expect(command).To(Equal([]string{"app"}))
+	})
+
+	It("does not mistake flag values for the all and any commands", func() {
+		args, command := split("waitfor", "port", "--host", "any", "1", "--", "echo", "hi")
+		Expect(args).To(Equal([]string{"waitfor", "port", "--host", "any", "1"}))
+		Expect(command).To(Equal([]string{"echo", "hi"}))
+	})
+
+	It("keeps the separators of the all and any commands", func() {
+		args, command := split("waitfor", "all", "--", "port", "5432", "--", "curl", "http://api")
+		Expect(args).To(Equal([]string{"waitfor", "all", "--", "port", "5432", "--", "curl", "http://api"}))
+		Expect(command).To(BeNil())
+	})
+
+	It("splits off the command following '-- exec' for the all and any commands", func() {
+		args, command := split("waitfor", "-t", "1m", "any", "--", "port", "5432", "--", "port", "5433", "--", "exec", "app", "--", "x")
+		Expect(args).To(Equal([]string{"waitfor", "-t", "1m", "any", "--", "port", "5432", "--", "port", "5433"}))
+		Expect(command).To(Equal([]string{"app", "--", "x"}))
+	})
+})
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/codegangsta/cli"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+)
+
+var amqpCheckProvider = check.AMQP
+
+var amqpCommand = cli.Command{
+	Name:  "amqp",
+	Usage: "wait for an AMQP 0-9-1 broker, e.g. RabbitMQ, to accept connections to a vhost",
+
+	ArgsUsage: "<url>...",
+
+	HideHelp: true,
+
+	Flags: []cli.Flag{
+		queueFlag,
+		exchangeFlag,
+		tlsFlag,
+		insecureFlag,
+		anyFlag,
+		connectTimeoutFlag,
+		timeoutFlag,
+		intervalFlag,
+		verboseFlag,
+		strictFlag,
+		traceFlag,
+		quietFlag,
+	},
+
+	Action: func(c *cli.Context) error {
+		names, conditions := amqpConditions(c)
+		return waitFor(c, names, conditions, "brokers", "ready")
+	},
+}
+
+// amqpConditions returns the URLs given as positional arguments, without
+// passwords, and the corresponding conditions.
+func amqpConditions(c *cli.Context) ([]string, []waitfor.Check) {
+	if !positional(c).Present() {
+		cli.ShowCommandHelp(c, "amqp")
+		fmt.Fprintln(c.App.Writer, "must specify URL")
+		exitWith(c, exitUsage)
+	}
+
+	var (
+		names      []string
+		conditions []waitfor.Check
+	)
+
+	for _, url := range positional(c) {
+		names = append(names, redactDSN(url))
+		conditions = append(conditions, amqpCondition(c, url))
+	}
+
+	return names, conditions
+}
+
+func amqpCondition(c *cli.Context, url string) waitfor.Check {
+	target := currentWait(c).Target("amqp", redactDSN(url))
+	amqp := amqpCheckProvider(url).WithLogger(targetLogger(c, target))
+
+	if timeout := c.Duration("connect-timeout"); timeout > 0 {
+		amqp.WithTimeout(timeout)
+	}
+
+	if config := tlsConfig(c); config != nil {
+		amqp.WithTLS(config)
+	}
+
+	for _, queue := range c.StringSlice("queue") {
+		amqp.WithQueue(queue)
+	}
+
+	for _, exchange := range c.StringSlice("exchange") {
+		amqp.WithExchange(exchange)
+	}
+
+	return target.Check(amqp.IsReady)
+}
@@ -0,0 +1,574 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"gopkg.in/yaml.v2"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+	"github.com/st3v/waitfor/tracing"
+)
+
+// config describes a set of named checks. Since JSON is a subset of YAML,
+// configs can be written in either format.
+type config struct {
+	Timeout  time.Duration `yaml:"timeout"`
+	Interval time.Duration `yaml:"interval"`
+	Checks   []checkConfig `yaml:"checks"`
+}
+
+type checkConfig struct {
+	Name      string        `yaml:"name"`
+	Kind      string        `yaml:"kind"`
+	DependsOn []string      `yaml:"depends_on"`
+	Interval  time.Duration `yaml:"interval"`
+	Timeout   time.Duration `yaml:"timeout"`
+
+	// port
+	Host         string        `yaml:"host"`
+	Port         int           `yaml:"port"`
+	Network      string        `yaml:"network"`
+	Payload      string        `yaml:"payload"`
+	ReplyTimeout time.Duration `yaml:"reply_timeout"`
+	NoReplyOK    bool          `yaml:"no_reply_ok"`
+	Closed       bool          `yaml:"closed"`
+	Protocol     string        `yaml:"protocol"`
+	Fingerprint  string        `yaml:"fingerprint"`
+
+	// curl
+	URL     string            `yaml:"url"`
+	Method  string            `yaml:"method"`
+	Status  int               `yaml:"status"`
+	User    string            `yaml:"user"`
+	Headers map[string]string `yaml:"headers"`
+	Data    string            `yaml:"data"`
+
+	// sh
+	Command  []string `yaml:"command"`
+	ExitCode int      `yaml:"exit_code"`
+
+	// file, socket
+	Path string `yaml:"path"`
+
+	// postgres, mysql
+	DSN            string        `yaml:"dsn"`
+	Query          string        `yaml:"query"`
+	Primary        bool          `yaml:"primary"`
+	Ping           bool          `yaml:"ping"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+
+	// redis
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	Info     []string `yaml:"info"`
+
+	// redis, grpc, amqp, mongo, ws
+	TLS      bool `yaml:"tls"`
+	Insecure bool `yaml:"insecure"`
+
+	// grpc
+	Service string `yaml:"service"`
+	Watch   bool   `yaml:"watch"`
+
+	// amqp
+	Queues    []string `yaml:"queues"`
+	Exchanges []string `yaml:"exchanges"`
+
+	// kafka
+	Brokers    []string `yaml:"brokers"`
+	Topics     []string `yaml:"topics"`
+	Partitions int      `yaml:"partitions"`
+
+	// mongo
+	WritablePrimary bool `yaml:"writable_primary"`
+	HasPrimary      bool `yaml:"has_primary"`
+
+	// probe
+	Probe string `yaml:"probe"`
+
+	// ws
+	Message string `yaml:"message"`
+
+	// curl, sh, ws
+	Match string `yaml:"match"`
+	Fail  bool   `yaml:"fail"`
+}
+
+type checkBuilder func(checkConfig, io.Writer, check.Tracer, context.Context) (waitfor.Check, error)
+
+// checkBuilders maps the kind of a configured check to the function turning
+// it into a condition. New kinds of checks only need to register here.
+var checkBuilders = map[string]checkBuilder{
+	"port":     portCheckFromConfig,
+	"curl":     curlCheckFromConfig,
+	"sh":       shellCheckFromConfig,
+	"file":     fileCheckFromConfig,
+	"socket":   socketCheckFromConfig,
+	"postgres": postgresCheckFromConfig,
+	"mysql":    mysqlCheckFromConfig,
+	"redis":    redisCheckFromConfig,
+	"grpc":     grpcCheckFromConfig,
+	"amqp":     amqpCheckFromConfig,
+	"kafka":    kafkaCheckFromConfig,
+	"mongo":    mongoCheckFromConfig,
+	"probe":    probeCheckFromConfig,
+	"ws":       webSocketCheckFromConfig,
+}
+
+func loadConfig(path string) (config, error) {
+	var cfg config
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return cfg, err
+	}
+
+	return cfg, cfg.validate()
+}
+
+func (cfg config) validate() error {
+	for i, c := range cfg.Checks {
+		if _, ok := checkBuilders[c.Kind]; !ok {
+			return fmt.Errorf("check #%d has unknown kind '%s'", i+1, c.Kind)
+		}
+	}
+
+	return nil
+}
+
+// checkObserver records the evaluations of a named check, e.g. as metrics.
+type checkObserver interface {
+	Observe(name, kind string, check waitfor.Check) waitfor.Check
+}
+
+// graph turns the configured checks into a dependency graph. This fails if
+// the dependencies between checks are invalid, e.g. because of cycles. Every
+// evaluation of every check is traced as an attempt of the given wait and
+// recorded by the given observers. Commands run by checks are killed once the
+// given context is done.
+func (cfg config) graph(logger io.Writer, wait *tracing.Wait, ctx context.Context, observers ...checkObserver) (*waitfor.Graph, error) {
+	nodes := make([]waitfor.Node, len(cfg.Checks))
+
+	for i, c := range cfg.Checks {
+		target := wait.Target(c.Kind, c.Name)
+
+		condition, err := c.condition(io.MultiWriter(logger, target), target, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		condition = target.Check(condition)
+
+		for _, observer := range observers {
+			condition = observer.Observe(c.Name, c.Kind, condition)
+		}
+
+		nodes[i] = waitfor.Node{
+			Name:      c.Name,
+			Check:     condition,
+			DependsOn: c.DependsOn,
+			Interval:  c.Interval,
+			Timeout:   c.Timeout,
+		}
+	}
+
+	return waitfor.NewGraph(nodes...)
+}
+
+func (c checkConfig) condition(logger io.Writer, tracer check.Tracer, ctx context.Context) (waitfor.Check, error) {
+	return checkBuilders[c.Kind](c, logger, tracer, ctx)
+}
+
+func portCheckFromConfig(c checkConfig, logger io.Writer, tracer check.Tracer, ctx context.Context) (waitfor.Check, error) {
+	if c.Port == 0 {
+		return nil, fmt.Errorf("check '%s' must specify port", c.Name)
+	}
+
+	if c.Protocol != "" {
+		return bannerCheckFromConfig(c, logger)
+	}
+
+	payload, err := unescape(c.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("check '%s' has invalid payload: %s", c.Name, err)
+	}
+
+	portCheck := portCheckProvider(c.Port).WithLogger(logger).WithPayload([]byte(payload))
+
+	if c.Host != "" {
+		portCheck.OnHost(c.Host)
+	}
+
+	if c.Network != "" {
+		portCheck.ForNetwork(c.Network)
+	}
+
+	if c.ReplyTimeout != 0 {
+		portCheck.WithReplyTimeout(c.ReplyTimeout)
+	}
+
+	if c.NoReplyOK {
+		portCheck.WithNoReplyOK(true)
+	}
+
+	if c.Closed {
+		return portCheck.IsClosed, nil
+	}
+
+	return portCheck.IsOpen, nil
+}
+
+func bannerCheckFromConfig(c checkConfig, logger io.Writer) (waitfor.Check, error) {
+	if !isBannerProtocol(c.Protocol) {
+		return nil, fmt.Errorf("check '%s' has invalid protocol '%s', must be one of %s", c.Name, c.Protocol, strings.Join(check.BannerProtocols(), ", "))
+	}
+
+	host := c.Host
+	if host == "" {
+		host = check.DefaultHost
+	}
+
+	banner := bannerCheckProvider(c.Protocol, net.JoinHostPort(host, strconv.Itoa(c.Port))).WithLogger(logger)
+
+	if c.ConnectTimeout != 0 {
+		banner.WithTimeout(c.ConnectTimeout)
+	}
+
+	if c.Fingerprint != "" {
+		banner.WithFingerprint(c.Fingerprint)
+	}
+
+	return banner.IsReady, nil
+}
+
+func curlCheckFromConfig(c checkConfig, logger io.Writer, tracer check.Tracer, ctx context.Context) (waitfor.Check, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("check '%s' must specify url", c.Name)
+	}
+
+	curlCheck := curlCheckProvider(c.URL).WithLogger(logger).WithTracer(tracer)
+
+	if c.Method != "" {
+		curlCheck.WithMethod(c.Method)
+	}
+
+	if c.User != "" {
+		curlCheck.WithAuth(splitByColon(c.User))
+	}
+
+	for k, v := range c.Headers {
+		curlCheck.WithHeader(k, v)
+	}
+
+	if c.Data != "" {
+		curlCheck.WithData(strings.NewReader(c.Data))
+	}
+
+	status := c.Status
+	if status == 0 {
+		status = 200
+	}
+
+	condition := func() bool {
+		return curlCheck.MatchResponseCode(status)
+	}
+
+	if c.Match != "" {
+		r, err := regexp.Compile(c.Match)
+		if err != nil {
+			return nil, fmt.Errorf("check '%s' has invalid regex: %s", c.Name, err)
+		}
+
+		condition = func() bool {
+			return curlCheck.MatchBody(r)
+		}
+	}
+
+	if c.Fail {
+		return func() bool {
+			return !condition()
+		}, nil
+	}
+
+	return condition, nil
+}
+
+func shellCheckFromConfig(c checkConfig, logger io.Writer, tracer check.Tracer, ctx context.Context) (waitfor.Check, error) {
+	if len(c.Command) == 0 {
+		return nil, fmt.Errorf("check '%s' must specify command", c.Name)
+	}
+
+	command := check.Command(c.Command[0], c.Command[1:]...).WithLogger(logger).WithContext(ctx)
+
+	condition := command.Succeeds
+
+	if c.Fail {
+		condition = command.Fails
+	}
+
+	if c.ExitCode != 0 {
+		condition = func() bool {
+			return command.MatchesExitCode(c.ExitCode)
+		}
+	}
+
+	if c.Match != "" {
+		r, err := regexp.Compile(c.Match)
+		if err != nil {
+			return nil, fmt.Errorf("check '%s' has invalid regex: %s", c.Name, err)
+		}
+
+		condition = func() bool {
+			return command.MatchesOutput(r)
+		}
+	}
+
+	return condition, nil
+}
+
+func fileCheckFromConfig(c checkConfig, logger io.Writer, tracer check.Tracer, ctx context.Context) (waitfor.Check, error) {
+	if c.Path == "" {
+		return nil, fmt.Errorf("check '%s' must specify path", c.Name)
+	}
+
+	return fileCheckProvider(c.Path).WithLogger(logger).Exists, nil
+}
+
+func socketCheckFromConfig(c checkConfig, logger io.Writer, tracer check.Tracer, ctx context.Context) (waitfor.Check, error) {
+	if c.Path == "" {
+		return nil, fmt.Errorf("check '%s' must specify path", c.Name)
+	}
+
+	return socketCheckProvider(c.Path).WithLogger(logger).IsOpen, nil
+}
+
+func postgresCheckFromConfig(c checkConfig, logger io.Writer, tracer check.Tracer, ctx context.Context) (waitfor.Check, error) {
+	if c.DSN == "" {
+		return nil, fmt.Errorf("check '%s' must specify dsn", c.Name)
+	}
+
+	pg := postgresCheckProvider(c.DSN).WithLogger(logger).WithQuery(c.Query)
+
+	if c.ConnectTimeout != 0 {
+		pg.WithTimeout(c.ConnectTimeout)
+	}
+
+	if c.Primary {
+		return pg.IsPrimary, nil
+	}
+
+	return pg.IsReady, nil
+}
+
+func mysqlCheckFromConfig(c checkConfig, logger io.Writer, tracer check.Tracer, ctx context.Context) (waitfor.Check, error) {
+	if c.DSN == "" {
+		return nil, fmt.Errorf("check '%s' must specify dsn", c.Name)
+	}
+
+	mysql := mysqlCheckProvider(c.DSN).WithLogger(logger).WithQuery(c.Query)
+
+	if c.ConnectTimeout != 0 {
+		mysql.WithTimeout(c.ConnectTimeout)
+	}
+
+	if c.Ping {
+		mysql.WithPing()
+	}
+
+	return mysql.IsReady, nil
+}
+
+func redisCheckFromConfig(c checkConfig, logger io.Writer, tracer check.Tracer, ctx context.Context) (waitfor.Check, error) {
+	host := c.Host
+	if host == "" {
+		host = check.DefaultHost
+	}
+
+	addr := host
+	if c.Port != 0 {
+		addr = net.JoinHostPort(host, strconv.Itoa(c.Port))
+	}
+
+	redis := redisCheckProvider(addr).WithLogger(logger).WithUsername(c.Username).WithPassword(c.Password)
+
+	if c.ConnectTimeout != 0 {
+		redis.WithTimeout(c.ConnectTimeout)
+	}
+
+	if c.TLS || c.Insecure {
+		redis.WithTLS(&tls.Config{InsecureSkipVerify: c.Insecure})
+	}
+
+	for _, field := range c.Info {
+		redis.WithInfo(field)
+	}
+
+	return redis.IsReady, nil
+}
+
+func grpcCheckFromConfig(c checkConfig, logger io.Writer, tracer check.Tracer, ctx context.Context) (waitfor.Check, error) {
+	if c.Port == 0 {
+		return nil, fmt.Errorf("check '%s' must specify port", c.Name)
+	}
+
+	host := c.Host
+	if host == "" {
+		host = check.DefaultHost
+	}
+
+	grpc := grpcHealthCheckProvider(net.JoinHostPort(host, strconv.Itoa(c.Port)), c.Service).WithLogger(logger)
+
+	if c.ConnectTimeout != 0 {
+		grpc.WithTimeout(c.ConnectTimeout)
+	}
+
+	if c.TLS || c.Insecure {
+		grpc.WithTLS(&tls.Config{InsecureSkipVerify: c.Insecure})
+	}
+
+	if c.Watch {
+		grpc.WithWatch()
+	}
+
+	return grpc.IsServing, nil
+}
+
+func amqpCheckFromConfig(c checkConfig, logger io.Writer, tracer check.Tracer, ctx context.Context) (waitfor.Check, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("check '%s' must specify url", c.Name)
+	}
+
+	amqp := amqpCheckProvider(c.URL).WithLogger(logger)
+
+	if c.ConnectTimeout != 0 {
+		amqp.WithTimeout(c.ConnectTimeout)
+	}
+
+	if c.TLS || c.Insecure {
+		amqp.WithTLS(&tls.Config{InsecureSkipVerify: c.Insecure})
+	}
+
+	for _, queue := range c.Queues {
+		amqp.WithQueue(queue)
+	}
+
+	for _, exchange := range c.Exchanges {
+		amqp.WithExchange(exchange)
+	}
+
+	return amqp.IsReady, nil
+}
+
+func kafkaCheckFromConfig(c checkConfig, logger io.Writer, tracer check.Tracer, ctx context.Context) (waitfor.Check, error) {
+	if len(c.Brokers) == 0 {
+		return nil, fmt.Errorf("check '%s' must specify brokers", c.Name)
+	}
+
+	kafka := kafkaCheckProvider(c.Brokers...).WithLogger(logger).WithPartitions(c.Partitions)
+
+	if c.ConnectTimeout != 0 {
+		kafka.WithTimeout(c.ConnectTimeout)
+	}
+
+	for _, topic := range c.Topics {
+		kafka.WithTopic(topic)
+	}
+
+	return kafka.IsReady, nil
+}
+
+func mongoCheckFromConfig(c checkConfig, logger io.Writer, tracer check.Tracer, ctx context.Context) (waitfor.Check, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("check '%s' must specify url", c.Name)
+	}
+
+	mongo := mongoCheckProvider(c.URL).WithLogger(logger)
+
+	if c.ConnectTimeout != 0 {
+		mongo.WithTimeout(c.ConnectTimeout)
+	}
+
+	if c.TLS || c.Insecure {
+		mongo.WithTLS(&tls.Config{InsecureSkipVerify: c.Insecure})
+	}
+
+	switch {
+	case c.WritablePrimary:
+		return mongo.IsWritablePrimary, nil
+	case c.HasPrimary:
+		return mongo.HasPrimary, nil
+	}
+
+	return mongo.IsReady, nil
+}
+
+func probeCheckFromConfig(c checkConfig, logger io.Writer, tracer check.Tracer, ctx context.Context) (waitfor.Check, error) {
+	if !isProbe(c.Probe) {
+		return nil, fmt.Errorf("check '%s' must specify probe, one of %s", c.Name, strings.Join(check.Probes(), ", "))
+	}
+
+	addr := c.Host
+	if addr == "" {
+		addr = check.DefaultHost
+	}
+
+	if c.Port != 0 {
+		addr = net.JoinHostPort(addr, strconv.Itoa(c.Port))
+	}
+
+	probe := probeCheckProvider(c.Probe, addr).WithLogger(logger)
+
+	if c.ConnectTimeout != 0 {
+		probe.WithTimeout(c.ConnectTimeout)
+	}
+
+	return probe.IsReady, nil
+}
+
+func webSocketCheckFromConfig(c checkConfig, logger io.Writer, tracer check.Tracer, ctx context.Context) (waitfor.Check, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("check '%s' must specify url", c.Name)
+	}
+
+	ws := webSocketCheckProvider(c.URL).WithLogger(logger)
+
+	if c.ConnectTimeout != 0 {
+		ws.WithTimeout(c.ConnectTimeout)
+	}
+
+	if c.Insecure {
+		ws.WithTLS(&tls.Config{InsecureSkipVerify: true})
+	}
+
+	for k, v := range c.Headers {
+		ws.WithHeader(k, v)
+	}
+
+	if c.Message != "" {
+		ws.WithMessage(c.Message)
+	}
+
+	if c.Match != "" {
+		match, err := regexp.Compile(c.Match)
+		if err != nil {
+			return nil, fmt.Errorf("check '%s' has invalid match: %s", c.Name, err)
+		}
+		ws.WithMatch(match)
+	}
+
+	return ws.IsReady, nil
+}
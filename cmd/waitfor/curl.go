@@ -2,24 +2,23 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
-	"regexp"
 	"strings"
 
 	"github.com/codegangsta/cli"
 
+	"github.com/st3v/waitfor"
 	"github.com/st3v/waitfor/check"
 )
 
 var curlCheckProvider = check.Curl
 
 var url = func(c *cli.Context) string {
-	if !c.Args().Present() {
+	if !positional(c).Present() {
 		cli.ShowCommandHelp(c, "curl")
 		fmt.Fprintln(c.App.Writer, "must specify url")
-		exit(1)
+		exitWith(c, exitUsage)
 	}
-	return c.Args().First()
+	return positional(c).First()
 }
 
 func splitByColon(str string) (string, string) {
@@ -52,61 +51,29 @@ var curlCommand = cli.Command{
 		timeoutFlag,
 		intervalFlag,
 		verboseFlag,
+		strictFlag,
+		traceFlag,
+		quietFlag,
 	},
 
 	Action: func(c *cli.Context) error {
-		statusCode := c.Int("status")
-		regex := c.String("match")
-		method := c.String("method")
-		data := c.StringSlice("data")
-		headers := append(c.StringSlice("header"), fmt.Sprintf("user-agent:waitfor/%s", c.App.Version))
-		auth := c.String("user")
-		negate := c.Bool("fail")
 		timeout := c.Duration("timeout")
 		interval := c.Duration("interval")
-		verbose := c.Bool("verbose")
 
-		logger := ioutil.Discard
-		if verbose {
-			logger = c.App.Writer
-		}
-
-		curlCheck := curlCheckProvider(url(c)).WithMethod(method).WithLogger(logger)
-
-		if auth != "" {
-			curlCheck.WithAuth(splitByColon(auth))
-		}
-
-		for _, h := range headers {
-			curlCheck.WithHeader(splitByColon(h))
-		}
-
-		if len(data) > 0 {
-			curlCheck.WithData(strings.NewReader(strings.Join(data, "&")))
-		}
-
-		condition := func() bool {
-			return curlCheck.MatchResponseCode(statusCode)
-		}
-
-		if regex != "" {
-			r := regexp.MustCompile(regex)
-			condition = func() bool {
-				return curlCheck.MatchBody(r)
-			}
-		}
+		condition := curlCondition(c, url(c))
 
 		state := "succeed"
-		if negate {
+		if c.Bool("fail") {
 			state = "fail"
-			aux := condition
-			condition = func() bool {
-				return !aux()
-			}
 		}
 
-		fmt.Fprintf(c.App.Writer, "Waiting for curl to %s...\n", state)
-		if err := waitForConditionWithTimeout(condition, interval, timeout); err != nil {
+		fmt.Fprintf(info(c), "Waiting for curl to %s...\n", state)
+
+		conditions, stop := track(c, timeout, condition)
+		err := waitForConditionWithTimeout(conditions[0], interval, timeout, waitContext(c))
+		stop()
+
+		if err != nil {
 			fmt.Fprintf(c.App.Writer, "Error waiting for curl to %s: %s\n", state, err)
 			return err
 		}
@@ -115,3 +82,53 @@ var curlCommand = cli.Command{
 		return nil
 	},
 }
+
+// curlConditions returns the URL given as positional argument and the
+// corresponding condition.
+func curlConditions(c *cli.Context) ([]string, []waitfor.Check) {
+	url := url(c)
+	return []string{url}, []waitfor.Check{curlCondition(c, url)}
+}
+
+func curlCondition(c *cli.Context, url string) waitfor.Check {
+	statusCode := c.Int("status")
+	regex := c.String("match")
+	method := c.String("request")
+	data := c.StringSlice("data")
+	headers := append(c.StringSlice("header"), fmt.Sprintf("user-agent:waitfor/%s", c.App.Version))
+	auth := c.String("user")
+
+	target := currentWait(c).Target("curl", url)
+	curlCheck := curlCheckProvider(url).WithMethod(method).WithLogger(targetLogger(c, target)).WithTracer(target)
+
+	if auth != "" {
+		curlCheck.WithAuth(splitByColon(auth))
+	}
+
+	for _, h := range headers {
+		curlCheck.WithHeader(splitByColon(h))
+	}
+
+	if len(data) > 0 {
+		curlCheck.WithData(strings.NewReader(strings.Join(data, "&")))
+	}
+
+	condition := func() bool {
+		return curlCheck.MatchResponseCode(statusCode)
+	}
+
+	if regex != "" {
+		r := matchRegexp(c, regex)
+		condition = func() bool {
+			return curlCheck.MatchBody(r)
+		}
+	}
+
+	if c.Bool("fail") {
+		return target.Check(func() bool {
+			return !condition()
+		})
+	}
+
+	return target.Check(condition)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+	"github.com/st3v/waitfor/cmd/waitfor/fake"
+)
+
+var _ = Describe("environment variables", func() {
+	var (
+		args []string
+		env  map[string]string
+
+		portcheck       *fake.PortCheck
+		actualPorts     []int
+		actualCondition waitfor.Check
+		actualTimeout   time.Duration
+		actualInterval  time.Duration
+		actualOutput    *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		portcheck = new(fake.PortCheck)
+		portcheck.OnHostReturns(portcheck)
+		portcheck.ForNetworkReturns(portcheck)
+		portcheck.WithPayloadReturns(portcheck)
+		portcheck.WithReplyTimeoutReturns(portcheck)
+		portcheck.WithNoReplyOKReturns(portcheck)
+		portcheck.WithLoggerReturns(portcheck)
+		portCheckProvider = func(port int) check.PortCheck {
+			actualPorts = append(actualPorts, port)
+			return portcheck
+		}
+
+		waitForConditionWithTimeout = func(condition waitfor.Check, interval, timeout time.Duration, ctx context.Context) error {
+			actualCondition = condition
+			actualInterval = interval
+			actualTimeout = timeout
+			return nil
+		}
+
+		actualPorts = nil
+		actualOutput = gbytes.NewBuffer()
+		env = map[string]string{
+			"WAITFOR_TIMEOUT":  "2m",
+			"WAITFOR_INTERVAL": "5s",
+			"WAITFOR_HOST":     "db",
+		}
+		args = []string{"waitfor", "port", "5432"}
+	})
+
+	JustBeforeEach(func() {
+		for k, v := range env {
+			os.Setenv(k, v)
+		}
+
+		app := app()
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		app.Run(args)
+	})
+
+	AfterEach(func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	})
+
+	It("uses flag values from the environment", func() {
+		Expect(actualTimeout).To(Equal(2 * time.Minute))
+		Expect(actualInterval).To(Equal(5 * time.Second))
+		Expect(portcheck.OnHostArgsForCall(0)).To(Equal("db"))
+	})
+
+	Context("when flags have been specified as well", func() {
+		BeforeEach(func() {
+			args = []string{"waitfor", "port", "--timeout", "3m", "5432"}
+		})
+
+		It("gives precedence to the flags", func() {
+			Expect(actualTimeout).To(Equal(3 * time.Minute))
+			Expect(actualInterval).To(Equal(5 * time.Second))
+		})
+	})
+
+	Context("when positional arguments are set via WAITFOR_ARGS", func() {
+		BeforeEach(func() {
+			env["WAITFOR_ARGS"] = "5432"
+			args = []string{"waitfor", "port"}
+		})
+
+		It("uses them", func() {
+			Expect(actualPorts).To(Equal([]int{5432}))
+		})
+
+		Context("and on the command line", func() {
+			BeforeEach(func() {
+				args = []string{"waitfor", "port", "6379"}
+			})
+
+			It("gives precedence to the command line", func() {
+				Expect(actualPorts).To(Equal([]int{6379}))
+			})
+		})
+	})
+
+	Context("when WAITFOR_HTTP_STATUS is set", func() {
+		BeforeEach(func() {
+			env["WAITFOR_HTTP_STATUS"] = "404"
+			args = []string{"waitfor", "-t", "1s", "sh", "true"}
+		})
+
+		It("does not change the exit code sh waits for", func() {
+			Expect(actualOutput).To(gbytes.Say("Success: true did succeed"))
+		})
+	})
+
+	Context("when WAITFOR_EXIT_CODE is set", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			env["WAITFOR_EXIT_CODE"] = "3"
+			args = []string{"waitfor", "curl", server.URL}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("does not change the HTTP status curl waits for", func() {
+			Expect(actualCondition()).To(BeTrue())
+		})
+	})
+
+	Context("when help is requested", func() {
+		BeforeEach(func() {
+			args = []string{"waitfor", "help", "port"}
+		})
+
+		It("shows the names of the environment variables", func() {
+			Expect(actualOutput).To(gbytes.Say(`\$WAITFOR_HOST`))
+			Expect(actualOutput).To(gbytes.Say(`\$WAITFOR_TIMEOUT`))
+		})
+	})
+})
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/codegangsta/cli"
+)
+
+var (
+	lookPath    = exec.LookPath
+	execCommand = syscall.Exec
+)
+
+// splitCommand separates the arguments meant for waitfor from the command
+// following the first '--', e.g. 'waitfor port 5432 -- app --flag'. The
+// 'all' and 'any' commands use '--' to separate their invocations and shell
+// commands may contain '--' themselves, their command follows '-- exec'
+// instead, e.g. 'waitfor all -- port 5432 -- exec app --flag'.
+func splitCommand(app *cli.App, args []string) ([]string, []string) {
+	separator := []string{"--"}
+	switch subcommand(app, args) {
+	case "all", "any", "sh", "":
+		separator = []string{"--", "exec"}
+	}
+
+	for i := range args {
+		if hasPrefix(args[i:], separator) {
+			return args[:i], args[i+len(separator):]
+		}
+	}
+
+	return args, nil
+}
+
+// subcommand returns the name of the command invoked by the given arguments,
+// skipping global flags and their values.
+func subcommand(app *cli.App, args []string) string {
+	set := flag.NewFlagSet(app.Name, flag.ContinueOnError)
+	set.SetOutput(ioutil.Discard)
+	for _, f := range app.Flags {
+		f.Apply(set)
+	}
+
+	if len(args) == 0 || set.Parse(args[1:]) != nil || set.NArg() == 0 {
+		return ""
+	}
+
+	if command := app.Command(set.Arg(0)); command != nil {
+		return command.Name
+	}
+
+	return ""
+}
+
+func hasPrefix(args, prefix []string) bool {
+	if len(args) < len(prefix) {
+		return false
+	}
+
+	for i := range prefix {
+		if args[i] != prefix[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// execAfter wraps the actions of the app and all its commands. Once an action
+// returns, the process is replaced by the given command. If the action failed
+// and strict mode is on, waitfor exits non-zero instead.
+func execAfter(app *cli.App, command []string) {
+	if len(command) == 0 {
+		return
+	}
+
+	app.Action = execAfterAction(app.Action, command)
+	for i := range app.Commands {
+		app.Commands[i].Action = execAfterAction(app.Commands[i].Action, command)
+	}
+}
+
+func execAfterAction(action interface{}, command []string) func(*cli.Context) error {
+	run, ok := action.(func(*cli.Context) error)
+	if !ok {
+		return func(c *cli.Context) error {
+			return fmt.Errorf("cannot run %s after action of type %T", command[0], action)
+		}
+	}
+
+	return func(c *cli.Context) error {
+		err := run(c)
+
+		if err != nil {
+			if strict(c) {
+				fmt.Fprintf(c.App.Writer, "Not running %s\n", command[0])
+				exit(exitCode(err))
+				return err
+			}
+			fmt.Fprintf(c.App.Writer, "Running %s regardless, strict mode is off\n", command[0])
+		}
+
+		path, err := lookPath(command[0])
+		if err != nil {
+			fmt.Fprintf(c.App.Writer, "Error running %s: %s\n", command[0], err)
+			exit(exitNotFound)
+			return err
+		}
+
+		fmt.Fprintf(c.App.Writer, "Running %s\n", strings.Join(command, " "))
+		if err := execCommand(path, command, os.Environ()); err != nil {
+			fmt.Fprintf(c.App.Writer, "Error running %s: %s\n", command[0], err)
+			exit(exitNotExecutable)
+			return err
+		}
+
+		return nil
+	}
+}
+
+func strict(c *cli.Context) bool {
+	if c.IsSet("strict") {
+		return c.BoolT("strict")
+	}
+
+	if c.GlobalIsSet("strict") {
+		return c.GlobalBoolT("strict")
+	}
+
+	return true
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+	"github.com/st3v/waitfor/cmd/waitfor/fake"
+)
+
+var _ = Describe("running a command after waiting", func() {
+	var (
+		args        []string
+		expectedErr error
+
+		exitCode     int
+		actualPath   string
+		actualArgv   []string
+		actualOutput *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		portcheck := new(fake.PortCheck)
+		portcheck.OnHostReturns(portcheck)
+		portcheck.ForNetworkReturns(portcheck)
+		portcheck.WithPayloadReturns(portcheck)
+		portcheck.WithReplyTimeoutReturns(portcheck)
+		portcheck.WithNoReplyOKReturns(portcheck)
+		portcheck.WithLoggerReturns(portcheck)
+		portCheckProvider = func(int) check.PortCheck {
+			return portcheck
+		}
+
+		expectedErr = nil
+		waitForConditionWithTimeout = func(waitfor.Check, time.Duration, time.Duration, context.Context) error {
+			return expectedErr
+		}
+
+		exitCode = -1
+		exit = func(rc int) {
+			exitCode = rc
+		}
+
+		actualPath = ""
+		actualArgv = nil
+		lookPath = func(file string) (string, error) {
+			return "/usr/bin/" + file, nil
+		}
+		execCommand = func(path string, argv []string, env []string) error {
+			actualPath = path
+			actualArgv = argv
+			return nil
+		}
+
+		actualOutput = gbytes.NewBuffer()
+		args = []string{"waitfor", "port", "5432", "--", "app", "--flag"}
+	})
+
+	AfterEach(func() {
+		exit = os.Exit
+		lookPath = exec.LookPath
+	})
+
+	JustBeforeEach(func() {
+		app := app()
+
+		args, command := splitCommand(app, args)
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		execAfter(app, command)
+		app.Run(args)
+	})
+
+	Context("when the wait succeeds", func() {
+		It("runs the command", func() {
+			Expect(actualPath).To(Equal("/usr/bin/app"))
+			Expect(actualArgv).To(Equal([]string{"app", "--flag"}))
+		})
+
+		It("does not exit", func() {
+			Expect(exitCode).To(Equal(-1))
+		})
+	})
+
+	Context("when the wait fails", func() {
+		BeforeEach(func() {
+			expectedErr = errors.New("some-error")
+		})
+
+		It("does not run the command", func() {
+			Expect(actualArgv).To(BeNil())
+		})
+
+		It("exits with a non-zero exit code", func() {
+			Expect(exitCode).To(Equal(1))
+			Expect(actualOutput).To(gbytes.Say("Not running app"))
+		})
+
+		Context("and strict mode is off", func() {
+			BeforeEach(func() {
+				args = []string{"waitfor", "port", "5432", "--strict=false", "--", "app"}
+			})
+
+			It("runs the command anyway", func() {
+				Expect(actualArgv).To(Equal([]string{"app"}))
+				Expect(exitCode).To(Equal(-1))
+			})
+		})
+
+		Context("and strict mode is turned off globally", func() {
+			BeforeEach(func() {
+				args = []string{"waitfor", "--strict=false", "port", "5432", "--", "app"}
+			})
+
+			It("runs the command anyway", func() {
+				Expect(actualArgv).To(Equal([]string{"app"}))
+			})
+		})
+	})
+
+	Context("when a flag value is named like the all or any command", func() {
+		BeforeEach(func() {
+			args = []string{"waitfor", "port", "--host", "any", "5432", "--", "app"}
+		})
+
+		It("runs the command", func() {
+			Expect(actualArgv).To(Equal([]string{"app"}))
+		})
+	})
+
+	Context("when waiting for multiple invocations", func() {
+		var actualChecks []waitfor.Check
+
+		BeforeEach(func() {
+			actualChecks = nil
+			waitForAllWithTimeout = func(checks []waitfor.Check, interval, timeout time.Duration, ctx context.Context) []error {
+				actualChecks = checks
+				errs := make([]error, len(checks))
+				errs[0] = expectedErr
+				return errs
+			}
+
+			args = []string{"waitfor", "all", "--", "port", "5432", "--", "port", "5433", "--", "exec", "app", "--flag"}
+		})
+
+		It("runs the command following '-- exec'", func() {
+			Expect(actualChecks).To(HaveLen(2))
+			Expect(actualArgv).To(Equal([]string{"app", "--flag"}))
+		})
+
+		Context("and the wait fails", func() {
+			BeforeEach(func() {
+				expectedErr = errors.New("some-error")
+			})
+
+			It("does not run the command", func() {
+				Expect(actualArgv).To(BeNil())
+				Expect(exitCode).To(Equal(1))
+			})
+
+			Context("and strict mode is off", func() {
+				BeforeEach(func() {
+					args = []string{"waitfor", "all", "--strict=false", "--", "port", "5432", "--", "port", "5433", "--", "exec", "app"}
+				})
+
+				It("runs the command anyway", func() {
+					Expect(actualArgv).To(Equal([]string{"app"}))
+				})
+			})
+		})
+	})
+
+	Context("when the command cannot be found", func() {
+		BeforeEach(func() {
+			lookPath = func(file string) (string, error) {
+				return "", errors.New("not found")
+			}
+		})
+
+		It("exits with exit code 127", func() {
+			Expect(exitCode).To(Equal(127))
+			Expect(actualArgv).To(BeNil())
+		})
+	})
+
+	Context("when no command has been specified", func() {
+		BeforeEach(func() {
+			args = []string{"waitfor", "port", "5432"}
+		})
+
+		It("does not run anything", func() {
+			Expect(actualArgv).To(BeNil())
+			Expect(exitCode).To(Equal(-1))
+		})
+	})
+})
+
+var _ = Describe("execAfterAction", func() {
+	It("returns an error for unsupported actions instead of panicking", func() {
+		action := execAfterAction(func(*cli.Context) {}, []string{"app"})
+		Expect(action(nil)).To(MatchError("cannot run app after action of type func(*cli.Context)"))
+	})
+})
+
+var _ = Describe("splitCommand", func() {
+	It("splits at the first '--'", func() {
+		args, command := splitCommand(app(), []string{"waitfor", "port", "5432", "--", "app", "--", "file"})
+		Expect(args).To(Equal([]string{"waitfor", "port", "5432"}))
+		Expect(command).To(Equal([]string{"app", "--", "file"}))
+	})
+
+	for _, a := range [][]string{
+		{"waitfor", "sh", "git", "diff", "--", "file"},
+		{"waitfor", "-t", "1m", "shell", "git", "diff", "--", "file"},
+		{"waitfor", "git", "diff", "--", "file"},
+	} {
+		a := a
+
+		It("passes '--' on to the shell command for "+strings.Join(a, " "), func() {
+			args, command := splitCommand(app(), a)
+			Expect(args).To(Equal(a))
+			Expect(command).To(BeNil())
+		})
+	}
+
+	It("splits shell commands at '-- exec'", func() {
+		args, command := splitCommand(app(), []string{"waitfor", "sh", "git", "diff", "--", "file", "--", "exec", "app"})
+		Expect(args).To(Equal([]string{"waitfor", "sh", "git", "diff", "--", "file"}))
+		Expect(command).To(Equal([]string{"app"}))
+	})
+})
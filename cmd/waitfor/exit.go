@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/codegangsta/cli"
+)
+
+// Exit codes of waitfor. If a command following '--' cannot be run, waitfor
+// exits with 126 or 127 like a shell would. If waitfor has been interrupted
+// by a signal, it exits with 128 plus the number of the signal.
+const (
+	exitSuccess  = 0
+	exitTimeout  = 1
+	exitUsage    = 2
+	exitInternal = 3
+
+	exitNotExecutable = 126
+	exitNotFound      = 127
+	exitInterrupted   = 128
+)
+
+// exitError overrides the exit code an error would result in by default.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func usageError(err error) error {
+	return &exitError{exitUsage, err}
+}
+
+func internalError(err error) error {
+	return &exitError{exitInternal, err}
+}
+
+// exitCode maps the error returned by an action to the exit code of waitfor.
+// Errors that have not been marked otherwise mean that the wait has failed,
+// e.g. because of a timeout.
+func exitCode(err error) int {
+	if err == nil {
+		return exitSuccess
+	}
+
+	if e, ok := err.(*exitError); ok {
+		return e.code
+	}
+
+	return exitTimeout
+}
+
+// onUsageError is called by cli whenever flags cannot be parsed.
+func onUsageError(c *cli.Context, err error, isSubcommand bool) error {
+	fmt.Fprintf(c.App.Writer, "Incorrect Usage: %s\n\n", err)
+
+	if c.Command.Name != "" {
+		cli.ShowCommandHelp(c, c.Command.Name)
+	} else {
+		cli.ShowAppHelp(c)
+	}
+
+	return usageError(err)
+}
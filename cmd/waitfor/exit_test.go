@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+	"github.com/st3v/waitfor/cmd/waitfor/fake"
+)
+
+var _ = Describe("exit codes", func() {
+	var (
+		args   []string
+		result error
+
+		actualOutput *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		portcheck := new(fake.PortCheck)
+		portcheck.OnHostReturns(portcheck)
+		portcheck.ForNetworkReturns(portcheck)
+		portcheck.WithPayloadReturns(portcheck)
+		portcheck.WithReplyTimeoutReturns(portcheck)
+		portcheck.WithNoReplyOKReturns(portcheck)
+		portcheck.WithLoggerReturns(portcheck)
+		portCheckProvider = func(int) check.PortCheck {
+			return portcheck
+		}
+
+		result = nil
+		waitForConditionWithTimeout = func(waitfor.Check, time.Duration, time.Duration, context.Context) error {
+			return result
+		}
+
+		exit = func(rc int) {
+			panic(rc)
+		}
+
+		actualOutput = gbytes.NewBuffer()
+	})
+
+	AfterEach(func() {
+		exit = os.Exit
+	})
+
+	run := func() (rc int) {
+		defer func() {
+			if r := recover(); r != nil {
+				rc = r.(int)
+			}
+		}()
+
+		app := app()
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+
+		return exitCode(app.Run(args))
+	}
+
+	Context("when the wait succeeds", func() {
+		BeforeEach(func() {
+			args = []string{"waitfor", "port", "5432"}
+		})
+
+		It("exits with 0", func() {
+			Expect(run()).To(Equal(exitSuccess))
+		})
+	})
+
+	Context("when the wait times out", func() {
+		BeforeEach(func() {
+			result = waitfor.ErrTimeoutExceeded
+			args = []string{"waitfor", "port", "5432"}
+		})
+
+		It("exits with 1", func() {
+			Expect(run()).To(Equal(exitTimeout))
+		})
+	})
+
+	Context("when an unknown flag has been passed to a command", func() {
+		BeforeEach(func() {
+			args = []string{"waitfor", "port", "--unknown", "5432"}
+		})
+
+		It("exits with 2", func() {
+			Expect(run()).To(Equal(exitUsage))
+			Expect(actualOutput).To(gbytes.Say("Incorrect Usage: flag provided but not defined: -unknown"))
+		})
+	})
+
+	Context("when an unknown global flag has been passed", func() {
+		BeforeEach(func() {
+			args = []string{"waitfor", "--unknown", "port", "5432"}
+		})
+
+		It("exits with 2", func() {
+			Expect(run()).To(Equal(exitUsage))
+		})
+	})
+
+	Context("when a required argument is missing", func() {
+		for _, command := range []string{"port", "curl", "sh", "on"} {
+			command := command
+
+			It("exits with 2 for "+command, func() {
+				args = []string{"waitfor", command}
+				Expect(run()).To(Equal(exitUsage))
+			})
+		}
+	})
+
+	Context("when the match is not a valid regex", func() {
+		for _, a := range [][]string{
+			{"waitfor", "curl", "-m", "(", "http://example.com"},
+			{"waitfor", "-m", "(", "sh", "true"},
+			{"waitfor", "on", "-m", "(", "http://example.com"},
+			{"waitfor", "ws", "-m", "(", "ws://example.com"},
+			{"waitfor", "on", "-m", "(", "ws://example.com"},
+		} {
+			a := a
+
+			It("exits with 2 for "+strings.Join(a, " "), func() {
+				args = a
+				Expect(run()).To(Equal(exitUsage))
+				Expect(actualOutput).To(gbytes.Say("invalid match: error parsing regexp"))
+			})
+		}
+	})
+
+	Context("when the status cannot be served", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "exit")
+			Expect(err).ToNot(HaveOccurred())
+
+			path := filepath.Join(dir, "waitfor.yaml")
+			Expect(ioutil.WriteFile(path, []byte("checks: []"), 0644)).To(Succeed())
+
+			listenAndServe = func(string, http.Handler) error {
+				return errors.New("address already in use")
+			}
+
+			args = []string{"waitfor", "serve", "-f", path}
+		})
+
+		AfterEach(func() {
+			listenAndServe = http.ListenAndServe
+			os.RemoveAll(dir)
+		})
+
+		It("exits with 3", func() {
+			Expect(run()).To(Equal(exitInternal))
+		})
+	})
+})
@@ -0,0 +1,246 @@
+// This file was generated by counterfeiter
+package fake
+
+import (
+	"crypto/tls"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/st3v/waitfor/check"
+)
+
+type AMQPCheck struct {
+	IsReadyStub        func() bool
+	isReadyMutex       sync.RWMutex
+	isReadyArgsForCall []struct{}
+	isReadyReturns struct {
+		result1 bool
+	}
+	WithQueueStub        func(string) check.AMQPCheck
+	withQueueMutex       sync.RWMutex
+	withQueueArgsForCall []struct {
+		arg1 string
+	}
+	withQueueReturns struct {
+		result1 check.AMQPCheck
+	}
+	WithExchangeStub        func(string) check.AMQPCheck
+	withExchangeMutex       sync.RWMutex
+	withExchangeArgsForCall []struct {
+		arg1 string
+	}
+	withExchangeReturns struct {
+		result1 check.AMQPCheck
+	}
+	WithTLSStub        func(*tls.Config) check.AMQPCheck
+	withTLSMutex       sync.RWMutex
+	withTLSArgsForCall []struct {
+		arg1 *tls.Config
+	}
+	withTLSReturns struct {
+		result1 check.AMQPCheck
+	}
+	WithTimeoutStub        func(time.Duration) check.AMQPCheck
+	withTimeoutMutex       sync.RWMutex
+	withTimeoutArgsForCall []struct {
+		arg1 time.Duration
+	}
+	withTimeoutReturns struct {
+		result1 check.AMQPCheck
+	}
+	WithLoggerStub        func(io.Writer) check.AMQPCheck
+	withLoggerMutex       sync.RWMutex
+	withLoggerArgsForCall []struct {
+		arg1 io.Writer
+	}
+	withLoggerReturns struct {
+		result1 check.AMQPCheck
+	}
+}
+
+func (fake *AMQPCheck) IsReady() bool {
+	fake.isReadyMutex.Lock()
+	fake.isReadyArgsForCall = append(fake.isReadyArgsForCall, struct{}{})
+	fake.isReadyMutex.Unlock()
+	if fake.IsReadyStub != nil {
+		return fake.IsReadyStub()
+	} else {
+		return fake.isReadyReturns.result1
+	}
+}
+
+func (fake *AMQPCheck) IsReadyCallCount() int {
+	fake.isReadyMutex.RLock()
+	defer fake.isReadyMutex.RUnlock()
+	return len(fake.isReadyArgsForCall)
+}
+
+func (fake *AMQPCheck) IsReadyReturns(result1 bool) {
+	fake.IsReadyStub = nil
+	fake.isReadyReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *AMQPCheck) WithQueue(arg1 string) check.AMQPCheck {
+	fake.withQueueMutex.Lock()
+	fake.withQueueArgsForCall = append(fake.withQueueArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.withQueueMutex.Unlock()
+	if fake.WithQueueStub != nil {
+		return fake.WithQueueStub(arg1)
+	} else {
+		return fake.withQueueReturns.result1
+	}
+}
+
+func (fake *AMQPCheck) WithQueueCallCount() int {
+	fake.withQueueMutex.RLock()
+	defer fake.withQueueMutex.RUnlock()
+	return len(fake.withQueueArgsForCall)
+}
+
+func (fake *AMQPCheck) WithQueueArgsForCall(i int) string {
+	fake.withQueueMutex.RLock()
+	defer fake.withQueueMutex.RUnlock()
+	return fake.withQueueArgsForCall[i].arg1
+}
+
+func (fake *AMQPCheck) WithQueueReturns(result1 check.AMQPCheck) {
+	fake.WithQueueStub = nil
+	fake.withQueueReturns = struct {
+		result1 check.AMQPCheck
+	}{result1}
+}
+
+func (fake *AMQPCheck) WithExchange(arg1 string) check.AMQPCheck {
+	fake.withExchangeMutex.Lock()
+	fake.withExchangeArgsForCall = append(fake.withExchangeArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.withExchangeMutex.Unlock()
+	if fake.WithExchangeStub != nil {
+		return fake.WithExchangeStub(arg1)
+	} else {
+		return fake.withExchangeReturns.result1
+	}
+}
+
+func (fake *AMQPCheck) WithExchangeCallCount() int {
+	fake.withExchangeMutex.RLock()
+	defer fake.withExchangeMutex.RUnlock()
+	return len(fake.withExchangeArgsForCall)
+}
+
+func (fake *AMQPCheck) WithExchangeArgsForCall(i int) string {
+	fake.withExchangeMutex.RLock()
+	defer fake.withExchangeMutex.RUnlock()
+	return fake.withExchangeArgsForCall[i].arg1
+}
+
+func (fake *AMQPCheck) WithExchangeReturns(result1 check.AMQPCheck) {
+	fake.WithExchangeStub = nil
+	fake.withExchangeReturns = struct {
+		result1 check.AMQPCheck
+	}{result1}
+}
+
+func (fake *AMQPCheck) WithTLS(arg1 *tls.Config) check.AMQPCheck {
+	fake.withTLSMutex.Lock()
+	fake.withTLSArgsForCall = append(fake.withTLSArgsForCall, struct {
+		arg1 *tls.Config
+	}{arg1})
+	fake.withTLSMutex.Unlock()
+	if fake.WithTLSStub != nil {
+		return fake.WithTLSStub(arg1)
+	} else {
+		return fake.withTLSReturns.result1
+	}
+}
+
+func (fake *AMQPCheck) WithTLSCallCount() int {
+	fake.withTLSMutex.RLock()
+	defer fake.withTLSMutex.RUnlock()
+	return len(fake.withTLSArgsForCall)
+}
+
+func (fake *AMQPCheck) WithTLSArgsForCall(i int) *tls.Config {
+	fake.withTLSMutex.RLock()
+	defer fake.withTLSMutex.RUnlock()
+	return fake.withTLSArgsForCall[i].arg1
+}
+
+func (fake *AMQPCheck) WithTLSReturns(result1 check.AMQPCheck) {
+	fake.WithTLSStub = nil
+	fake.withTLSReturns = struct {
+		result1 check.AMQPCheck
+	}{result1}
+}
+
+func (fake *AMQPCheck) WithTimeout(arg1 time.Duration) check.AMQPCheck {
+	fake.withTimeoutMutex.Lock()
+	fake.withTimeoutArgsForCall = append(fake.withTimeoutArgsForCall, struct {
+		arg1 time.Duration
+	}{arg1})
+	fake.withTimeoutMutex.Unlock()
+	if fake.WithTimeoutStub != nil {
+		return fake.WithTimeoutStub(arg1)
+	} else {
+		return fake.withTimeoutReturns.result1
+	}
+}
+
+func (fake *AMQPCheck) WithTimeoutCallCount() int {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return len(fake.withTimeoutArgsForCall)
+}
+
+func (fake *AMQPCheck) WithTimeoutArgsForCall(i int) time.Duration {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return fake.withTimeoutArgsForCall[i].arg1
+}
+
+func (fake *AMQPCheck) WithTimeoutReturns(result1 check.AMQPCheck) {
+	fake.WithTimeoutStub = nil
+	fake.withTimeoutReturns = struct {
+		result1 check.AMQPCheck
+	}{result1}
+}
+
+func (fake *AMQPCheck) WithLogger(arg1 io.Writer) check.AMQPCheck {
+	fake.withLoggerMutex.Lock()
+	fake.withLoggerArgsForCall = append(fake.withLoggerArgsForCall, struct {
+		arg1 io.Writer
+	}{arg1})
+	fake.withLoggerMutex.Unlock()
+	if fake.WithLoggerStub != nil {
+		return fake.WithLoggerStub(arg1)
+	} else {
+		return fake.withLoggerReturns.result1
+	}
+}
+
+func (fake *AMQPCheck) WithLoggerCallCount() int {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return len(fake.withLoggerArgsForCall)
+}
+
+func (fake *AMQPCheck) WithLoggerArgsForCall(i int) io.Writer {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return fake.withLoggerArgsForCall[i].arg1
+}
+
+func (fake *AMQPCheck) WithLoggerReturns(result1 check.AMQPCheck) {
+	fake.WithLoggerStub = nil
+	fake.withLoggerReturns = struct {
+		result1 check.AMQPCheck
+	}{result1}
+}
+
+var _ check.AMQPCheck = new(AMQPCheck)
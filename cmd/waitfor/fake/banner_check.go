@@ -0,0 +1,165 @@
+// This file was generated by counterfeiter
+package fake
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/st3v/waitfor/check"
+)
+
+type BannerCheck struct {
+	IsReadyStub        func() bool
+	isReadyMutex       sync.RWMutex
+	isReadyArgsForCall []struct{}
+	isReadyReturns struct {
+		result1 bool
+	}
+	WithFingerprintStub        func(string) check.BannerCheck
+	withFingerprintMutex       sync.RWMutex
+	withFingerprintArgsForCall []struct {
+		arg1 string
+	}
+	withFingerprintReturns struct {
+		result1 check.BannerCheck
+	}
+	WithTimeoutStub        func(time.Duration) check.BannerCheck
+	withTimeoutMutex       sync.RWMutex
+	withTimeoutArgsForCall []struct {
+		arg1 time.Duration
+	}
+	withTimeoutReturns struct {
+		result1 check.BannerCheck
+	}
+	WithLoggerStub        func(io.Writer) check.BannerCheck
+	withLoggerMutex       sync.RWMutex
+	withLoggerArgsForCall []struct {
+		arg1 io.Writer
+	}
+	withLoggerReturns struct {
+		result1 check.BannerCheck
+	}
+}
+
+func (fake *BannerCheck) IsReady() bool {
+	fake.isReadyMutex.Lock()
+	fake.isReadyArgsForCall = append(fake.isReadyArgsForCall, struct{}{})
+	fake.isReadyMutex.Unlock()
+	if fake.IsReadyStub != nil {
+		return fake.IsReadyStub()
+	} else {
+		return fake.isReadyReturns.result1
+	}
+}
+
+func (fake *BannerCheck) IsReadyCallCount() int {
+	fake.isReadyMutex.RLock()
+	defer fake.isReadyMutex.RUnlock()
+	return len(fake.isReadyArgsForCall)
+}
+
+func (fake *BannerCheck) IsReadyReturns(result1 bool) {
+	fake.IsReadyStub = nil
+	fake.isReadyReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *BannerCheck) WithFingerprint(arg1 string) check.BannerCheck {
+	fake.withFingerprintMutex.Lock()
+	fake.withFingerprintArgsForCall = append(fake.withFingerprintArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.withFingerprintMutex.Unlock()
+	if fake.WithFingerprintStub != nil {
+		return fake.WithFingerprintStub(arg1)
+	} else {
+		return fake.withFingerprintReturns.result1
+	}
+}
+
+func (fake *BannerCheck) WithFingerprintCallCount() int {
+	fake.withFingerprintMutex.RLock()
+	defer fake.withFingerprintMutex.RUnlock()
+	return len(fake.withFingerprintArgsForCall)
+}
+
+func (fake *BannerCheck) WithFingerprintArgsForCall(i int) string {
+	fake.withFingerprintMutex.RLock()
+	defer fake.withFingerprintMutex.RUnlock()
+	return fake.withFingerprintArgsForCall[i].arg1
+}
+
+func (fake *BannerCheck) WithFingerprintReturns(result1 check.BannerCheck) {
+	fake.WithFingerprintStub = nil
+	fake.withFingerprintReturns = struct {
+		result1 check.BannerCheck
+	}{result1}
+}
+
+func (fake *BannerCheck) WithTimeout(arg1 time.Duration) check.BannerCheck {
+	fake.withTimeoutMutex.Lock()
+	fake.withTimeoutArgsForCall = append(fake.withTimeoutArgsForCall, struct {
+		arg1 time.Duration
+	}{arg1})
+	fake.withTimeoutMutex.Unlock()
+	if fake.WithTimeoutStub != nil {
+		return fake.WithTimeoutStub(arg1)
+	} else {
+		return fake.withTimeoutReturns.result1
+	}
+}
+
+func (fake *BannerCheck) WithTimeoutCallCount() int {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return len(fake.withTimeoutArgsForCall)
+}
+
+func (fake *BannerCheck) WithTimeoutArgsForCall(i int) time.Duration {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return fake.withTimeoutArgsForCall[i].arg1
+}
+
+func (fake *BannerCheck) WithTimeoutReturns(result1 check.BannerCheck) {
+	fake.WithTimeoutStub = nil
+	fake.withTimeoutReturns = struct {
+		result1 check.BannerCheck
+	}{result1}
+}
+
+func (fake *BannerCheck) WithLogger(arg1 io.Writer) check.BannerCheck {
+	fake.withLoggerMutex.Lock()
+	fake.withLoggerArgsForCall = append(fake.withLoggerArgsForCall, struct {
+		arg1 io.Writer
+	}{arg1})
+	fake.withLoggerMutex.Unlock()
+	if fake.WithLoggerStub != nil {
+		return fake.WithLoggerStub(arg1)
+	} else {
+		return fake.withLoggerReturns.result1
+	}
+}
+
+func (fake *BannerCheck) WithLoggerCallCount() int {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return len(fake.withLoggerArgsForCall)
+}
+
+func (fake *BannerCheck) WithLoggerArgsForCall(i int) io.Writer {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return fake.withLoggerArgsForCall[i].arg1
+}
+
+func (fake *BannerCheck) WithLoggerReturns(result1 check.BannerCheck) {
+	fake.WithLoggerStub = nil
+	fake.withLoggerReturns = struct {
+		result1 check.BannerCheck
+	}{result1}
+}
+
+var _ check.BannerCheck = new(BannerCheck)
@@ -0,0 +1,196 @@
+// This file was generated by counterfeiter
+package fake
+
+import (
+	"crypto/tls"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/st3v/waitfor/check"
+)
+
+type GRPCHealthCheck struct {
+	IsServingStub        func() bool
+	isServingMutex       sync.RWMutex
+	isServingArgsForCall []struct{}
+	isServingReturns struct {
+		result1 bool
+	}
+	WithTLSStub        func(*tls.Config) check.GRPCHealthCheck
+	withTLSMutex       sync.RWMutex
+	withTLSArgsForCall []struct {
+		arg1 *tls.Config
+	}
+	withTLSReturns struct {
+		result1 check.GRPCHealthCheck
+	}
+	WithWatchStub        func() check.GRPCHealthCheck
+	withWatchMutex       sync.RWMutex
+	withWatchArgsForCall []struct{}
+	withWatchReturns struct {
+		result1 check.GRPCHealthCheck
+	}
+	WithTimeoutStub        func(time.Duration) check.GRPCHealthCheck
+	withTimeoutMutex       sync.RWMutex
+	withTimeoutArgsForCall []struct {
+		arg1 time.Duration
+	}
+	withTimeoutReturns struct {
+		result1 check.GRPCHealthCheck
+	}
+	WithLoggerStub        func(io.Writer) check.GRPCHealthCheck
+	withLoggerMutex       sync.RWMutex
+	withLoggerArgsForCall []struct {
+		arg1 io.Writer
+	}
+	withLoggerReturns struct {
+		result1 check.GRPCHealthCheck
+	}
+}
+
+func (fake *GRPCHealthCheck) IsServing() bool {
+	fake.isServingMutex.Lock()
+	fake.isServingArgsForCall = append(fake.isServingArgsForCall, struct{}{})
+	fake.isServingMutex.Unlock()
+	if fake.IsServingStub != nil {
+		return fake.IsServingStub()
+	} else {
+		return fake.isServingReturns.result1
+	}
+}
+
+func (fake *GRPCHealthCheck) IsServingCallCount() int {
+	fake.isServingMutex.RLock()
+	defer fake.isServingMutex.RUnlock()
+	return len(fake.isServingArgsForCall)
+}
+
+func (fake *GRPCHealthCheck) IsServingReturns(result1 bool) {
+	fake.IsServingStub = nil
+	fake.isServingReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *GRPCHealthCheck) WithTLS(arg1 *tls.Config) check.GRPCHealthCheck {
+	fake.withTLSMutex.Lock()
+	fake.withTLSArgsForCall = append(fake.withTLSArgsForCall, struct {
+		arg1 *tls.Config
+	}{arg1})
+	fake.withTLSMutex.Unlock()
+	if fake.WithTLSStub != nil {
+		return fake.WithTLSStub(arg1)
+	} else {
+		return fake.withTLSReturns.result1
+	}
+}
+
+func (fake *GRPCHealthCheck) WithTLSCallCount() int {
+	fake.withTLSMutex.RLock()
+	defer fake.withTLSMutex.RUnlock()
+	return len(fake.withTLSArgsForCall)
+}
+
+func (fake *GRPCHealthCheck) WithTLSArgsForCall(i int) *tls.Config {
+	fake.withTLSMutex.RLock()
+	defer fake.withTLSMutex.RUnlock()
+	return fake.withTLSArgsForCall[i].arg1
+}
+
+func (fake *GRPCHealthCheck) WithTLSReturns(result1 check.GRPCHealthCheck) {
+	fake.WithTLSStub = nil
+	fake.withTLSReturns = struct {
+		result1 check.GRPCHealthCheck
+	}{result1}
+}
+
+func (fake *GRPCHealthCheck) WithWatch() check.GRPCHealthCheck {
+	fake.withWatchMutex.Lock()
+	fake.withWatchArgsForCall = append(fake.withWatchArgsForCall, struct{}{})
+	fake.withWatchMutex.Unlock()
+	if fake.WithWatchStub != nil {
+		return fake.WithWatchStub()
+	} else {
+		return fake.withWatchReturns.result1
+	}
+}
+
+func (fake *GRPCHealthCheck) WithWatchCallCount() int {
+	fake.withWatchMutex.RLock()
+	defer fake.withWatchMutex.RUnlock()
+	return len(fake.withWatchArgsForCall)
+}
+
+func (fake *GRPCHealthCheck) WithWatchReturns(result1 check.GRPCHealthCheck) {
+	fake.WithWatchStub = nil
+	fake.withWatchReturns = struct {
+		result1 check.GRPCHealthCheck
+	}{result1}
+}
+
+func (fake *GRPCHealthCheck) WithTimeout(arg1 time.Duration) check.GRPCHealthCheck {
+	fake.withTimeoutMutex.Lock()
+	fake.withTimeoutArgsForCall = append(fake.withTimeoutArgsForCall, struct {
+		arg1 time.Duration
+	}{arg1})
+	fake.withTimeoutMutex.Unlock()
+	if fake.WithTimeoutStub != nil {
+		return fake.WithTimeoutStub(arg1)
+	} else {
+		return fake.withTimeoutReturns.result1
+	}
+}
+
+func (fake *GRPCHealthCheck) WithTimeoutCallCount() int {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return len(fake.withTimeoutArgsForCall)
+}
+
+func (fake *GRPCHealthCheck) WithTimeoutArgsForCall(i int) time.Duration {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return fake.withTimeoutArgsForCall[i].arg1
+}
+
+func (fake *GRPCHealthCheck) WithTimeoutReturns(result1 check.GRPCHealthCheck) {
+	fake.WithTimeoutStub = nil
+	fake.withTimeoutReturns = struct {
+		result1 check.GRPCHealthCheck
+	}{result1}
+}
+
+func (fake *GRPCHealthCheck) WithLogger(arg1 io.Writer) check.GRPCHealthCheck {
+	fake.withLoggerMutex.Lock()
+	fake.withLoggerArgsForCall = append(fake.withLoggerArgsForCall, struct {
+		arg1 io.Writer
+	}{arg1})
+	fake.withLoggerMutex.Unlock()
+	if fake.WithLoggerStub != nil {
+		return fake.WithLoggerStub(arg1)
+	} else {
+		return fake.withLoggerReturns.result1
+	}
+}
+
+func (fake *GRPCHealthCheck) WithLoggerCallCount() int {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return len(fake.withLoggerArgsForCall)
+}
+
+func (fake *GRPCHealthCheck) WithLoggerArgsForCall(i int) io.Writer {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return fake.withLoggerArgsForCall[i].arg1
+}
+
+func (fake *GRPCHealthCheck) WithLoggerReturns(result1 check.GRPCHealthCheck) {
+	fake.WithLoggerStub = nil
+	fake.withLoggerReturns = struct {
+		result1 check.GRPCHealthCheck
+	}{result1}
+}
+
+var _ check.GRPCHealthCheck = new(GRPCHealthCheck)
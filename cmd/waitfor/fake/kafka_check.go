@@ -0,0 +1,205 @@
+// This file was generated by counterfeiter
+package fake
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/st3v/waitfor/check"
+)
+
+type KafkaCheck struct {
+	IsReadyStub        func() bool
+	isReadyMutex       sync.RWMutex
+	isReadyArgsForCall []struct{}
+	isReadyReturns struct {
+		result1 bool
+	}
+	WithTopicStub        func(string) check.KafkaCheck
+	withTopicMutex       sync.RWMutex
+	withTopicArgsForCall []struct {
+		arg1 string
+	}
+	withTopicReturns struct {
+		result1 check.KafkaCheck
+	}
+	WithPartitionsStub        func(int) check.KafkaCheck
+	withPartitionsMutex       sync.RWMutex
+	withPartitionsArgsForCall []struct {
+		arg1 int
+	}
+	withPartitionsReturns struct {
+		result1 check.KafkaCheck
+	}
+	WithTimeoutStub        func(time.Duration) check.KafkaCheck
+	withTimeoutMutex       sync.RWMutex
+	withTimeoutArgsForCall []struct {
+		arg1 time.Duration
+	}
+	withTimeoutReturns struct {
+		result1 check.KafkaCheck
+	}
+	WithLoggerStub        func(io.Writer) check.KafkaCheck
+	withLoggerMutex       sync.RWMutex
+	withLoggerArgsForCall []struct {
+		arg1 io.Writer
+	}
+	withLoggerReturns struct {
+		result1 check.KafkaCheck
+	}
+}
+
+func (fake *KafkaCheck) IsReady() bool {
+	fake.isReadyMutex.Lock()
+	fake.isReadyArgsForCall = append(fake.isReadyArgsForCall, struct{}{})
+	fake.isReadyMutex.Unlock()
+	if fake.IsReadyStub != nil {
+		return fake.IsReadyStub()
+	} else {
+		return fake.isReadyReturns.result1
+	}
+}
+
+func (fake *KafkaCheck) IsReadyCallCount() int {
+	fake.isReadyMutex.RLock()
+	defer fake.isReadyMutex.RUnlock()
+	return len(fake.isReadyArgsForCall)
+}
+
+func (fake *KafkaCheck) IsReadyReturns(result1 bool) {
+	fake.IsReadyStub = nil
+	fake.isReadyReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *KafkaCheck) WithTopic(arg1 string) check.KafkaCheck {
+	fake.withTopicMutex.Lock()
+	fake.withTopicArgsForCall = append(fake.withTopicArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.withTopicMutex.Unlock()
+	if fake.WithTopicStub != nil {
+		return fake.WithTopicStub(arg1)
+	} else {
+		return fake.withTopicReturns.result1
+	}
+}
+
+func (fake *KafkaCheck) WithTopicCallCount() int {
+	fake.withTopicMutex.RLock()
+	defer fake.withTopicMutex.RUnlock()
+	return len(fake.withTopicArgsForCall)
+}
+
+func (fake *KafkaCheck) WithTopicArgsForCall(i int) string {
+	fake.withTopicMutex.RLock()
+	defer fake.withTopicMutex.RUnlock()
+	return fake.withTopicArgsForCall[i].arg1
+}
+
+func (fake *KafkaCheck) WithTopicReturns(result1 check.KafkaCheck) {
+	fake.WithTopicStub = nil
+	fake.withTopicReturns = struct {
+		result1 check.KafkaCheck
+	}{result1}
+}
+
+func (fake *KafkaCheck) WithPartitions(arg1 int) check.KafkaCheck {
+	fake.withPartitionsMutex.Lock()
+	fake.withPartitionsArgsForCall = append(fake.withPartitionsArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	fake.withPartitionsMutex.Unlock()
+	if fake.WithPartitionsStub != nil {
+		return fake.WithPartitionsStub(arg1)
+	} else {
+		return fake.withPartitionsReturns.result1
+	}
+}
+
+func (fake *KafkaCheck) WithPartitionsCallCount() int {
+	fake.withPartitionsMutex.RLock()
+	defer fake.withPartitionsMutex.RUnlock()
+	return len(fake.withPartitionsArgsForCall)
+}
+
+func (fake *KafkaCheck) WithPartitionsArgsForCall(i int) int {
+	fake.withPartitionsMutex.RLock()
+	defer fake.withPartitionsMutex.RUnlock()
+	return fake.withPartitionsArgsForCall[i].arg1
+}
+
+func (fake *KafkaCheck) WithPartitionsReturns(result1 check.KafkaCheck) {
+	fake.WithPartitionsStub = nil
+	fake.withPartitionsReturns = struct {
+		result1 check.KafkaCheck
+	}{result1}
+}
+
+func (fake *KafkaCheck) WithTimeout(arg1 time.Duration) check.KafkaCheck {
+	fake.withTimeoutMutex.Lock()
+	fake.withTimeoutArgsForCall = append(fake.withTimeoutArgsForCall, struct {
+		arg1 time.Duration
+	}{arg1})
+	fake.withTimeoutMutex.Unlock()
+	if fake.WithTimeoutStub != nil {
+		return fake.WithTimeoutStub(arg1)
+	} else {
+		return fake.withTimeoutReturns.result1
+	}
+}
+
+func (fake *KafkaCheck) WithTimeoutCallCount() int {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return len(fake.withTimeoutArgsForCall)
+}
+
+func (fake *KafkaCheck) WithTimeoutArgsForCall(i int) time.Duration {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return fake.withTimeoutArgsForCall[i].arg1
+}
+
+func (fake *KafkaCheck) WithTimeoutReturns(result1 check.KafkaCheck) {
+	fake.WithTimeoutStub = nil
+	fake.withTimeoutReturns = struct {
+		result1 check.KafkaCheck
+	}{result1}
+}
+
+func (fake *KafkaCheck) WithLogger(arg1 io.Writer) check.KafkaCheck {
+	fake.withLoggerMutex.Lock()
+	fake.withLoggerArgsForCall = append(fake.withLoggerArgsForCall, struct {
+		arg1 io.Writer
+	}{arg1})
+	fake.withLoggerMutex.Unlock()
+	if fake.WithLoggerStub != nil {
+		return fake.WithLoggerStub(arg1)
+	} else {
+		return fake.withLoggerReturns.result1
+	}
+}
+
+func (fake *KafkaCheck) WithLoggerCallCount() int {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return len(fake.withLoggerArgsForCall)
+}
+
+func (fake *KafkaCheck) WithLoggerArgsForCall(i int) io.Writer {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return fake.withLoggerArgsForCall[i].arg1
+}
+
+func (fake *KafkaCheck) WithLoggerReturns(result1 check.KafkaCheck) {
+	fake.WithLoggerStub = nil
+	fake.withLoggerReturns = struct {
+		result1 check.KafkaCheck
+	}{result1}
+}
+
+var _ check.KafkaCheck = new(KafkaCheck)
@@ -0,0 +1,226 @@
+// This file was generated by counterfeiter
+package fake
+
+import (
+	"crypto/tls"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/st3v/waitfor/check"
+)
+
+type MongoCheck struct {
+	IsReadyStub        func() bool
+	isReadyMutex       sync.RWMutex
+	isReadyArgsForCall []struct{}
+	isReadyReturns struct {
+		result1 bool
+	}
+	IsWritablePrimaryStub        func() bool
+	isWritablePrimaryMutex       sync.RWMutex
+	isWritablePrimaryArgsForCall []struct{}
+	isWritablePrimaryReturns struct {
+		result1 bool
+	}
+	HasPrimaryStub        func() bool
+	hasPrimaryMutex       sync.RWMutex
+	hasPrimaryArgsForCall []struct{}
+	hasPrimaryReturns struct {
+		result1 bool
+	}
+	WithTLSStub        func(*tls.Config) check.MongoCheck
+	withTLSMutex       sync.RWMutex
+	withTLSArgsForCall []struct {
+		arg1 *tls.Config
+	}
+	withTLSReturns struct {
+		result1 check.MongoCheck
+	}
+	WithTimeoutStub        func(time.Duration) check.MongoCheck
+	withTimeoutMutex       sync.RWMutex
+	withTimeoutArgsForCall []struct {
+		arg1 time.Duration
+	}
+	withTimeoutReturns struct {
+		result1 check.MongoCheck
+	}
+	WithLoggerStub        func(io.Writer) check.MongoCheck
+	withLoggerMutex       sync.RWMutex
+	withLoggerArgsForCall []struct {
+		arg1 io.Writer
+	}
+	withLoggerReturns struct {
+		result1 check.MongoCheck
+	}
+}
+
+func (fake *MongoCheck) IsReady() bool {
+	fake.isReadyMutex.Lock()
+	fake.isReadyArgsForCall = append(fake.isReadyArgsForCall, struct{}{})
+	fake.isReadyMutex.Unlock()
+	if fake.IsReadyStub != nil {
+		return fake.IsReadyStub()
+	} else {
+		return fake.isReadyReturns.result1
+	}
+}
+
+func (fake *MongoCheck) IsReadyCallCount() int {
+	fake.isReadyMutex.RLock()
+	defer fake.isReadyMutex.RUnlock()
+	return len(fake.isReadyArgsForCall)
+}
+
+func (fake *MongoCheck) IsReadyReturns(result1 bool) {
+	fake.IsReadyStub = nil
+	fake.isReadyReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *MongoCheck) IsWritablePrimary() bool {
+	fake.isWritablePrimaryMutex.Lock()
+	fake.isWritablePrimaryArgsForCall = append(fake.isWritablePrimaryArgsForCall, struct{}{})
+	fake.isWritablePrimaryMutex.Unlock()
+	if fake.IsWritablePrimaryStub != nil {
+		return fake.IsWritablePrimaryStub()
+	} else {
+		return fake.isWritablePrimaryReturns.result1
+	}
+}
+
+func (fake *MongoCheck) IsWritablePrimaryCallCount() int {
+	fake.isWritablePrimaryMutex.RLock()
+	defer fake.isWritablePrimaryMutex.RUnlock()
+	return len(fake.isWritablePrimaryArgsForCall)
+}
+
+func (fake *MongoCheck) IsWritablePrimaryReturns(result1 bool) {
+	fake.IsWritablePrimaryStub = nil
+	fake.isWritablePrimaryReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *MongoCheck) HasPrimary() bool {
+	fake.hasPrimaryMutex.Lock()
+	fake.hasPrimaryArgsForCall = append(fake.hasPrimaryArgsForCall, struct{}{})
+	fake.hasPrimaryMutex.Unlock()
+	if fake.HasPrimaryStub != nil {
+		return fake.HasPrimaryStub()
+	} else {
+		return fake.hasPrimaryReturns.result1
+	}
+}
+
+func (fake *MongoCheck) HasPrimaryCallCount() int {
+	fake.hasPrimaryMutex.RLock()
+	defer fake.hasPrimaryMutex.RUnlock()
+	return len(fake.hasPrimaryArgsForCall)
+}
+
+func (fake *MongoCheck) HasPrimaryReturns(result1 bool) {
+	fake.HasPrimaryStub = nil
+	fake.hasPrimaryReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *MongoCheck) WithTLS(arg1 *tls.Config) check.MongoCheck {
+	fake.withTLSMutex.Lock()
+	fake.withTLSArgsForCall = append(fake.withTLSArgsForCall, struct {
+		arg1 *tls.Config
+	}{arg1})
+	fake.withTLSMutex.Unlock()
+	if fake.WithTLSStub != nil {
+		return fake.WithTLSStub(arg1)
+	} else {
+		return fake.withTLSReturns.result1
+	}
+}
+
+func (fake *MongoCheck) WithTLSCallCount() int {
+	fake.withTLSMutex.RLock()
+	defer fake.withTLSMutex.RUnlock()
+	return len(fake.withTLSArgsForCall)
+}
+
+func (fake *MongoCheck) WithTLSArgsForCall(i int) *tls.Config {
+	fake.withTLSMutex.RLock()
+	defer fake.withTLSMutex.RUnlock()
+	return fake.withTLSArgsForCall[i].arg1
+}
+
+func (fake *MongoCheck) WithTLSReturns(result1 check.MongoCheck) {
+	fake.WithTLSStub = nil
+	fake.withTLSReturns = struct {
+		result1 check.MongoCheck
+	}{result1}
+}
+
+func (fake *MongoCheck) WithTimeout(arg1 time.Duration) check.MongoCheck {
+	fake.withTimeoutMutex.Lock()
+	fake.withTimeoutArgsForCall = append(fake.withTimeoutArgsForCall, struct {
+		arg1 time.Duration
+	}{arg1})
+	fake.withTimeoutMutex.Unlock()
+	if fake.WithTimeoutStub != nil {
+		return fake.WithTimeoutStub(arg1)
+	} else {
+		return fake.withTimeoutReturns.result1
+	}
+}
+
+func (fake *MongoCheck) WithTimeoutCallCount() int {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return len(fake.withTimeoutArgsForCall)
+}
+
+func (fake *MongoCheck) WithTimeoutArgsForCall(i int) time.Duration {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return fake.withTimeoutArgsForCall[i].arg1
+}
+
+func (fake *MongoCheck) WithTimeoutReturns(result1 check.MongoCheck) {
+	fake.WithTimeoutStub = nil
+	fake.withTimeoutReturns = struct {
+		result1 check.MongoCheck
+	}{result1}
+}
+
+func (fake *MongoCheck) WithLogger(arg1 io.Writer) check.MongoCheck {
+	fake.withLoggerMutex.Lock()
+	fake.withLoggerArgsForCall = append(fake.withLoggerArgsForCall, struct {
+		arg1 io.Writer
+	}{arg1})
+	fake.withLoggerMutex.Unlock()
+	if fake.WithLoggerStub != nil {
+		return fake.WithLoggerStub(arg1)
+	} else {
+		return fake.withLoggerReturns.result1
+	}
+}
+
+func (fake *MongoCheck) WithLoggerCallCount() int {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return len(fake.withLoggerArgsForCall)
+}
+
+func (fake *MongoCheck) WithLoggerArgsForCall(i int) io.Writer {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return fake.withLoggerArgsForCall[i].arg1
+}
+
+func (fake *MongoCheck) WithLoggerReturns(result1 check.MongoCheck) {
+	fake.WithLoggerStub = nil
+	fake.withLoggerReturns = struct {
+		result1 check.MongoCheck
+	}{result1}
+}
+
+var _ check.MongoCheck = new(MongoCheck)
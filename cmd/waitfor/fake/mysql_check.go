@@ -0,0 +1,195 @@
+// This file was generated by counterfeiter
+package fake
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/st3v/waitfor/check"
+)
+
+type MySQLCheck struct {
+	IsReadyStub        func() bool
+	isReadyMutex       sync.RWMutex
+	isReadyArgsForCall []struct{}
+	isReadyReturns struct {
+		result1 bool
+	}
+	WithPingStub        func() check.MySQLCheck
+	withPingMutex       sync.RWMutex
+	withPingArgsForCall []struct{}
+	withPingReturns struct {
+		result1 check.MySQLCheck
+	}
+	WithQueryStub        func(string) check.MySQLCheck
+	withQueryMutex       sync.RWMutex
+	withQueryArgsForCall []struct {
+		arg1 string
+	}
+	withQueryReturns struct {
+		result1 check.MySQLCheck
+	}
+	WithTimeoutStub        func(time.Duration) check.MySQLCheck
+	withTimeoutMutex       sync.RWMutex
+	withTimeoutArgsForCall []struct {
+		arg1 time.Duration
+	}
+	withTimeoutReturns struct {
+		result1 check.MySQLCheck
+	}
+	WithLoggerStub        func(io.Writer) check.MySQLCheck
+	withLoggerMutex       sync.RWMutex
+	withLoggerArgsForCall []struct {
+		arg1 io.Writer
+	}
+	withLoggerReturns struct {
+		result1 check.MySQLCheck
+	}
+}
+
+func (fake *MySQLCheck) IsReady() bool {
+	fake.isReadyMutex.Lock()
+	fake.isReadyArgsForCall = append(fake.isReadyArgsForCall, struct{}{})
+	fake.isReadyMutex.Unlock()
+	if fake.IsReadyStub != nil {
+		return fake.IsReadyStub()
+	} else {
+		return fake.isReadyReturns.result1
+	}
+}
+
+func (fake *MySQLCheck) IsReadyCallCount() int {
+	fake.isReadyMutex.RLock()
+	defer fake.isReadyMutex.RUnlock()
+	return len(fake.isReadyArgsForCall)
+}
+
+func (fake *MySQLCheck) IsReadyReturns(result1 bool) {
+	fake.IsReadyStub = nil
+	fake.isReadyReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *MySQLCheck) WithPing() check.MySQLCheck {
+	fake.withPingMutex.Lock()
+	fake.withPingArgsForCall = append(fake.withPingArgsForCall, struct{}{})
+	fake.withPingMutex.Unlock()
+	if fake.WithPingStub != nil {
+		return fake.WithPingStub()
+	} else {
+		return fake.withPingReturns.result1
+	}
+}
+
+func (fake *MySQLCheck) WithPingCallCount() int {
+	fake.withPingMutex.RLock()
+	defer fake.withPingMutex.RUnlock()
+	return len(fake.withPingArgsForCall)
+}
+
+func (fake *MySQLCheck) WithPingReturns(result1 check.MySQLCheck) {
+	fake.WithPingStub = nil
+	fake.withPingReturns = struct {
+		result1 check.MySQLCheck
+	}{result1}
+}
+
+func (fake *MySQLCheck) WithQuery(arg1 string) check.MySQLCheck {
+	fake.withQueryMutex.Lock()
+	fake.withQueryArgsForCall = append(fake.withQueryArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.withQueryMutex.Unlock()
+	if fake.WithQueryStub != nil {
+		return fake.WithQueryStub(arg1)
+	} else {
+		return fake.withQueryReturns.result1
+	}
+}
+
+func (fake *MySQLCheck) WithQueryCallCount() int {
+	fake.withQueryMutex.RLock()
+	defer fake.withQueryMutex.RUnlock()
+	return len(fake.withQueryArgsForCall)
+}
+
+func (fake *MySQLCheck) WithQueryArgsForCall(i int) string {
+	fake.withQueryMutex.RLock()
+	defer fake.withQueryMutex.RUnlock()
+	return fake.withQueryArgsForCall[i].arg1
+}
+
+func (fake *MySQLCheck) WithQueryReturns(result1 check.MySQLCheck) {
+	fake.WithQueryStub = nil
+	fake.withQueryReturns = struct {
+		result1 check.MySQLCheck
+	}{result1}
+}
+
+func (fake *MySQLCheck) WithTimeout(arg1 time.Duration) check.MySQLCheck {
+	fake.withTimeoutMutex.Lock()
+	fake.withTimeoutArgsForCall = append(fake.withTimeoutArgsForCall, struct {
+		arg1 time.Duration
+	}{arg1})
+	fake.withTimeoutMutex.Unlock()
+	if fake.WithTimeoutStub != nil {
+		return fake.WithTimeoutStub(arg1)
+	} else {
+		return fake.withTimeoutReturns.result1
+	}
+}
+
+func (fake *MySQLCheck) WithTimeoutCallCount() int {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return len(fake.withTimeoutArgsForCall)
+}
+
+func (fake *MySQLCheck) WithTimeoutArgsForCall(i int) time.Duration {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return fake.withTimeoutArgsForCall[i].arg1
+}
+
+func (fake *MySQLCheck) WithTimeoutReturns(result1 check.MySQLCheck) {
+	fake.WithTimeoutStub = nil
+	fake.withTimeoutReturns = struct {
+		result1 check.MySQLCheck
+	}{result1}
+}
+
+func (fake *MySQLCheck) WithLogger(arg1 io.Writer) check.MySQLCheck {
+	fake.withLoggerMutex.Lock()
+	fake.withLoggerArgsForCall = append(fake.withLoggerArgsForCall, struct {
+		arg1 io.Writer
+	}{arg1})
+	fake.withLoggerMutex.Unlock()
+	if fake.WithLoggerStub != nil {
+		return fake.WithLoggerStub(arg1)
+	} else {
+		return fake.withLoggerReturns.result1
+	}
+}
+
+func (fake *MySQLCheck) WithLoggerCallCount() int {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return len(fake.withLoggerArgsForCall)
+}
+
+func (fake *MySQLCheck) WithLoggerArgsForCall(i int) io.Writer {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return fake.withLoggerArgsForCall[i].arg1
+}
+
+func (fake *MySQLCheck) WithLoggerReturns(result1 check.MySQLCheck) {
+	fake.WithLoggerStub = nil
+	fake.withLoggerReturns = struct {
+		result1 check.MySQLCheck
+	}{result1}
+}
+
+var _ check.MySQLCheck = new(MySQLCheck)
@@ -4,6 +4,7 @@ package fake
 import (
 	"io"
 	"sync"
+	"time"
 
 	"github.com/st3v/waitfor/check"
 )
@@ -37,6 +38,30 @@ type PortCheck struct {
 	forNetworkReturns struct {
 		result1 check.PortCheck
 	}
+	WithPayloadStub        func([]byte) check.PortCheck
+	withPayloadMutex       sync.RWMutex
+	withPayloadArgsForCall []struct {
+		arg1 []byte
+	}
+	withPayloadReturns struct {
+		result1 check.PortCheck
+	}
+	WithReplyTimeoutStub        func(time.Duration) check.PortCheck
+	withReplyTimeoutMutex       sync.RWMutex
+	withReplyTimeoutArgsForCall []struct {
+		arg1 time.Duration
+	}
+	withReplyTimeoutReturns struct {
+		result1 check.PortCheck
+	}
+	WithNoReplyOKStub        func(bool) check.PortCheck
+	withNoReplyOKMutex       sync.RWMutex
+	withNoReplyOKArgsForCall []struct {
+		arg1 bool
+	}
+	withNoReplyOKReturns struct {
+		result1 check.PortCheck
+	}
 	WithLoggerStub        func(io.Writer) check.PortCheck
 	withLoggerMutex       sync.RWMutex
 	withLoggerArgsForCall []struct {
@@ -159,6 +184,107 @@ func (fake *PortCheck) ForNetworkReturns(result1 check.PortCheck) {
 	}{result1}
 }
 
+func (fake *PortCheck) WithPayload(arg1 []byte) check.PortCheck {
+	var arg1Copy []byte
+	if arg1 != nil {
+		arg1Copy = make([]byte, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.withPayloadMutex.Lock()
+	fake.withPayloadArgsForCall = append(fake.withPayloadArgsForCall, struct {
+		arg1 []byte
+	}{arg1Copy})
+	fake.withPayloadMutex.Unlock()
+	if fake.WithPayloadStub != nil {
+		return fake.WithPayloadStub(arg1)
+	} else {
+		return fake.withPayloadReturns.result1
+	}
+}
+
+func (fake *PortCheck) WithPayloadCallCount() int {
+	fake.withPayloadMutex.RLock()
+	defer fake.withPayloadMutex.RUnlock()
+	return len(fake.withPayloadArgsForCall)
+}
+
+func (fake *PortCheck) WithPayloadArgsForCall(i int) []byte {
+	fake.withPayloadMutex.RLock()
+	defer fake.withPayloadMutex.RUnlock()
+	return fake.withPayloadArgsForCall[i].arg1
+}
+
+func (fake *PortCheck) WithPayloadReturns(result1 check.PortCheck) {
+	fake.WithPayloadStub = nil
+	fake.withPayloadReturns = struct {
+		result1 check.PortCheck
+	}{result1}
+}
+
+func (fake *PortCheck) WithReplyTimeout(arg1 time.Duration) check.PortCheck {
+	fake.withReplyTimeoutMutex.Lock()
+	fake.withReplyTimeoutArgsForCall = append(fake.withReplyTimeoutArgsForCall, struct {
+		arg1 time.Duration
+	}{arg1})
+	fake.withReplyTimeoutMutex.Unlock()
+	if fake.WithReplyTimeoutStub != nil {
+		return fake.WithReplyTimeoutStub(arg1)
+	} else {
+		return fake.withReplyTimeoutReturns.result1
+	}
+}
+
+func (fake *PortCheck) WithReplyTimeoutCallCount() int {
+	fake.withReplyTimeoutMutex.RLock()
+	defer fake.withReplyTimeoutMutex.RUnlock()
+	return len(fake.withReplyTimeoutArgsForCall)
+}
+
+func (fake *PortCheck) WithReplyTimeoutArgsForCall(i int) time.Duration {
+	fake.withReplyTimeoutMutex.RLock()
+	defer fake.withReplyTimeoutMutex.RUnlock()
+	return fake.withReplyTimeoutArgsForCall[i].arg1
+}
+
+func (fake *PortCheck) WithReplyTimeoutReturns(result1 check.PortCheck) {
+	fake.WithReplyTimeoutStub = nil
+	fake.withReplyTimeoutReturns = struct {
+		result1 check.PortCheck
+	}{result1}
+}
+
+func (fake *PortCheck) WithNoReplyOK(arg1 bool) check.PortCheck {
+	fake.withNoReplyOKMutex.Lock()
+	fake.withNoReplyOKArgsForCall = append(fake.withNoReplyOKArgsForCall, struct {
+		arg1 bool
+	}{arg1})
+	fake.withNoReplyOKMutex.Unlock()
+	if fake.WithNoReplyOKStub != nil {
+		return fake.WithNoReplyOKStub(arg1)
+	} else {
+		return fake.withNoReplyOKReturns.result1
+	}
+}
+
+func (fake *PortCheck) WithNoReplyOKCallCount() int {
+	fake.withNoReplyOKMutex.RLock()
+	defer fake.withNoReplyOKMutex.RUnlock()
+	return len(fake.withNoReplyOKArgsForCall)
+}
+
+func (fake *PortCheck) WithNoReplyOKArgsForCall(i int) bool {
+	fake.withNoReplyOKMutex.RLock()
+	defer fake.withNoReplyOKMutex.RUnlock()
+	return fake.withNoReplyOKArgsForCall[i].arg1
+}
+
+func (fake *PortCheck) WithNoReplyOKReturns(result1 check.PortCheck) {
+	fake.WithNoReplyOKStub = nil
+	fake.withNoReplyOKReturns = struct {
+		result1 check.PortCheck
+	}{result1}
+}
+
 func (fake *PortCheck) WithLogger(arg1 io.Writer) check.PortCheck {
 	fake.withLoggerMutex.Lock()
 	fake.withLoggerArgsForCall = append(fake.withLoggerArgsForCall, struct {
@@ -0,0 +1,195 @@
+// This file was generated by counterfeiter
+package fake
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/st3v/waitfor/check"
+)
+
+type PostgresCheck struct {
+	IsReadyStub        func() bool
+	isReadyMutex       sync.RWMutex
+	isReadyArgsForCall []struct{}
+	isReadyReturns struct {
+		result1 bool
+	}
+	IsPrimaryStub        func() bool
+	isPrimaryMutex       sync.RWMutex
+	isPrimaryArgsForCall []struct{}
+	isPrimaryReturns struct {
+		result1 bool
+	}
+	WithQueryStub        func(string) check.PostgresCheck
+	withQueryMutex       sync.RWMutex
+	withQueryArgsForCall []struct {
+		arg1 string
+	}
+	withQueryReturns struct {
+		result1 check.PostgresCheck
+	}
+	WithTimeoutStub        func(time.Duration) check.PostgresCheck
+	withTimeoutMutex       sync.RWMutex
+	withTimeoutArgsForCall []struct {
+		arg1 time.Duration
+	}
+	withTimeoutReturns struct {
+		result1 check.PostgresCheck
+	}
+	WithLoggerStub        func(io.Writer) check.PostgresCheck
+	withLoggerMutex       sync.RWMutex
+	withLoggerArgsForCall []struct {
+		arg1 io.Writer
+	}
+	withLoggerReturns struct {
+		result1 check.PostgresCheck
+	}
+}
+
+func (fake *PostgresCheck) IsReady() bool {
+	fake.isReadyMutex.Lock()
+	fake.isReadyArgsForCall = append(fake.isReadyArgsForCall, struct{}{})
+	fake.isReadyMutex.Unlock()
+	if fake.IsReadyStub != nil {
+		return fake.IsReadyStub()
+	} else {
+		return fake.isReadyReturns.result1
+	}
+}
+
+func (fake *PostgresCheck) IsReadyCallCount() int {
+	fake.isReadyMutex.RLock()
+	defer fake.isReadyMutex.RUnlock()
+	return len(fake.isReadyArgsForCall)
+}
+
+func (fake *PostgresCheck) IsReadyReturns(result1 bool) {
+	fake.IsReadyStub = nil
+	fake.isReadyReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *PostgresCheck) IsPrimary() bool {
+	fake.isPrimaryMutex.Lock()
+	fake.isPrimaryArgsForCall = append(fake.isPrimaryArgsForCall, struct{}{})
+	fake.isPrimaryMutex.Unlock()
+	if fake.IsPrimaryStub != nil {
+		return fake.IsPrimaryStub()
+	} else {
+		return fake.isPrimaryReturns.result1
+	}
+}
+
+func (fake *PostgresCheck) IsPrimaryCallCount() int {
+	fake.isPrimaryMutex.RLock()
+	defer fake.isPrimaryMutex.RUnlock()
+	return len(fake.isPrimaryArgsForCall)
+}
+
+func (fake *PostgresCheck) IsPrimaryReturns(result1 bool) {
+	fake.IsPrimaryStub = nil
+	fake.isPrimaryReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *PostgresCheck) WithQuery(arg1 string) check.PostgresCheck {
+	fake.withQueryMutex.Lock()
+	fake.withQueryArgsForCall = append(fake.withQueryArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.withQueryMutex.Unlock()
+	if fake.WithQueryStub != nil {
+		return fake.WithQueryStub(arg1)
+	} else {
+		return fake.withQueryReturns.result1
+	}
+}
+
+func (fake *PostgresCheck) WithQueryCallCount() int {
+	fake.withQueryMutex.RLock()
+	defer fake.withQueryMutex.RUnlock()
+	return len(fake.withQueryArgsForCall)
+}
+
+func (fake *PostgresCheck) WithQueryArgsForCall(i int) string {
+	fake.withQueryMutex.RLock()
+	defer fake.withQueryMutex.RUnlock()
+	return fake.withQueryArgsForCall[i].arg1
+}
+
+func (fake *PostgresCheck) WithQueryReturns(result1 check.PostgresCheck) {
+	fake.WithQueryStub = nil
+	fake.withQueryReturns = struct {
+		result1 check.PostgresCheck
+	}{result1}
+}
+
+func (fake *PostgresCheck) WithTimeout(arg1 time.Duration) check.PostgresCheck {
+	fake.withTimeoutMutex.Lock()
+	fake.withTimeoutArgsForCall = append(fake.withTimeoutArgsForCall, struct {
+		arg1 time.Duration
+	}{arg1})
+	fake.withTimeoutMutex.Unlock()
+	if fake.WithTimeoutStub != nil {
+		return fake.WithTimeoutStub(arg1)
+	} else {
+		return fake.withTimeoutReturns.result1
+	}
+}
+
+func (fake *PostgresCheck) WithTimeoutCallCount() int {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return len(fake.withTimeoutArgsForCall)
+}
+
+func (fake *PostgresCheck) WithTimeoutArgsForCall(i int) time.Duration {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return fake.withTimeoutArgsForCall[i].arg1
+}
+
+func (fake *PostgresCheck) WithTimeoutReturns(result1 check.PostgresCheck) {
+	fake.WithTimeoutStub = nil
+	fake.withTimeoutReturns = struct {
+		result1 check.PostgresCheck
+	}{result1}
+}
+
+func (fake *PostgresCheck) WithLogger(arg1 io.Writer) check.PostgresCheck {
+	fake.withLoggerMutex.Lock()
+	fake.withLoggerArgsForCall = append(fake.withLoggerArgsForCall, struct {
+		arg1 io.Writer
+	}{arg1})
+	fake.withLoggerMutex.Unlock()
+	if fake.WithLoggerStub != nil {
+		return fake.WithLoggerStub(arg1)
+	} else {
+		return fake.withLoggerReturns.result1
+	}
+}
+
+func (fake *PostgresCheck) WithLoggerCallCount() int {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return len(fake.withLoggerArgsForCall)
+}
+
+func (fake *PostgresCheck) WithLoggerArgsForCall(i int) io.Writer {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return fake.withLoggerArgsForCall[i].arg1
+}
+
+func (fake *PostgresCheck) WithLoggerReturns(result1 check.PostgresCheck) {
+	fake.WithLoggerStub = nil
+	fake.withLoggerReturns = struct {
+		result1 check.PostgresCheck
+	}{result1}
+}
+
+var _ check.PostgresCheck = new(PostgresCheck)
@@ -0,0 +1,286 @@
+// This file was generated by counterfeiter
+package fake
+
+import (
+	"crypto/tls"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/st3v/waitfor/check"
+)
+
+type RedisCheck struct {
+	IsReadyStub        func() bool
+	isReadyMutex       sync.RWMutex
+	isReadyArgsForCall []struct{}
+	isReadyReturns struct {
+		result1 bool
+	}
+	WithUsernameStub        func(string) check.RedisCheck
+	withUsernameMutex       sync.RWMutex
+	withUsernameArgsForCall []struct {
+		arg1 string
+	}
+	withUsernameReturns struct {
+		result1 check.RedisCheck
+	}
+	WithPasswordStub        func(string) check.RedisCheck
+	withPasswordMutex       sync.RWMutex
+	withPasswordArgsForCall []struct {
+		arg1 string
+	}
+	withPasswordReturns struct {
+		result1 check.RedisCheck
+	}
+	WithTLSStub        func(*tls.Config) check.RedisCheck
+	withTLSMutex       sync.RWMutex
+	withTLSArgsForCall []struct {
+		arg1 *tls.Config
+	}
+	withTLSReturns struct {
+		result1 check.RedisCheck
+	}
+	WithInfoStub        func(string) check.RedisCheck
+	withInfoMutex       sync.RWMutex
+	withInfoArgsForCall []struct {
+		arg1 string
+	}
+	withInfoReturns struct {
+		result1 check.RedisCheck
+	}
+	WithTimeoutStub        func(time.Duration) check.RedisCheck
+	withTimeoutMutex       sync.RWMutex
+	withTimeoutArgsForCall []struct {
+		arg1 time.Duration
+	}
+	withTimeoutReturns struct {
+		result1 check.RedisCheck
+	}
+	WithLoggerStub        func(io.Writer) check.RedisCheck
+	withLoggerMutex       sync.RWMutex
+	withLoggerArgsForCall []struct {
+		arg1 io.Writer
+	}
+	withLoggerReturns struct {
+		result1 check.RedisCheck
+	}
+}
+
+func (fake *RedisCheck) IsReady() bool {
+	fake.isReadyMutex.Lock()
+	fake.isReadyArgsForCall = append(fake.isReadyArgsForCall, struct{}{})
+	fake.isReadyMutex.Unlock()
+	if fake.IsReadyStub != nil {
+		return fake.IsReadyStub()
+	} else {
+		return fake.isReadyReturns.result1
+	}
+}
+
+func (fake *RedisCheck) IsReadyCallCount() int {
+	fake.isReadyMutex.RLock()
+	defer fake.isReadyMutex.RUnlock()
+	return len(fake.isReadyArgsForCall)
+}
+
+func (fake *RedisCheck) IsReadyReturns(result1 bool) {
+	fake.IsReadyStub = nil
+	fake.isReadyReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *RedisCheck) WithUsername(arg1 string) check.RedisCheck {
+	fake.withUsernameMutex.Lock()
+	fake.withUsernameArgsForCall = append(fake.withUsernameArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.withUsernameMutex.Unlock()
+	if fake.WithUsernameStub != nil {
+		return fake.WithUsernameStub(arg1)
+	} else {
+		return fake.withUsernameReturns.result1
+	}
+}
+
+func (fake *RedisCheck) WithUsernameCallCount() int {
+	fake.withUsernameMutex.RLock()
+	defer fake.withUsernameMutex.RUnlock()
+	return len(fake.withUsernameArgsForCall)
+}
+
+func (fake *RedisCheck) WithUsernameArgsForCall(i int) string {
+	fake.withUsernameMutex.RLock()
+	defer fake.withUsernameMutex.RUnlock()
+	return fake.withUsernameArgsForCall[i].arg1
+}
+
+func (fake *RedisCheck) WithUsernameReturns(result1 check.RedisCheck) {
+	fake.WithUsernameStub = nil
+	fake.withUsernameReturns = struct {
+		result1 check.RedisCheck
+	}{result1}
+}
+
+func (fake *RedisCheck) WithPassword(arg1 string) check.RedisCheck {
+	fake.withPasswordMutex.Lock()
+	fake.withPasswordArgsForCall = append(fake.withPasswordArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.withPasswordMutex.Unlock()
+	if fake.WithPasswordStub != nil {
+		return fake.WithPasswordStub(arg1)
+	} else {
+		return fake.withPasswordReturns.result1
+	}
+}
+
+func (fake *RedisCheck) WithPasswordCallCount() int {
+	fake.withPasswordMutex.RLock()
+	defer fake.withPasswordMutex.RUnlock()
+	return len(fake.withPasswordArgsForCall)
+}
+
+func (fake *RedisCheck) WithPasswordArgsForCall(i int) string {
+	fake.withPasswordMutex.RLock()
+	defer fake.withPasswordMutex.RUnlock()
+	return fake.withPasswordArgsForCall[i].arg1
+}
+
+func (fake *RedisCheck) WithPasswordReturns(result1 check.RedisCheck) {
+	fake.WithPasswordStub = nil
+	fake.withPasswordReturns = struct {
+		result1 check.RedisCheck
+	}{result1}
+}
+
+func (fake *RedisCheck) WithTLS(arg1 *tls.Config) check.RedisCheck {
+	fake.withTLSMutex.Lock()
+	fake.withTLSArgsForCall = append(fake.withTLSArgsForCall, struct {
+		arg1 *tls.Config
+	}{arg1})
+	fake.withTLSMutex.Unlock()
+	if fake.WithTLSStub != nil {
+		return fake.WithTLSStub(arg1)
+	} else {
+		return fake.withTLSReturns.result1
+	}
+}
+
+func (fake *RedisCheck) WithTLSCallCount() int {
+	fake.withTLSMutex.RLock()
+	defer fake.withTLSMutex.RUnlock()
+	return len(fake.withTLSArgsForCall)
+}
+
+func (fake *RedisCheck) WithTLSArgsForCall(i int) *tls.Config {
+	fake.withTLSMutex.RLock()
+	defer fake.withTLSMutex.RUnlock()
+	return fake.withTLSArgsForCall[i].arg1
+}
+
+func (fake *RedisCheck) WithTLSReturns(result1 check.RedisCheck) {
+	fake.WithTLSStub = nil
+	fake.withTLSReturns = struct {
+		result1 check.RedisCheck
+	}{result1}
+}
+
+func (fake *RedisCheck) WithInfo(arg1 string) check.RedisCheck {
+	fake.withInfoMutex.Lock()
+	fake.withInfoArgsForCall = append(fake.withInfoArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.withInfoMutex.Unlock()
+	if fake.WithInfoStub != nil {
+		return fake.WithInfoStub(arg1)
+	} else {
+		return fake.withInfoReturns.result1
+	}
+}
+
+func (fake *RedisCheck) WithInfoCallCount() int {
+	fake.withInfoMutex.RLock()
+	defer fake.withInfoMutex.RUnlock()
+	return len(fake.withInfoArgsForCall)
+}
+
+func (fake *RedisCheck) WithInfoArgsForCall(i int) string {
+	fake.withInfoMutex.RLock()
+	defer fake.withInfoMutex.RUnlock()
+	return fake.withInfoArgsForCall[i].arg1
+}
+
+func (fake *RedisCheck) WithInfoReturns(result1 check.RedisCheck) {
+	fake.WithInfoStub = nil
+	fake.withInfoReturns = struct {
+		result1 check.RedisCheck
+	}{result1}
+}
+
+func (fake *RedisCheck) WithTimeout(arg1 time.Duration) check.RedisCheck {
+	fake.withTimeoutMutex.Lock()
+	fake.withTimeoutArgsForCall = append(fake.withTimeoutArgsForCall, struct {
+		arg1 time.Duration
+	}{arg1})
+	fake.withTimeoutMutex.Unlock()
+	if fake.WithTimeoutStub != nil {
+		return fake.WithTimeoutStub(arg1)
+	} else {
+		return fake.withTimeoutReturns.result1
+	}
+}
+
+func (fake *RedisCheck) WithTimeoutCallCount() int {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return len(fake.withTimeoutArgsForCall)
+}
+
+func (fake *RedisCheck) WithTimeoutArgsForCall(i int) time.Duration {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return fake.withTimeoutArgsForCall[i].arg1
+}
+
+func (fake *RedisCheck) WithTimeoutReturns(result1 check.RedisCheck) {
+	fake.WithTimeoutStub = nil
+	fake.withTimeoutReturns = struct {
+		result1 check.RedisCheck
+	}{result1}
+}
+
+func (fake *RedisCheck) WithLogger(arg1 io.Writer) check.RedisCheck {
+	fake.withLoggerMutex.Lock()
+	fake.withLoggerArgsForCall = append(fake.withLoggerArgsForCall, struct {
+		arg1 io.Writer
+	}{arg1})
+	fake.withLoggerMutex.Unlock()
+	if fake.WithLoggerStub != nil {
+		return fake.WithLoggerStub(arg1)
+	} else {
+		return fake.withLoggerReturns.result1
+	}
+}
+
+func (fake *RedisCheck) WithLoggerCallCount() int {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return len(fake.withLoggerArgsForCall)
+}
+
+func (fake *RedisCheck) WithLoggerArgsForCall(i int) io.Writer {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return fake.withLoggerArgsForCall[i].arg1
+}
+
+func (fake *RedisCheck) WithLoggerReturns(result1 check.RedisCheck) {
+	fake.WithLoggerStub = nil
+	fake.withLoggerReturns = struct {
+		result1 check.RedisCheck
+	}{result1}
+}
+
+var _ check.RedisCheck = new(RedisCheck)
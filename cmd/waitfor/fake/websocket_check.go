@@ -0,0 +1,289 @@
+// This file was generated by counterfeiter
+package fake
+
+import (
+	"crypto/tls"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/st3v/waitfor/check"
+)
+
+type WebSocketCheck struct {
+	IsReadyStub        func() bool
+	isReadyMutex       sync.RWMutex
+	isReadyArgsForCall []struct{}
+	isReadyReturns struct {
+		result1 bool
+	}
+	WithHeaderStub        func(string, string) check.WebSocketCheck
+	withHeaderMutex       sync.RWMutex
+	withHeaderArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	withHeaderReturns struct {
+		result1 check.WebSocketCheck
+	}
+	WithMessageStub        func(string) check.WebSocketCheck
+	withMessageMutex       sync.RWMutex
+	withMessageArgsForCall []struct {
+		arg1 string
+	}
+	withMessageReturns struct {
+		result1 check.WebSocketCheck
+	}
+	WithMatchStub        func(*regexp.Regexp) check.WebSocketCheck
+	withMatchMutex       sync.RWMutex
+	withMatchArgsForCall []struct {
+		arg1 *regexp.Regexp
+	}
+	withMatchReturns struct {
+		result1 check.WebSocketCheck
+	}
+	WithTLSStub        func(*tls.Config) check.WebSocketCheck
+	withTLSMutex       sync.RWMutex
+	withTLSArgsForCall []struct {
+		arg1 *tls.Config
+	}
+	withTLSReturns struct {
+		result1 check.WebSocketCheck
+	}
+	WithTimeoutStub        func(time.Duration) check.WebSocketCheck
+	withTimeoutMutex       sync.RWMutex
+	withTimeoutArgsForCall []struct {
+		arg1 time.Duration
+	}
+	withTimeoutReturns struct {
+		result1 check.WebSocketCheck
+	}
+	WithLoggerStub        func(io.Writer) check.WebSocketCheck
+	withLoggerMutex       sync.RWMutex
+	withLoggerArgsForCall []struct {
+		arg1 io.Writer
+	}
+	withLoggerReturns struct {
+		result1 check.WebSocketCheck
+	}
+}
+
+func (fake *WebSocketCheck) IsReady() bool {
+	fake.isReadyMutex.Lock()
+	fake.isReadyArgsForCall = append(fake.isReadyArgsForCall, struct{}{})
+	fake.isReadyMutex.Unlock()
+	if fake.IsReadyStub != nil {
+		return fake.IsReadyStub()
+	} else {
+		return fake.isReadyReturns.result1
+	}
+}
+
+func (fake *WebSocketCheck) IsReadyCallCount() int {
+	fake.isReadyMutex.RLock()
+	defer fake.isReadyMutex.RUnlock()
+	return len(fake.isReadyArgsForCall)
+}
+
+func (fake *WebSocketCheck) IsReadyReturns(result1 bool) {
+	fake.IsReadyStub = nil
+	fake.isReadyReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *WebSocketCheck) WithHeader(arg1 string, arg2 string) check.WebSocketCheck {
+	fake.withHeaderMutex.Lock()
+	fake.withHeaderArgsForCall = append(fake.withHeaderArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.withHeaderMutex.Unlock()
+	if fake.WithHeaderStub != nil {
+		return fake.WithHeaderStub(arg1, arg2)
+	} else {
+		return fake.withHeaderReturns.result1
+	}
+}
+
+func (fake *WebSocketCheck) WithHeaderCallCount() int {
+	fake.withHeaderMutex.RLock()
+	defer fake.withHeaderMutex.RUnlock()
+	return len(fake.withHeaderArgsForCall)
+}
+
+func (fake *WebSocketCheck) WithHeaderArgsForCall(i int) (string, string) {
+	fake.withHeaderMutex.RLock()
+	defer fake.withHeaderMutex.RUnlock()
+	return fake.withHeaderArgsForCall[i].arg1, fake.withHeaderArgsForCall[i].arg2
+}
+
+func (fake *WebSocketCheck) WithHeaderReturns(result1 check.WebSocketCheck) {
+	fake.WithHeaderStub = nil
+	fake.withHeaderReturns = struct {
+		result1 check.WebSocketCheck
+	}{result1}
+}
+
+func (fake *WebSocketCheck) WithMessage(arg1 string) check.WebSocketCheck {
+	fake.withMessageMutex.Lock()
+	fake.withMessageArgsForCall = append(fake.withMessageArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.withMessageMutex.Unlock()
+	if fake.WithMessageStub != nil {
+		return fake.WithMessageStub(arg1)
+	} else {
+		return fake.withMessageReturns.result1
+	}
+}
+
+func (fake *WebSocketCheck) WithMessageCallCount() int {
+	fake.withMessageMutex.RLock()
+	defer fake.withMessageMutex.RUnlock()
+	return len(fake.withMessageArgsForCall)
+}
+
+func (fake *WebSocketCheck) WithMessageArgsForCall(i int) string {
+	fake.withMessageMutex.RLock()
+	defer fake.withMessageMutex.RUnlock()
+	return fake.withMessageArgsForCall[i].arg1
+}
+
+func (fake *WebSocketCheck) WithMessageReturns(result1 check.WebSocketCheck) {
+	fake.WithMessageStub = nil
+	fake.withMessageReturns = struct {
+		result1 check.WebSocketCheck
+	}{result1}
+}
+
+func (fake *WebSocketCheck) WithMatch(arg1 *regexp.Regexp) check.WebSocketCheck {
+	fake.withMatchMutex.Lock()
+	fake.withMatchArgsForCall = append(fake.withMatchArgsForCall, struct {
+		arg1 *regexp.Regexp
+	}{arg1})
+	fake.withMatchMutex.Unlock()
+	if fake.WithMatchStub != nil {
+		return fake.WithMatchStub(arg1)
+	} else {
+		return fake.withMatchReturns.result1
+	}
+}
+
+func (fake *WebSocketCheck) WithMatchCallCount() int {
+	fake.withMatchMutex.RLock()
+	defer fake.withMatchMutex.RUnlock()
+	return len(fake.withMatchArgsForCall)
+}
+
+func (fake *WebSocketCheck) WithMatchArgsForCall(i int) *regexp.Regexp {
+	fake.withMatchMutex.RLock()
+	defer fake.withMatchMutex.RUnlock()
+	return fake.withMatchArgsForCall[i].arg1
+}
+
+func (fake *WebSocketCheck) WithMatchReturns(result1 check.WebSocketCheck) {
+	fake.WithMatchStub = nil
+	fake.withMatchReturns = struct {
+		result1 check.WebSocketCheck
+	}{result1}
+}
+
+func (fake *WebSocketCheck) WithTLS(arg1 *tls.Config) check.WebSocketCheck {
+	fake.withTLSMutex.Lock()
+	fake.withTLSArgsForCall = append(fake.withTLSArgsForCall, struct {
+		arg1 *tls.Config
+	}{arg1})
+	fake.withTLSMutex.Unlock()
+	if fake.WithTLSStub != nil {
+		return fake.WithTLSStub(arg1)
+	} else {
+		return fake.withTLSReturns.result1
+	}
+}
+
+func (fake *WebSocketCheck) WithTLSCallCount() int {
+	fake.withTLSMutex.RLock()
+	defer fake.withTLSMutex.RUnlock()
+	return len(fake.withTLSArgsForCall)
+}
+
+func (fake *WebSocketCheck) WithTLSArgsForCall(i int) *tls.Config {
+	fake.withTLSMutex.RLock()
+	defer fake.withTLSMutex.RUnlock()
+	return fake.withTLSArgsForCall[i].arg1
+}
+
+func (fake *WebSocketCheck) WithTLSReturns(result1 check.WebSocketCheck) {
+	fake.WithTLSStub = nil
+	fake.withTLSReturns = struct {
+		result1 check.WebSocketCheck
+	}{result1}
+}
+
+func (fake *WebSocketCheck) WithTimeout(arg1 time.Duration) check.WebSocketCheck {
+	fake.withTimeoutMutex.Lock()
+	fake.withTimeoutArgsForCall = append(fake.withTimeoutArgsForCall, struct {
+		arg1 time.Duration
+	}{arg1})
+	fake.withTimeoutMutex.Unlock()
+	if fake.WithTimeoutStub != nil {
+		return fake.WithTimeoutStub(arg1)
+	} else {
+		return fake.withTimeoutReturns.result1
+	}
+}
+
+func (fake *WebSocketCheck) WithTimeoutCallCount() int {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return len(fake.withTimeoutArgsForCall)
+}
+
+func (fake *WebSocketCheck) WithTimeoutArgsForCall(i int) time.Duration {
+	fake.withTimeoutMutex.RLock()
+	defer fake.withTimeoutMutex.RUnlock()
+	return fake.withTimeoutArgsForCall[i].arg1
+}
+
+func (fake *WebSocketCheck) WithTimeoutReturns(result1 check.WebSocketCheck) {
+	fake.WithTimeoutStub = nil
+	fake.withTimeoutReturns = struct {
+		result1 check.WebSocketCheck
+	}{result1}
+}
+
+func (fake *WebSocketCheck) WithLogger(arg1 io.Writer) check.WebSocketCheck {
+	fake.withLoggerMutex.Lock()
+	fake.withLoggerArgsForCall = append(fake.withLoggerArgsForCall, struct {
+		arg1 io.Writer
+	}{arg1})
+	fake.withLoggerMutex.Unlock()
+	if fake.WithLoggerStub != nil {
+		return fake.WithLoggerStub(arg1)
+	} else {
+		return fake.withLoggerReturns.result1
+	}
+}
+
+func (fake *WebSocketCheck) WithLoggerCallCount() int {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return len(fake.withLoggerArgsForCall)
+}
+
+func (fake *WebSocketCheck) WithLoggerArgsForCall(i int) io.Writer {
+	fake.withLoggerMutex.RLock()
+	defer fake.withLoggerMutex.RUnlock()
+	return fake.withLoggerArgsForCall[i].arg1
+}
+
+func (fake *WebSocketCheck) WithLoggerReturns(result1 check.WebSocketCheck) {
+	fake.WithLoggerStub = nil
+	fake.withLoggerReturns = struct {
+		result1 check.WebSocketCheck
+	}{result1}
+}
+
+var _ check.WebSocketCheck = new(WebSocketCheck)
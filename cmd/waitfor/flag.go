@@ -7,82 +7,337 @@ import (
 )
 
 var closedFlag = cli.BoolFlag{
-	Name:  "closed, c",
-	Usage: "wait for port to be closed",
+	Name:   "closed, c",
+	EnvVar: "WAITFOR_CLOSED",
+	Usage:  "wait for port to be closed",
+}
+
+var anyFlag = cli.BoolFlag{
+	Name:   "any, a",
+	EnvVar: "WAITFOR_ANY",
+	Usage:  "succeed as soon as one of multiple ports satisfies the condition",
 }
 
 var networkFlag = cli.StringFlag{
-	Name:  "network, n",
-	Value: "tcp",
-	Usage: "named network, ['tcp', 'tcp4', 'tcp6', 'udp', 'udp4', 'udp6', 'ip', 'ip4', 'ip6']",
+	Name:   "network, n",
+	EnvVar: "WAITFOR_NETWORK",
+	Value:  "tcp",
+	Usage:  "named network, ['tcp', 'tcp4', 'tcp6', 'udp', 'udp4', 'udp6', 'ip', 'ip4', 'ip6']",
 }
 
 var hostFlag = cli.StringFlag{
-	Name:  "host, h",
-	Value: "127.0.0.1",
-	Usage: "resolvable hostname or IP address",
+	Name:   "host, h",
+	EnvVar: "WAITFOR_HOST",
+	Value:  "127.0.0.1",
+	Usage:  "resolvable hostname or IP address",
+}
+
+var payloadFlag = cli.StringFlag{
+	Name:   "payload, p",
+	EnvVar: "WAITFOR_PAYLOAD",
+	Value:  "",
+	Usage:  "datagram sent to probe UDP ports, supports escape sequences like '\\n' or '\\x00'",
+}
+
+var replyTimeoutFlag = cli.DurationFlag{
+	Name:   "reply-timeout, r",
+	EnvVar: "WAITFOR_REPLY_TIMEOUT",
+	Value:  1 * time.Second,
+	Usage:  "maximum time to wait for a reply to a UDP probe",
+}
+
+var noReplyOKFlag = cli.BoolFlag{
+	Name:   "no-reply-ok",
+	EnvVar: "WAITFOR_NO_REPLY_OK",
+	Usage:  "consider UDP ports open if a probe goes unanswered without ICMP port unreachable, e.g. for StatsD or syslog",
 }
 
 var timeoutFlag = cli.DurationFlag{
-	Name:  "timeout, t",
-	Value: 300 * time.Second,
-	Usage: "maximum time to wait for",
+	Name:   "timeout, t",
+	EnvVar: "WAITFOR_TIMEOUT",
+	Value:  300 * time.Second,
+	Usage:  "maximum time to wait for",
 }
 
 var intervalFlag = cli.DurationFlag{
-	Name:  "interval, i",
-	Value: 1 * time.Second,
-	Usage: "time in-between checks",
+	Name:   "interval, i",
+	EnvVar: "WAITFOR_INTERVAL",
+	Value:  1 * time.Second,
+	Usage:  "time in-between checks",
 }
 
 var verboseFlag = cli.BoolFlag{
-	Name:  "verbose, v",
-	Usage: "enable additional logging",
+	Name:   "verbose, v",
+	EnvVar: "WAITFOR_VERBOSE",
+	Usage:  "enable additional logging",
 }
 
 var failFlag = cli.BoolFlag{
-	Name:  "fail, f",
-	Usage: "wait for condition to fail",
+	Name:   "fail, f",
+	EnvVar: "WAITFOR_FAIL",
+	Usage:  "wait for condition to fail",
 }
 
 var dataFlag = cli.StringSliceFlag{
-	Name:  "data, d",
-	Value: &cli.StringSlice{},
-	Usage: "HTTP POST data",
+	Name:   "data, d",
+	EnvVar: "WAITFOR_DATA",
+	Value:  &cli.StringSlice{},
+	Usage:  "HTTP POST data",
 }
 
 var headerFlag = cli.StringSliceFlag{
-	Name:  "header, H",
-	Value: &cli.StringSlice{},
-	Usage: "custom header",
+	Name:   "header, H",
+	EnvVar: "WAITFOR_HEADER",
+	Value:  &cli.StringSlice{},
+	Usage:  "custom header",
 }
 
 var userFlag = cli.StringFlag{
-	Name:  "user, u",
-	Value: "",
-	Usage: "username and password separated by colon, e.g. 'username:password'",
+	Name:   "user, u",
+	EnvVar: "WAITFOR_USER",
+	Value:  "",
+	Usage:  "username and password separated by colon, e.g. 'username:password'",
 }
 
 var methodFlag = cli.StringFlag{
-	Name:  "request, X",
-	Value: "GET",
-	Usage: "method for curl request",
+	Name:   "request, X",
+	EnvVar: "WAITFOR_REQUEST",
+	Value:  "GET",
+	Usage:  "method for curl request",
 }
 
 var httpStatusFlag = cli.StringFlag{
-	Name:  "status, s",
-	Value: "200",
-	Usage: "match HTTP status code for curl request",
+	Name:   "status, s",
+	EnvVar: "WAITFOR_HTTP_STATUS",
+	Value:  "200",
+	Usage:  "match HTTP status code for curl request",
 }
 
 var exitCodeFlag = cli.StringFlag{
-	Name:  "status, rc",
-	Value: "0",
-	Usage: "match exit code",
+	Name:   "status, rc",
+	EnvVar: "WAITFOR_EXIT_CODE",
+	Value:  "0",
+	Usage:  "match exit code",
 }
 
 var matchFlag = cli.StringFlag{
-	Name:  "match, m",
-	Value: "",
-	Usage: "match regex",
+	Name:   "match, m",
+	EnvVar: "WAITFOR_MATCH",
+	Value:  "",
+	Usage:  "match regex",
+}
+
+var strictFlag = cli.BoolTFlag{
+	Name:   "strict",
+	EnvVar: "WAITFOR_STRICT",
+	Usage:  "only run the command following '--' if the condition has been met",
+}
+
+var fileFlag = cli.StringFlag{
+	Name:   "file, f",
+	EnvVar: "WAITFOR_FILE",
+	Value:  "waitfor.yaml",
+	Usage:  "YAML or JSON file describing the checks to wait for",
+}
+
+var listenFlag = cli.StringFlag{
+	Name:   "listen, l",
+	EnvVar: "WAITFOR_LISTEN",
+	Value:  ":8081",
+	Usage:  "address to serve the status of checks on",
+}
+
+var metricsFlag = cli.StringFlag{
+	Name:   "metrics",
+	EnvVar: "WAITFOR_METRICS",
+	Value:  "",
+	Usage:  "address to expose Prometheus metrics on while waiting, e.g. ':9090'",
+}
+
+var traceFlag = cli.StringFlag{
+	Name:   "trace",
+	EnvVar: "WAITFOR_TRACE",
+	Value:  "",
+	Usage:  "export a trace of the wait to 'stdout' or 'otlp', the latter is configured via the standard OTEL_EXPORTER_OTLP_* environment variables",
+}
+
+var quietFlag = cli.BoolFlag{
+	Name:   "quiet, q",
+	EnvVar: "WAITFOR_QUIET",
+	Usage:  "only print the final result",
+}
+
+var shellFlag = cli.BoolFlag{
+	Name:   "shell, c",
+	EnvVar: "WAITFOR_SHELL",
+	Usage:  "run the command as a single string through the interpreter, e.g. 'pg_isready -h db && psql -c \"select 1\"'",
+}
+
+var interpreterFlag = cli.StringFlag{
+	Name:   "interpreter",
+	EnvVar: "WAITFOR_INTERPRETER",
+	Value:  "/bin/sh",
+	Usage:  "shell used with --shell, e.g. \"$SHELL\", it is invoked with '-c' and the command",
+}
+
+var workdirFlag = cli.StringFlag{
+	Name:   "workdir, w",
+	EnvVar: "WAITFOR_WORKDIR",
+	Value:  "",
+	Usage:  "working directory of the command",
+}
+
+var envFlag = cli.StringSliceFlag{
+	Name:   "env, e",
+	EnvVar: "WAITFOR_ENV",
+	Value:  &cli.StringSlice{},
+	Usage:  "environment variable of the command, e.g. 'KEY=VAL', added to the environment of waitfor",
+}
+
+var timeoutPerRunFlag = cli.DurationFlag{
+	Name:   "timeout-per-run",
+	EnvVar: "WAITFOR_TIMEOUT_PER_RUN",
+	Value:  0,
+	Usage:  "maximum time a single run of the command may take, no limit if 0",
+}
+
+var shellFlags = []cli.Flag{
+	shellFlag,
+	interpreterFlag,
+	workdirFlag,
+	envFlag,
+	timeoutPerRunFlag,
+}
+
+var connectTimeoutFlag = cli.DurationFlag{
+	Name:   "connect-timeout",
+	EnvVar: "WAITFOR_CONNECT_TIMEOUT",
+	Value:  5 * time.Second,
+	Usage:  "maximum time a single attempt to connect and talk to the server may take",
+}
+
+var queryFlag = cli.StringFlag{
+	Name:   "query",
+	EnvVar: "WAITFOR_QUERY",
+	Value:  "",
+	Usage:  "query that must succeed once connected, e.g. 'SELECT 1'",
+}
+
+var primaryFlag = cli.BoolFlag{
+	Name:   "primary",
+	EnvVar: "WAITFOR_PRIMARY",
+	Usage:  "wait for the server to not be in recovery, i.e. to be neither a standby nor replaying WAL",
+}
+
+var pingFlag = cli.BoolFlag{
+	Name:   "ping",
+	EnvVar: "WAITFOR_PING",
+	Usage:  "send a ping once logged in",
+}
+
+var usernameFlag = cli.StringFlag{
+	Name:   "username",
+	EnvVar: "WAITFOR_USERNAME",
+	Value:  "",
+	Usage:  "user to authenticate as",
+}
+
+var passwordFlag = cli.StringFlag{
+	Name:   "password",
+	EnvVar: "WAITFOR_PASSWORD",
+	Value:  "",
+	Usage:  "password to authenticate with",
+}
+
+var tlsFlag = cli.BoolFlag{
+	Name:   "tls",
+	EnvVar: "WAITFOR_TLS",
+	Usage:  "connect using TLS",
+}
+
+var insecureFlag = cli.BoolFlag{
+	Name:   "insecure, k",
+	EnvVar: "WAITFOR_INSECURE",
+	Usage:  "connect using TLS without verifying the certificate of the server",
+}
+
+var infoFlag = cli.StringSliceFlag{
+	Name:   "info",
+	EnvVar: "WAITFOR_INFO",
+	Value:  &cli.StringSlice{},
+	Usage:  "field returned by INFO that must have the given value, e.g. 'role:master'",
+}
+
+var serviceFlag = cli.StringFlag{
+	Name:   "service",
+	EnvVar: "WAITFOR_SERVICE",
+	Value:  "",
+	Usage:  "name of the service to check, empty for the overall health of the server",
+}
+
+var watchFlag = cli.BoolFlag{
+	Name:   "watch",
+	EnvVar: "WAITFOR_WATCH",
+	Usage:  "watch the status of the service instead of polling it",
+}
+
+var queueFlag = cli.StringSliceFlag{
+	Name:   "queue",
+	EnvVar: "WAITFOR_QUEUE",
+	Value:  &cli.StringSlice{},
+	Usage:  "queue that must exist",
+}
+
+var exchangeFlag = cli.StringSliceFlag{
+	Name:   "exchange",
+	EnvVar: "WAITFOR_EXCHANGE",
+	Value:  &cli.StringSlice{},
+	Usage:  "exchange that must exist",
+}
+
+var topicFlag = cli.StringSliceFlag{
+	Name:   "topic",
+	EnvVar: "WAITFOR_TOPIC",
+	Value:  &cli.StringSlice{},
+	Usage:  "topic that must exist and whose partitions must all have a leader",
+}
+
+var partitionsFlag = cli.IntFlag{
+	Name:   "partitions",
+	EnvVar: "WAITFOR_PARTITIONS",
+	Value:  0,
+	Usage:  "number of partitions each topic must have",
+}
+
+var writablePrimaryFlag = cli.BoolFlag{
+	Name:   "writable-primary",
+	EnvVar: "WAITFOR_WRITABLE_PRIMARY",
+	Usage:  "wait for the node to be the writable primary, which a standalone server always is",
+}
+
+var hasPrimaryFlag = cli.BoolFlag{
+	Name:   "has-primary",
+	EnvVar: "WAITFOR_HAS_PRIMARY",
+	Usage:  "wait for the replica set of the node to have elected a primary",
+}
+
+var protocolFlag = cli.StringFlag{
+	Name:   "protocol",
+	EnvVar: "WAITFOR_PROTOCOL",
+	Value:  "",
+	Usage:  "wait for the server to greet its clients according to the given protocol, one of smtp, ssh, ftp",
+}
+
+var fingerprintFlag = cli.StringFlag{
+	Name:   "fingerprint",
+	EnvVar: "WAITFOR_FINGERPRINT",
+	Value:  "",
+	Usage:  "fingerprint of the host key an SSH server must present, e.g. 'SHA256:...'",
+}
+
+var messageFlag = cli.StringFlag{
+	Name:   "message",
+	EnvVar: "WAITFOR_MESSAGE",
+	Value:  "",
+	Usage:  "text message sent once connected, e.g. to be answered with a message matching --match",
 }
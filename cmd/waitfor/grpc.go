@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/codegangsta/cli"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+)
+
+var grpcHealthCheckProvider = check.GRPCHealth
+
+var grpcCommand = cli.Command{
+	Name:  "grpc",
+	Usage: "wait for a gRPC server to report a service as serving using the standard health checking protocol",
+
+	ArgsUsage: "<addr>...",
+
+	HideHelp: true,
+
+	Flags: []cli.Flag{
+		serviceFlag,
+		watchFlag,
+		tlsFlag,
+		insecureFlag,
+		anyFlag,
+		connectTimeoutFlag,
+		timeoutFlag,
+		intervalFlag,
+		verboseFlag,
+		strictFlag,
+		traceFlag,
+		quietFlag,
+	},
+
+	Action: func(c *cli.Context) error {
+		names, conditions := grpcConditions(c)
+		return waitFor(c, names, conditions, "servers", "serving")
+	},
+}
+
+// grpcConditions returns the addresses given as positional arguments and the
+// corresponding conditions.
+func grpcConditions(c *cli.Context) ([]string, []waitfor.Check) {
+	if !positional(c).Present() {
+		cli.ShowCommandHelp(c, "grpc")
+		fmt.Fprintln(c.App.Writer, "must specify address")
+		exitWith(c, exitUsage)
+	}
+
+	var (
+		names      []string
+		conditions []waitfor.Check
+	)
+
+	for _, addr := range positional(c) {
+		names = append(names, addr)
+		conditions = append(conditions, grpcCondition(c, addr, c.String("service"), false))
+	}
+
+	return names, conditions
+}
+
+// grpcCondition checks the health of the given service. If secure is set TLS
+// is used regardless of the --tls flag.
+func grpcCondition(c *cli.Context, addr, service string, secure bool) waitfor.Check {
+	target := currentWait(c).Target("grpc", addr)
+	grpc := grpcHealthCheckProvider(addr, service).WithLogger(targetLogger(c, target))
+
+	if timeout := c.Duration("connect-timeout"); timeout > 0 {
+		grpc.WithTimeout(timeout)
+	}
+
+	config := tlsConfig(c)
+	if config == nil && secure {
+		config = &tls.Config{}
+	}
+
+	if config != nil {
+		grpc.WithTLS(config)
+	}
+
+	if c.Bool("watch") {
+		grpc.WithWatch()
+	}
+
+	return target.Check(grpc.IsServing)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+	"github.com/st3v/waitfor/cmd/waitfor/fake"
+)
+
+var _ = Describe("grpc command", func() {
+	var (
+		grpccheck   *fake.GRPCHealthCheck
+		command     string
+		args        []string
+		expectedErr error
+
+		actualAddrs    []string
+		actualServices []string
+		actualChecks   []waitfor.Check
+		actualTimeout  time.Duration
+		actualOutput   *gbytes.Buffer
+		actualErr      error
+	)
+
+	BeforeEach(func() {
+		grpccheck = new(fake.GRPCHealthCheck)
+		grpccheck.WithTLSReturns(grpccheck)
+		grpccheck.WithWatchReturns(grpccheck)
+		grpccheck.WithTimeoutReturns(grpccheck)
+		grpccheck.WithLoggerReturns(grpccheck)
+		grpccheck.IsServingReturns(true)
+		grpcHealthCheckProvider = func(addr, service string) check.GRPCHealthCheck {
+			actualAddrs = append(actualAddrs, addr)
+			actualServices = append(actualServices, service)
+			return grpccheck
+		}
+
+		command = "grpc"
+		args = []string{"api:50051"}
+		expectedErr = nil
+		actualAddrs = nil
+		actualServices = nil
+		actualChecks = nil
+		actualOutput = gbytes.NewBuffer()
+
+		waitForConditionWithTimeout = func(check waitfor.Check, interval, timeout time.Duration, ctx context.Context) error {
+			check()
+			actualTimeout = timeout
+			return expectedErr
+		}
+
+		waitForAllWithTimeout = func(checks []waitfor.Check, interval, timeout time.Duration, ctx context.Context) []error {
+			actualChecks = checks
+			for _, check := range checks {
+				check()
+			}
+			return make([]error, len(checks))
+		}
+	})
+
+	JustBeforeEach(func() {
+		app := app()
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		actualErr = app.Run(append([]string{"waitfor", command}, args...))
+	})
+
+	It("waits for the server to be serving", func() {
+		Expect(actualAddrs).To(Equal([]string{"api:50051"}))
+		Expect(actualServices).To(Equal([]string{""}))
+		Expect(grpccheck.IsServingCallCount()).To(Equal(1))
+		Expect(grpccheck.WithTLSCallCount()).To(Equal(0))
+		Expect(grpccheck.WithWatchCallCount()).To(Equal(0))
+		Expect(grpccheck.WithTimeoutArgsForCall(0)).To(Equal(5 * time.Second))
+		Expect(actualOutput).To(gbytes.Say("Success: api:50051 is serving"))
+		Expect(actualErr).ToNot(HaveOccurred())
+	})
+
+	Context("when the check fails", func() {
+		BeforeEach(func() {
+			expectedErr = errors.New("some-error")
+		})
+
+		It("returns an error", func() {
+			Expect(actualErr).To(HaveOccurred())
+			Expect(actualOutput).To(gbytes.Say("Error waiting for api:50051 to be serving: some-error"))
+		})
+	})
+
+	Describe("flags", func() {
+		BeforeEach(func() {
+			args = append(args, "--service", "app.Orders", "--watch", "--tls", "--connect-timeout", "2s", "-t", "1m")
+		})
+
+		It("are being used", func() {
+			Expect(actualServices).To(Equal([]string{"app.Orders"}))
+			Expect(grpccheck.WithWatchCallCount()).To(Equal(1))
+			Expect(grpccheck.WithTLSArgsForCall(0)).To(Equal(&tls.Config{}))
+			Expect(grpccheck.WithTimeoutArgsForCall(0)).To(Equal(2 * time.Second))
+			Expect(actualTimeout).To(Equal(time.Minute))
+		})
+	})
+
+	Context("when multiple addresses have been specified", func() {
+		BeforeEach(func() {
+			args = []string{"orders:50051", "billing:50051", "--insecure"}
+		})
+
+		It("waits for all of them", func() {
+			Expect(actualAddrs).To(Equal([]string{"orders:50051", "billing:50051"}))
+			Expect(actualChecks).To(HaveLen(2))
+			Expect(grpccheck.WithTLSArgsForCall(0)).To(Equal(&tls.Config{InsecureSkipVerify: true}))
+			Expect(actualOutput).To(gbytes.Say("orders:50051: serving"))
+			Expect(actualOutput).To(gbytes.Say("billing:50051: serving"))
+		})
+	})
+
+	Context("when used as target of the on command", func() {
+		BeforeEach(func() {
+			command = "on"
+			args = []string{"grpcs://api:50051/app.Orders", "--watch"}
+		})
+
+		It("takes the service from the path and uses TLS", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+			Expect(actualAddrs).To(Equal([]string{"api:50051"}))
+			Expect(actualServices).To(Equal([]string{"app.Orders"}))
+			Expect(grpccheck.WithTLSArgsForCall(0)).To(Equal(&tls.Config{}))
+			Expect(grpccheck.WithWatchCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when used in a config file", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "grpc")
+			Expect(err).ToNot(HaveOccurred())
+
+			config := `
+checks:
+- name: api
+  kind: grpc
+  host: api
+  port: 50051
+  service: app.Orders
+  watch: true
+`
+			Expect(ioutil.WriteFile(filepath.Join(dir, "waitfor.yaml"), []byte(config), 0644)).To(Succeed())
+
+			command = "run"
+			args = []string{"-f", filepath.Join(dir, "waitfor.yaml")}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("waits for the service to be serving", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+			Expect(actualAddrs).To(ContainElement("api:50051"))
+			Expect(actualServices).To(ContainElement("app.Orders"))
+			Expect(grpccheck.WithWatchCallCount()).To(BeNumerically(">", 0))
+		})
+	})
+})
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codegangsta/cli"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+)
+
+var kafkaCheckProvider = check.Kafka
+
+var kafkaCommand = cli.Command{
+	Name:  "kafka",
+	Usage: "wait for a Kafka cluster to have elected a controller, and optionally for topics to be ready",
+
+	ArgsUsage: "<broker>...",
+
+	HideHelp: true,
+
+	Flags: []cli.Flag{
+		topicFlag,
+		partitionsFlag,
+		connectTimeoutFlag,
+		timeoutFlag,
+		intervalFlag,
+		verboseFlag,
+		strictFlag,
+		traceFlag,
+		quietFlag,
+	},
+
+	Action: func(c *cli.Context) error {
+		names, conditions := kafkaConditions(c)
+		return waitFor(c, names, conditions, "clusters", "ready")
+	},
+}
+
+// kafkaConditions returns a single condition for the cluster reachable via
+// the brokers given as positional arguments.
+func kafkaConditions(c *cli.Context) ([]string, []waitfor.Check) {
+	if !positional(c).Present() {
+		cli.ShowCommandHelp(c, "kafka")
+		fmt.Fprintln(c.App.Writer, "must specify broker")
+		exitWith(c, exitUsage)
+	}
+
+	brokers := positional(c)
+	return []string{strings.Join(brokers, ",")}, []waitfor.Check{kafkaCondition(c, brokers, c.StringSlice("topic"))}
+}
+
+func kafkaCondition(c *cli.Context, brokers, topics []string) waitfor.Check {
+	target := currentWait(c).Target("kafka", strings.Join(brokers, ","))
+	kafka := kafkaCheckProvider(brokers...).WithLogger(targetLogger(c, target))
+
+	if timeout := c.Duration("connect-timeout"); timeout > 0 {
+		kafka.WithTimeout(timeout)
+	}
+
+	for _, topic := range topics {
+		kafka.WithTopic(topic)
+	}
+
+	if partitions := c.Int("partitions"); partitions > 0 {
+		kafka.WithPartitions(partitions)
+	}
+
+	return target.Check(kafka.IsReady)
+}
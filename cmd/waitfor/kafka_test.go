@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+	"github.com/st3v/waitfor/cmd/waitfor/fake"
+)
+
+var _ = Describe("kafka command", func() {
+	var (
+		kafkacheck  *fake.KafkaCheck
+		command     string
+		args        []string
+		expectedErr error
+
+		actualBrokers [][]string
+		actualTimeout time.Duration
+		actualOutput  *gbytes.Buffer
+		actualErr     error
+	)
+
+	BeforeEach(func() {
+		kafkacheck = new(fake.KafkaCheck)
+		kafkacheck.WithTopicReturns(kafkacheck)
+		kafkacheck.WithPartitionsReturns(kafkacheck)
+		kafkacheck.WithTimeoutReturns(kafkacheck)
+		kafkacheck.WithLoggerReturns(kafkacheck)
+		kafkacheck.IsReadyReturns(true)
+		kafkaCheckProvider = func(brokers ...string) check.KafkaCheck {
+			actualBrokers = append(actualBrokers, brokers)
+			return kafkacheck
+		}
+
+		command = "kafka"
+		args = []string{"kafka1:9092", "kafka2:9092"}
+		expectedErr = nil
+		actualBrokers = nil
+		actualOutput = gbytes.NewBuffer()
+
+		waitForConditionWithTimeout = func(check waitfor.Check, interval, timeout time.Duration, ctx context.Context) error {
+			check()
+			actualTimeout = timeout
+			return expectedErr
+		}
+	})
+
+	JustBeforeEach(func() {
+		app := app()
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		actualErr = app.Run(append([]string{"waitfor", command}, args...))
+	})
+
+	It("waits for the cluster reachable via the given brokers", func() {
+		Expect(actualBrokers).To(Equal([][]string{{"kafka1:9092", "kafka2:9092"}}))
+		Expect(kafkacheck.IsReadyCallCount()).To(Equal(1))
+		Expect(kafkacheck.WithTopicCallCount()).To(Equal(0))
+		Expect(kafkacheck.WithPartitionsCallCount()).To(Equal(0))
+		Expect(kafkacheck.WithTimeoutArgsForCall(0)).To(Equal(5 * time.Second))
+		Expect(actualOutput).To(gbytes.Say("Success: kafka1:9092,kafka2:9092 is ready"))
+		Expect(actualErr).ToNot(HaveOccurred())
+	})
+
+	Context("when the check fails", func() {
+		BeforeEach(func() {
+			expectedErr = errors.New("some-error")
+		})
+
+		It("returns an error", func() {
+			Expect(actualErr).To(HaveOccurred())
+			Expect(actualOutput).To(gbytes.Say("Error waiting for kafka1:9092,kafka2:9092 to be ready: some-error"))
+		})
+	})
+
+	Describe("flags", func() {
+		BeforeEach(func() {
+			args = append(args, "--topic", "orders", "--topic", "billing", "--partitions", "3", "--connect-timeout", "2s", "-t", "1m")
+		})
+
+		It("are being used", func() {
+			Expect(kafkacheck.WithTopicCallCount()).To(Equal(2))
+			Expect(kafkacheck.WithTopicArgsForCall(0)).To(Equal("orders"))
+			Expect(kafkacheck.WithTopicArgsForCall(1)).To(Equal("billing"))
+			Expect(kafkacheck.WithPartitionsArgsForCall(0)).To(Equal(3))
+			Expect(kafkacheck.WithTimeoutArgsForCall(0)).To(Equal(2 * time.Second))
+			Expect(actualTimeout).To(Equal(time.Minute))
+		})
+	})
+
+	Context("when used as target of the on command", func() {
+		BeforeEach(func() {
+			command = "on"
+			args = []string{"kafka://kafka1:9092,kafka2:9092/orders", "--partitions", "6"}
+		})
+
+		It("takes the brokers from the host and the topic from the path", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+			Expect(actualBrokers).To(Equal([][]string{{"kafka1:9092", "kafka2:9092"}}))
+			Expect(kafkacheck.WithTopicArgsForCall(0)).To(Equal("orders"))
+			Expect(kafkacheck.WithPartitionsArgsForCall(0)).To(Equal(6))
+		})
+	})
+
+	Context("when used in a config file", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "kafka")
+			Expect(err).ToNot(HaveOccurred())
+
+			config := `
+checks:
+- name: kafka
+  kind: kafka
+  brokers: [kafka1:9092, kafka2:9092]
+  topics: [orders]
+  partitions: 3
+`
+			Expect(ioutil.WriteFile(filepath.Join(dir, "waitfor.yaml"), []byte(config), 0644)).To(Succeed())
+
+			command = "run"
+			args = []string{"-f", filepath.Join(dir, "waitfor.yaml")}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("waits for the cluster to be ready", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+			Expect(actualBrokers).To(ContainElement([]string{"kafka1:9092", "kafka2:9092"}))
+			Expect(kafkacheck.WithTopicArgsForCall(0)).To(Equal("orders"))
+			Expect(kafkacheck.WithPartitionsArgsForCall(0)).To(Equal(3))
+		})
+	})
+})
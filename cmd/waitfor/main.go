@@ -1,26 +1,58 @@
 package main
 
 import (
+	"crypto/tls"
+	"fmt"
+	"io"
 	"os"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/codegangsta/cli"
+	"golang.org/x/net/context"
 
 	"github.com/st3v/waitfor"
 )
 
 var (
-	waitForConditionWithTimeout = waitfor.ConditionWithTimeout
+	waitForConditionWithTimeout = conditionWithTimeout
+	waitForAllWithTimeout       = allWithTimeout
+	waitForAnyWithTimeout       = anyWithTimeout
 	exit                        = os.Exit
 )
 
+func conditionWithTimeout(condition waitfor.Check, interval, timeout time.Duration, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return waitfor.ConditionWithContext(condition, interval, ctx)
+}
+
+func allWithTimeout(conditions []waitfor.Check, interval, timeout time.Duration, ctx context.Context) []error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return waitfor.AllWithContext(conditions, interval, ctx)
+}
+
+func anyWithTimeout(conditions []waitfor.Check, interval, timeout time.Duration, ctx context.Context) []error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return waitfor.AnyWithContext(conditions, interval, ctx)
+}
+
 func app() *cli.App {
 	app := cli.NewApp()
 
 	app.Name = "waitfor"
 	app.Usage = "Waits for a given condition before returning"
+	app.Description = "Every flag can also be set via the environment variable shown next to it. Positional arguments, e.g. ports or URLs, can be set via $WAITFOR_ARGS."
 	app.HideVersion = true
 
 	app.Action = shellAction
+	app.OnUsageError = onUsageError
 
 	app.Flags = []cli.Flag{
 		matchFlag,
@@ -29,17 +61,152 @@ func app() *cli.App {
 		intervalFlag,
 		verboseFlag,
 		failFlag,
+		strictFlag,
+		traceFlag,
+		quietFlag,
 	}
+	app.Flags = append(app.Flags, shellFlags...)
 
 	app.Commands = []cli.Command{
 		shellCommand,
 		portCommand,
 		curlCommand,
+		onCommand,
+		runCommand,
+		serveCommand,
+		postgresCommand,
+		mysqlCommand,
+		redisCommand,
+		grpcCommand,
+		amqpCommand,
+		kafkaCommand,
+		mongoCommand,
+		probeCommand,
+		webSocketCommand,
+		allCommand,
+		anyCommand,
 	}
 
+	for i := range app.Commands {
+		app.Commands[i].OnUsageError = onUsageError
+	}
+
+	trackActions(app)
+	traceActions(app)
+	handleSignals(app)
+
 	return app
 }
 
+// positional returns the positional arguments of a command. If none have been
+// given, they are read from the WAITFOR_ARGS environment variable instead,
+// e.g. WAITFOR_ARGS="5432 5433".
+func positional(c *cli.Context) cli.Args {
+	if c.Args().Present() {
+		return c.Args()
+	}
+	return cli.Args(strings.Fields(os.Getenv("WAITFOR_ARGS")))
+}
+
+func logger(c *cli.Context) io.Writer {
+	if verbose(c) {
+		return c.App.Writer
+	}
+	return currentProgress(c)
+}
+
+// matchRegexp compiles the regex given by the --match flag. An invalid regex
+// is a usage error.
+func matchRegexp(c *cli.Context, match string) *regexp.Regexp {
+	r, err := regexp.Compile(match)
+	if err != nil {
+		fmt.Fprintf(c.App.Writer, "invalid match: %s\n", err)
+		exitWith(c, exitUsage)
+	}
+	return r
+}
+
+// tlsConfig returns the TLS configuration requested by the --tls and
+// --insecure flags, or nil to connect using plaintext.
+func tlsConfig(c *cli.Context) *tls.Config {
+	if !c.Bool("tls") && !c.Bool("insecure") {
+		return nil
+	}
+	return &tls.Config{InsecureSkipVerify: c.Bool("insecure")}
+}
+
+// waitFor waits for a single condition, or concurrently for all of multiple
+// conditions, and reports the result.
+func waitFor(c *cli.Context, names []string, conditions []waitfor.Check, noun, state string) error {
+	if len(conditions) > 1 {
+		return waitForConditions(c, names, conditions, noun, state)
+	}
+
+	timeout := c.Duration("timeout")
+	interval := c.Duration("interval")
+
+	fmt.Fprintf(info(c), "Waiting for %s to be %s...\n", names[0], state)
+
+	conditions, stop := track(c, timeout, conditions[0])
+	err := waitForConditionWithTimeout(conditions[0], interval, timeout, waitContext(c))
+	stop()
+
+	if err != nil {
+		fmt.Fprintf(c.App.Writer, "Error waiting for %s to be %s: %s\n", names[0], state, err)
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "Success: %s is %s\n", names[0], state)
+	return nil
+}
+
+// waitForConditions concurrently waits for the given conditions and reports
+// the status of each of them. Unless the --any flag has been set all
+// conditions must be met.
+func waitForConditions(c *cli.Context, names []string, conditions []waitfor.Check, noun, state string) error {
+	timeout := c.Duration("timeout")
+	interval := c.Duration("interval")
+
+	wait := waitForAllWithTimeout
+	quantifier := "all"
+	if waitForAny(c) {
+		wait = waitForAnyWithTimeout
+		quantifier = "any"
+	}
+
+	fmt.Fprintf(info(c), "Waiting for %s of %d %s to be %s...\n", quantifier, len(names), noun, state)
+
+	conditions, stop := track(c, timeout, conditions...)
+	errs := wait(conditions, interval, timeout, waitContext(c))
+	stop()
+
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			failed++
+			fmt.Fprintf(info(c), "  %s: %s\n", names[i], err)
+			continue
+		}
+		fmt.Fprintf(info(c), "  %s: %s\n", names[i], state)
+	}
+
+	if failed == len(errs) || (failed > 0 && !waitForAny(c)) {
+		err := fmt.Errorf("%d of %d %s not %s", failed, len(errs), noun, state)
+		fmt.Fprintf(c.App.Writer, "Error waiting for %s %s: %s\n", state, noun, err)
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "Success: %d of %d %s %s\n", len(errs)-failed, len(errs), noun, state)
+	return nil
+}
+
 func main() {
-	app().Run(os.Args)
+	app := app()
+
+	args, command := splitCommand(app, os.Args)
+	execAfter(app, command)
+
+	if err := app.Run(args); err != nil {
+		exit(exitCode(err))
+	}
 }
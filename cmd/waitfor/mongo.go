@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/codegangsta/cli"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+)
+
+var mongoCheckProvider = check.Mongo
+
+var mongoCommand = cli.Command{
+	Name:    "mongo",
+	Aliases: []string{"mongodb"},
+	Usage:   "wait for a MongoDB server to answer, and optionally to be or know the primary of its replica set",
+
+	ArgsUsage: "<uri>...",
+
+	HideHelp: true,
+
+	Flags: []cli.Flag{
+		writablePrimaryFlag,
+		hasPrimaryFlag,
+		tlsFlag,
+		insecureFlag,
+		anyFlag,
+		connectTimeoutFlag,
+		timeoutFlag,
+		intervalFlag,
+		verboseFlag,
+		strictFlag,
+		traceFlag,
+		quietFlag,
+	},
+
+	Action: func(c *cli.Context) error {
+		state := "ready"
+		switch {
+		case c.Bool("writable-primary"):
+			state = "writable primary"
+		case c.Bool("has-primary"):
+			state = "ready with a primary"
+		}
+
+		names, conditions := mongoConditions(c)
+		return waitFor(c, names, conditions, "servers", state)
+	},
+}
+
+// mongoConditions returns the URIs given as positional arguments, without
+// passwords, and the corresponding conditions.
+func mongoConditions(c *cli.Context) ([]string, []waitfor.Check) {
+	if !positional(c).Present() {
+		cli.ShowCommandHelp(c, "mongo")
+		fmt.Fprintln(c.App.Writer, "must specify URI")
+		exitWith(c, exitUsage)
+	}
+
+	var (
+		names      []string
+		conditions []waitfor.Check
+	)
+
+	for _, uri := range positional(c) {
+		names = append(names, redactDSN(uri))
+		conditions = append(conditions, mongoCondition(c, uri))
+	}
+
+	return names, conditions
+}
+
+func mongoCondition(c *cli.Context, uri string) waitfor.Check {
+	target := currentWait(c).Target("mongo", redactDSN(uri))
+	mongo := mongoCheckProvider(uri).WithLogger(targetLogger(c, target))
+
+	if timeout := c.Duration("connect-timeout"); timeout > 0 {
+		mongo.WithTimeout(timeout)
+	}
+
+	if config := tlsConfig(c); config != nil {
+		mongo.WithTLS(config)
+	}
+
+	switch {
+	case c.Bool("writable-primary"):
+		return target.Check(mongo.IsWritablePrimary)
+	case c.Bool("has-primary"):
+		return target.Check(mongo.HasPrimary)
+	}
+
+	return target.Check(mongo.IsReady)
+}
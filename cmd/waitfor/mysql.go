@@ -0,0 +1,73 @@
+package main
+
+import (
+	"github.com/codegangsta/cli"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+)
+
+var mysqlCheckProvider = check.MySQL
+
+var mysqlCommand = cli.Command{
+	Name:    "mysql",
+	Aliases: []string{"mariadb"},
+	Usage:   "wait for a MySQL or MariaDB server to accept logins",
+
+	ArgsUsage: "<dsn>...",
+
+	HideHelp: true,
+
+	Flags: []cli.Flag{
+		pingFlag,
+		queryFlag,
+		anyFlag,
+		connectTimeoutFlag,
+		timeoutFlag,
+		intervalFlag,
+		verboseFlag,
+		strictFlag,
+		traceFlag,
+		quietFlag,
+	},
+
+	Action: func(c *cli.Context) error {
+		names, conditions := mysqlConditions(c)
+		return waitFor(c, names, conditions, "servers", "ready")
+	},
+}
+
+// mysqlConditions returns the DSNs given as positional arguments, without
+// passwords, and the corresponding conditions.
+func mysqlConditions(c *cli.Context) ([]string, []waitfor.Check) {
+	var (
+		names      []string
+		conditions []waitfor.Check
+	)
+
+	for _, dsn := range dsns(c, "mysql") {
+		names = append(names, redactDSN(dsn))
+		conditions = append(conditions, mysqlCondition(c, dsn))
+	}
+
+	return names, conditions
+}
+
+func mysqlCondition(c *cli.Context, dsn string) waitfor.Check {
+	target := currentWait(c).Target("mysql", redactDSN(dsn))
+	mysql := mysqlCheckProvider(dsn).WithLogger(targetLogger(c, target))
+
+	if timeout := c.Duration("connect-timeout"); timeout > 0 {
+		mysql.WithTimeout(timeout)
+	}
+
+	if c.Bool("ping") {
+		mysql.WithPing()
+	}
+
+	if query := c.String("query"); query != "" {
+		mysql.WithQuery(query)
+	}
+
+	return target.Check(mysql.IsReady)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	neturl "net/url"
+	"strings"
+
+	"github.com/codegangsta/cli"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+)
+
+var (
+	fileCheckProvider   = check.File
+	socketCheckProvider = check.Socket
+)
+
+var targets = func(c *cli.Context) ([]string, []waitfor.Check) {
+	if !positional(c).Present() {
+		cli.ShowCommandHelp(c, "on")
+		fmt.Fprintln(c.App.Writer, "must specify target")
+		exitWith(c, exitUsage)
+	}
+
+	var (
+		names      []string
+		conditions []waitfor.Check
+	)
+
+	for _, arg := range positional(c) {
+		name, condition, err := target(c, arg)
+		if err != nil {
+			fmt.Fprintf(c.App.Writer, "invalid target '%s': %s\n", arg, err)
+			exitWith(c, exitUsage)
+		}
+
+		names = append(names, name)
+		conditions = append(conditions, condition)
+	}
+
+	return names, conditions
+}
+
+// target turns a URL into the corresponding check. Targets without a scheme,
+// e.g. 'db:5432', are treated as TCP endpoints.
+func target(c *cli.Context, arg string) (string, waitfor.Check, error) {
+	if !strings.Contains(arg, "://") {
+		arg = "tcp://" + arg
+	}
+
+	u, err := neturl.Parse(arg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch u.Scheme {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+		host, portStr, err := net.SplitHostPort(u.Host)
+		if err != nil {
+			return "", nil, err
+		}
+
+		port, err := parsePort(portStr)
+		if err != nil {
+			return "", nil, err
+		}
+
+		return arg, portCondition(c, u.Scheme, host, port), nil
+	case "smtp", "ssh", "ftp":
+		return arg, bannerCondition(c, u.Scheme, u.Host), nil
+	case "http", "https":
+		return arg, curlCondition(c, arg), nil
+	case "ws", "wss":
+		return arg, webSocketCondition(c, arg), nil
+	case "file":
+		target := currentWait(c).Target("file", arg)
+		condition := fileCheckProvider(u.Host + u.Path).WithLogger(targetLogger(c, target)).Exists
+		return arg, target.Check(condition), nil
+	case "postgres", "postgresql":
+		return redactDSN(arg), postgresCondition(c, arg), nil
+	case "mysql", "mariadb":
+		return redactDSN(arg), mysqlCondition(c, arg), nil
+	case "redis", "rediss":
+		return redactDSN(arg), redisCondition(c, arg), nil
+	case "amqp", "amqps":
+		return redactDSN(arg), amqpCondition(c, arg), nil
+	case "kafka":
+		var topics []string
+		if topic := strings.TrimPrefix(u.Path, "/"); topic != "" {
+			topics = []string{topic}
+		}
+		return arg, kafkaCondition(c, strings.Split(u.Host, ","), topics), nil
+	case "mongodb":
+		return redactDSN(arg), mongoCondition(c, arg), nil
+	case "grpc", "grpcs":
+		return arg, grpcCondition(c, u.Host, strings.TrimPrefix(u.Path, "/"), u.Scheme == "grpcs"), nil
+	case "unix":
+		target := currentWait(c).Target("socket", arg)
+		condition := socketCheckProvider(u.Host + u.Path).WithLogger(targetLogger(c, target)).IsOpen
+		return arg, target.Check(condition), nil
+	}
+
+	if isProbe(u.Scheme) {
+		return arg, probeCondition(c, u.Scheme, u.Host), nil
+	}
+
+	return "", nil, fmt.Errorf("unsupported scheme '%s'", u.Scheme)
+}
+
+var onCommand = cli.Command{
+	Name:  "on",
+	Usage: "wait for targets given as URLs, e.g. tcp://db:5432, smtp://mail, http://api/health, ws://gateway/realtime, postgres://db/app, mysql://db/app, redis://cache, grpc://api:50051/app.Orders, amqp://broker/vhost, kafka://broker:9092/topic, mongodb://db1,db2/?replicaSet=rs0, zookeeper://zk:2181, file:///run/ready, unix:///var/run/app.sock",
+
+	ArgsUsage: "<url>...",
+
+	HideHelp: true,
+
+	Flags: []cli.Flag{
+		anyFlag,
+		payloadFlag,
+		replyTimeoutFlag,
+		noReplyOKFlag,
+		fingerprintFlag,
+		httpStatusFlag,
+		matchFlag,
+		methodFlag,
+		userFlag,
+		dataFlag,
+		headerFlag,
+		messageFlag,
+		queryFlag,
+		pingFlag,
+		insecureFlag,
+		infoFlag,
+		watchFlag,
+		queueFlag,
+		exchangeFlag,
+		partitionsFlag,
+		writablePrimaryFlag,
+		hasPrimaryFlag,
+		connectTimeoutFlag,
+		timeoutFlag,
+		intervalFlag,
+		verboseFlag,
+		strictFlag,
+		traceFlag,
+		quietFlag,
+	},
+
+	Action: func(c *cli.Context) error {
+		names, conditions := targets(c)
+		return waitForConditions(c, names, conditions, "targets", "ready")
+	},
+}
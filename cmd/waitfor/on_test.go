@@ -0,0 +1,209 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/ghttp"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+	"github.com/st3v/waitfor/cmd/waitfor/fake"
+)
+
+var _ = Describe("on command", func() {
+	var (
+		app = app()
+
+		portcheck *fake.PortCheck
+		server    *ghttp.Server
+		dir       string
+		args      []string
+
+		actualPorts    []int
+		actualResults  []bool
+		actualInterval time.Duration
+		actualTimeout  time.Duration
+		actualOutput   *gbytes.Buffer
+		actualErr      error
+	)
+
+	BeforeEach(func() {
+		portcheck = new(fake.PortCheck)
+		portcheck.OnHostReturns(portcheck)
+		portcheck.ForNetworkReturns(portcheck)
+		portcheck.WithPayloadReturns(portcheck)
+		portcheck.WithReplyTimeoutReturns(portcheck)
+		portcheck.WithNoReplyOKReturns(portcheck)
+		portcheck.WithLoggerReturns(portcheck)
+		portcheck.IsOpenReturns(true)
+		portCheckProvider = func(port int) check.PortCheck {
+			actualPorts = append(actualPorts, port)
+			return portcheck
+		}
+		curlCheckProvider = check.Curl
+
+		server = ghttp.NewServer()
+		server.RouteToHandler("GET", "/health", ghttp.RespondWith(200, "ok"))
+
+		var err error
+		dir, err = ioutil.TempDir("", "on")
+		Expect(err).ToNot(HaveOccurred())
+
+		args = []string{}
+		actualPorts = nil
+		actualResults = nil
+		actualOutput = gbytes.NewBuffer()
+
+		waitForAllWithTimeout = func(checks []waitfor.Check, interval, timeout time.Duration, ctx context.Context) []error {
+			actualInterval = interval
+			actualTimeout = timeout
+
+			errs := make([]error, len(checks))
+			for i, check := range checks {
+				result := check()
+				actualResults = append(actualResults, result)
+				if !result {
+					errs[i] = waitfor.ErrTimeoutExceeded
+				}
+			}
+			return errs
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	JustBeforeEach(func() {
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		actualErr = app.Run(append([]string{"waitfor", "on"}, args...))
+	})
+
+	Context("when targets of all supported kinds have been specified", func() {
+		BeforeEach(func() {
+			file := filepath.Join(dir, "ready")
+			Expect(ioutil.WriteFile(file, []byte{}, 0644)).To(Succeed())
+
+			args = []string{
+				"tcp://db:5432",
+				"udp://statsd:8125",
+				"cache:6379",
+				server.URL() + "/health",
+				"file://" + file,
+				"unix://" + filepath.Join(dir, "missing.sock"),
+			}
+		})
+
+		It("checks all of them", func() {
+			Expect(actualResults).To(Equal([]bool{true, true, true, true, true, false}))
+		})
+
+		It("uses port checks for tcp and udp targets", func() {
+			Expect(actualPorts).To(Equal([]int{5432, 8125, 6379}))
+			Expect(portcheck.OnHostArgsForCall(0)).To(Equal("db"))
+			Expect(portcheck.ForNetworkArgsForCall(0)).To(Equal("tcp"))
+			Expect(portcheck.OnHostArgsForCall(1)).To(Equal("statsd"))
+			Expect(portcheck.ForNetworkArgsForCall(1)).To(Equal("udp"))
+			Expect(portcheck.OnHostArgsForCall(2)).To(Equal("cache"))
+			Expect(portcheck.ForNetworkArgsForCall(2)).To(Equal("tcp"))
+		})
+
+		It("uses a curl check for http targets", func() {
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("reports the status of each target", func() {
+			Expect(actualOutput).To(gbytes.Say("Waiting for all of 6 targets to be ready"))
+			Expect(actualOutput).To(gbytes.Say("tcp://db:5432: ready"))
+			Expect(actualOutput).To(gbytes.Say("missing.sock: timeout exceeded"))
+			Expect(actualOutput).To(gbytes.Say("1 of 6 targets not ready"))
+		})
+
+		It("returns an error", func() {
+			Expect(actualErr).To(HaveOccurred())
+		})
+	})
+
+	Describe("curl flags", func() {
+		BeforeEach(func() {
+			server.RouteToHandler("POST", "/health", ghttp.RespondWith(201, "created"))
+			args = []string{server.URL() + "/health", "-X", "POST", "-s", "201"}
+		})
+
+		It("are applied to http targets", func() {
+			Expect(actualResults).To(Equal([]bool{true}))
+			Expect(server.ReceivedRequests()[0].Method).To(Equal("POST"))
+		})
+	})
+
+	Context("when a tcp target has an IPv6 host", func() {
+		BeforeEach(func() {
+			args = []string{"tcp://[::1]:5432"}
+		})
+
+		It("passes the host without brackets", func() {
+			Expect(actualPorts).To(Equal([]int{5432}))
+			Expect(portcheck.OnHostArgsForCall(0)).To(Equal("::1"))
+			Expect(actualOutput).To(gbytes.Say(`tcp://\[::1\]:5432: ready`))
+		})
+	})
+
+	Describe("--interval and --timeout flags", func() {
+		BeforeEach(func() {
+			args = []string{"db:5432", "-i", "2s", "-t", "1m"}
+		})
+
+		It("are being used", func() {
+			Expect(actualInterval).To(Equal(2 * time.Second))
+			Expect(actualTimeout).To(Equal(time.Minute))
+		})
+	})
+
+	Context("when a target is invalid", func() {
+		var exitCode int
+
+		BeforeEach(func() {
+			args = []string{"db:5432"}
+		})
+
+		JustBeforeEach(func() {
+			exitCode = 0
+			exit = func(rc int) {
+				exitCode = rc
+				panic(rc)
+			}
+
+			Expect(func() {
+				app.Run([]string{"waitfor", "on", "gopher://example.com"})
+			}).To(Panic())
+		})
+
+		AfterEach(func() {
+			exit = os.Exit
+		})
+
+		It("exits with the exit code for invalid usage", func() {
+			Expect(exitCode).To(Equal(exitUsage))
+		})
+
+		It("provides a corresponding error", func() {
+			Expect(actualOutput).To(gbytes.Say("invalid target 'gopher://example.com': unsupported scheme 'gopher'"))
+		})
+
+		It("rejects ports out of range", func() {
+			Expect(func() {
+				app.Run([]string{"waitfor", "on", "tcp://db:70000"})
+			}).To(Panic())
+			Expect(actualOutput).To(gbytes.Say("invalid target 'tcp://db:70000': port 70000 is out of range 1-65535"))
+		})
+	})
+})
@@ -2,78 +2,263 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
+	"net"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/codegangsta/cli"
 
+	"github.com/st3v/waitfor"
 	"github.com/st3v/waitfor/check"
 )
 
-var portCheckProvider = check.Port
+var (
+	portCheckProvider   = check.Port
+	bannerCheckProvider = check.Banner
+)
+
+type endpoint struct {
+	host string
+	port int
+}
 
-var port = func(c *cli.Context) int {
-	if !c.Args().Present() {
+var endpoints = func(c *cli.Context, host string) []endpoint {
+	if !positional(c).Present() {
 		cli.ShowCommandHelp(c, "port")
 		fmt.Fprintln(c.App.Writer, "must specify port")
-		exit(1)
+		exitWith(c, exitUsage)
+	}
+
+	var result []endpoint
+	for _, arg := range positional(c) {
+		e, err := parseEndpoints(arg, host)
+		if err != nil {
+			fmt.Fprintf(c.App.Writer, "invalid port '%s': %s\n", arg, err)
+			exitWith(c, exitUsage)
+		}
+		result = append(result, e...)
+	}
+
+	return result
+}
+
+// parseEndpoints accepts a port (8080), a port range (8000-8010) and either of
+// them prefixed with a host (localhost:8080, [::1]:8000-8010).
+func parseEndpoints(arg, host string) ([]endpoint, error) {
+	ports := arg
+	if strings.Contains(arg, ":") {
+		var err error
+		host, ports, err = net.SplitHostPort(arg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	first, last := ports, ports
+	if i := strings.Index(ports, "-"); i > 0 {
+		first, last = ports[:i], ports[i+1:]
+	}
+
+	from, err := parsePort(first)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := parsePort(last)
+	if err != nil {
+		return nil, err
+	}
+
+	if from > to {
+		return nil, fmt.Errorf("first port %d is greater than last port %d", from, to)
+	}
+
+	if to-from >= maxPortRange {
+		return nil, fmt.Errorf("port range %d-%d exceeds the limit of %d ports", from, to, maxPortRange)
+	}
+
+	result := make([]endpoint, 0, to-from+1)
+	for p := from; p <= to; p++ {
+		result = append(result, endpoint{host, p})
+	}
+
+	return result, nil
+}
+
+// maxPortRange limits the number of ports in a range, every port is checked
+// concurrently.
+const maxPortRange = 1024
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a number", s)
+	}
+
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("port %d is out of range 1-65535", port)
 	}
 
-	port, err := strconv.Atoi(c.Args().First())
+	return port, nil
+}
+
+// portAddr returns the address of a port the way it is reported, e.g.
+// 'tcp://[::1]:8080'.
+func portAddr(network, host string, port int) string {
+	return network + "://" + net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+var payload = func(c *cli.Context) []byte {
+	payload, err := unescape(c.String("payload"))
 	if err != nil {
-		fmt.Fprintln(c.App.Writer, "invalid port")
-		exit(1)
+		fmt.Fprintln(c.App.Writer, "invalid payload")
+		exitWith(c, exitUsage)
 	}
 
-	return port
+	return []byte(payload)
+}
+
+// unescape interprets Go escape sequences like '\n' or '\x00' in s. Unlike
+// strconv.Unquote it does not require quotes to be escaped.
+func unescape(s string) (string, error) {
+	var buf []byte
+	for len(s) > 0 {
+		if s[0] == '"' {
+			buf, s = append(buf, '"'), s[1:]
+			continue
+		}
+
+		r, multibyte, tail, err := strconv.UnquoteChar(s, '"')
+		if err != nil {
+			return "", err
+		}
+
+		if r < utf8.RuneSelf || !multibyte {
+			buf = append(buf, byte(r))
+		} else {
+			buf = utf8.AppendRune(buf, r)
+		}
+
+		s = tail
+	}
+
+	return string(buf), nil
 }
 
 var portCommand = cli.Command{
 	Name:  "port",
 	Usage: "wait for host to listen on port (or not)",
 
+	ArgsUsage: "<port|from-to|host:port|host:from-to>...",
+
 	HideHelp: true,
 
 	Flags: []cli.Flag{
 		closedFlag,
+		anyFlag,
 		hostFlag,
 		networkFlag,
+		payloadFlag,
+		replyTimeoutFlag,
+		noReplyOKFlag,
+		protocolFlag,
+		fingerprintFlag,
+		connectTimeoutFlag,
 		timeoutFlag,
 		intervalFlag,
 		verboseFlag,
+		strictFlag,
+		traceFlag,
+		quietFlag,
 	},
 
 	Action: func(c *cli.Context) error {
-		host := c.String("host")
-		network := c.String("network")
-		timeout := c.Duration("timeout")
-		interval := c.Duration("interval")
-
-		port := port(c)
-		addr := fmt.Sprintf("%s://%s:%d", network, host, port)
 		state := "open"
-
-		logger := ioutil.Discard
-		if c.Bool("verbose") {
-			logger = c.App.Writer
+		switch {
+		case c.Bool("closed"):
+			state = "closed"
+		case c.String("protocol") != "":
+			state = "ready"
 		}
 
-		portCheck := portCheckProvider(port).OnHost(host).ForNetwork(network).WithLogger(logger)
-		checkFunc := portCheck.IsOpen
+		addrs, conditions := portConditions(c)
+		return waitFor(c, addrs, conditions, "ports", state)
+	},
+}
+
+// portConditions returns the addresses and conditions of all endpoints given
+// as positional arguments.
+func portConditions(c *cli.Context) ([]string, []waitfor.Check) {
+	network := c.String("network")
+	endpoints := endpoints(c, c.String("host"))
+
+	if protocol := c.String("protocol"); protocol != "" {
+		if !isBannerProtocol(protocol) {
+			fmt.Fprintf(c.App.Writer, "invalid protocol '%s', must be one of %s\n", protocol, strings.Join(check.BannerProtocols(), ", "))
+			exitWith(c, exitUsage)
+		}
 
 		if c.Bool("closed") {
-			checkFunc = portCheck.IsClosed
-			state = "closed"
+			fmt.Fprintln(c.App.Writer, "--protocol cannot be combined with --closed")
+			exitWith(c, exitUsage)
 		}
+	}
 
-		fmt.Fprintf(c.App.Writer, "Waiting for %s to be %s...\n", addr, state)
+	addrs := make([]string, len(endpoints))
+	conditions := make([]waitfor.Check, len(endpoints))
 
-		if err := waitForConditionWithTimeout(checkFunc, interval, timeout); err != nil {
-			fmt.Fprintf(c.App.Writer, "Error waiting for %s port: %s\n", state, err)
-			return err
-		}
+	for i, e := range endpoints {
+		addrs[i] = portAddr(network, e.host, e.port)
+		conditions[i] = portCondition(c, network, e.host, e.port)
+	}
 
-		fmt.Fprintf(c.App.Writer, "Success: port is %s\n", state)
-		return nil
-	},
+	return addrs, conditions
+}
+
+func portCondition(c *cli.Context, network, host string, port int) waitfor.Check {
+	if protocol := c.String("protocol"); protocol != "" {
+		return bannerCondition(c, protocol, net.JoinHostPort(host, strconv.Itoa(port)))
+	}
+
+	target := currentWait(c).Target("port", portAddr(network, host, port))
+	portCheck := portCheckProvider(port).
+		OnHost(host).
+		ForNetwork(network).
+		WithPayload(payload(c)).
+		WithReplyTimeout(c.Duration("reply-timeout")).
+		WithNoReplyOK(c.Bool("no-reply-ok")).
+		WithLogger(targetLogger(c, target))
+
+	if c.Bool("closed") {
+		return target.Check(portCheck.IsClosed)
+	}
+
+	return target.Check(portCheck.IsOpen)
+}
+
+// bannerCondition waits for the server at addr to greet its clients according
+// to the given protocol, e.g. 'smtp'.
+func bannerCondition(c *cli.Context, protocol, addr string) waitfor.Check {
+	target := currentWait(c).Target("port", protocol+"://"+addr)
+	banner := bannerCheckProvider(protocol, addr).WithLogger(targetLogger(c, target))
+
+	if timeout := c.Duration("connect-timeout"); timeout > 0 {
+		banner.WithTimeout(timeout)
+	}
+
+	if fingerprint := c.String("fingerprint"); fingerprint != "" {
+		banner.WithFingerprint(fingerprint)
+	}
+
+	return target.Check(banner.IsReady)
+}
+
+func isBannerProtocol(protocol string) bool {
+	for _, name := range check.BannerProtocols() {
+		if name == protocol {
+			return true
+		}
+	}
+	return false
 }
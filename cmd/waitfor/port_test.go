@@ -2,15 +2,18 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/context"
 
 	"github.com/st3v/waitfor"
 	"github.com/st3v/waitfor/check"
@@ -27,6 +30,10 @@ var _ = Describe("port command", func() {
 
 		expectedPort   int
 		actualPort     int
+		actualPorts    []int
+		actualChecks   []waitfor.Check
+		actualAny      bool
+		expectedErrs   []error
 		actualInterval time.Duration
 		actualTimeout  time.Duration
 		actualOutput   *gbytes.Buffer
@@ -36,9 +43,13 @@ var _ = Describe("port command", func() {
 		portcheck = new(fake.PortCheck)
 		portcheck.OnHostReturns(portcheck)
 		portcheck.ForNetworkReturns(portcheck)
+		portcheck.WithPayloadReturns(portcheck)
+		portcheck.WithReplyTimeoutReturns(portcheck)
+		portcheck.WithNoReplyOKReturns(portcheck)
 		portcheck.WithLoggerReturns(portcheck)
 		portCheckProvider = func(port int) check.PortCheck {
 			actualPort = port
+			actualPorts = append(actualPorts, port)
 			return portcheck
 		}
 
@@ -46,16 +57,35 @@ var _ = Describe("port command", func() {
 		expectedPort = 12345
 
 		actualPort = 0
+		actualPorts = nil
+		actualChecks = nil
+		actualAny = false
+		expectedErrs = nil
 		actualInterval = 0
 		actualTimeout = 0
 		actualOutput = gbytes.NewBuffer()
 
-		waitForConditionWithTimeout = func(check waitfor.Check, interval, timeout time.Duration) error {
+		waitForConditionWithTimeout = func(check waitfor.Check, interval, timeout time.Duration, ctx context.Context) error {
 			check()
 			actualInterval = interval
 			actualTimeout = timeout
 			return expectedErr
 		}
+
+		waitForAllWithTimeout = func(checks []waitfor.Check, interval, timeout time.Duration, ctx context.Context) []error {
+			actualChecks = checks
+			actualInterval = interval
+			actualTimeout = timeout
+			if expectedErrs != nil {
+				return expectedErrs
+			}
+			return make([]error, len(checks))
+		}
+
+		waitForAnyWithTimeout = func(checks []waitfor.Check, interval, timeout time.Duration, ctx context.Context) []error {
+			actualAny = true
+			return waitForAllWithTimeout(checks, interval, timeout, ctx)
+		}
 	})
 
 	JustBeforeEach(func() {
@@ -111,8 +141,8 @@ var _ = Describe("port command", func() {
 				exit = os.Exit
 			})
 
-			It("exits with non-zero exit code", func() {
-				Expect(exitCode).ToNot(Equal(0))
+			It("exits with the exit code for invalid usage", func() {
+				Expect(exitCode).To(Equal(exitUsage))
 			})
 
 			It("provides a corresponding error", func() {
@@ -139,8 +169,8 @@ var _ = Describe("port command", func() {
 				exit = os.Exit
 			})
 
-			It("exits with non-zero exit code", func() {
-				Expect(exitCode).ToNot(Equal(0))
+			It("exits with the exit code for invalid usage", func() {
+				Expect(exitCode).To(Equal(exitUsage))
 			})
 
 			It("provides a corresponding error", func() {
@@ -149,6 +179,161 @@ var _ = Describe("port command", func() {
 		})
 	})
 
+	Describe("multiple port arguments", func() {
+		Context("when multiple ports have been specified", func() {
+			BeforeEach(func() {
+				args = []string{"8080", "9090"}
+			})
+
+			It("checks all of them concurrently", func() {
+				Expect(actualPorts).To(Equal([]int{expectedPort, 8080, 9090}))
+				Expect(actualChecks).To(HaveLen(3))
+				Expect(actualAny).To(BeFalse())
+			})
+
+			It("reports the status of each port", func() {
+				Expect(actualOutput).To(gbytes.Say("Waiting for all of 3 ports to be open"))
+				Expect(actualOutput).To(gbytes.Say("tcp://127.0.0.1:12345: open"))
+				Expect(actualOutput).To(gbytes.Say("tcp://127.0.0.1:8080: open"))
+				Expect(actualOutput).To(gbytes.Say("tcp://127.0.0.1:9090: open"))
+				Expect(actualOutput).To(gbytes.Say("Success: 3 of 3 ports open"))
+			})
+
+			Context("and one of them fails", func() {
+				BeforeEach(func() {
+					expectedErrs = []error{nil, waitfor.ErrTimeoutExceeded, nil}
+				})
+
+				It("reports the failing port", func() {
+					Expect(actualOutput).To(gbytes.Say("tcp://127.0.0.1:8080: timeout exceeded"))
+					Expect(actualOutput).To(gbytes.Say("Error waiting for open ports: 1 of 3 ports not open"))
+				})
+
+				Context("and --any has been specified", func() {
+					BeforeEach(func() {
+						args = append(args, "--any")
+					})
+
+					It("waits for any of them", func() {
+						Expect(actualAny).To(BeTrue())
+						Expect(actualOutput).To(gbytes.Say("Waiting for any of 3 ports to be open"))
+					})
+
+					It("succeeds", func() {
+						Expect(actualOutput).To(gbytes.Say("Success: 2 of 3 ports open"))
+					})
+				})
+			})
+		})
+
+		Context("when a port range has been specified", func() {
+			BeforeEach(func() {
+				args = []string{"8000-8002"}
+			})
+
+			It("checks every port in the range", func() {
+				Expect(actualPorts).To(Equal([]int{expectedPort, 8000, 8001, 8002}))
+			})
+		})
+
+		Context("when host:port pairs have been specified", func() {
+			BeforeEach(func() {
+				args = []string{"example.com:80", "[::1]:443-444"}
+			})
+
+			It("checks the ports on the given hosts", func() {
+				Expect(actualPorts).To(Equal([]int{expectedPort, 80, 443, 444}))
+				Expect(portcheck.OnHostArgsForCall(0)).To(Equal("127.0.0.1"))
+				Expect(portcheck.OnHostArgsForCall(1)).To(Equal("example.com"))
+				Expect(portcheck.OnHostArgsForCall(2)).To(Equal("::1"))
+				Expect(portcheck.OnHostArgsForCall(3)).To(Equal("::1"))
+			})
+		})
+
+		Context("when a port range is invalid", func() {
+			var exitCode int
+
+			JustBeforeEach(func() {
+				exitCode = 0
+				exit = func(rc int) {
+					exitCode = rc
+					panic(rc)
+				}
+
+				Expect(func() {
+					app.Run([]string{"watchfor", "port", "8010-8000"})
+				}).To(Panic())
+			})
+
+			AfterEach(func() {
+				exit = os.Exit
+			})
+
+			It("exits with the exit code for invalid usage", func() {
+				Expect(exitCode).To(Equal(exitUsage))
+			})
+
+			It("provides a corresponding error", func() {
+				Expect(actualOutput).To(gbytes.Say("invalid port '8010-8000': first port 8010 is greater than last port 8000"))
+			})
+		})
+
+		Context("when a port is out of range", func() {
+			JustBeforeEach(func() {
+				exit = func(rc int) {
+					panic(rc)
+				}
+			})
+
+			AfterEach(func() {
+				exit = os.Exit
+			})
+
+			It("rejects it", func() {
+				Expect(func() {
+					app.Run([]string{"watchfor", "port", "65530-65536"})
+				}).To(Panic())
+				Expect(actualOutput).To(gbytes.Say("invalid port '65530-65536': port 65536 is out of range 1-65535"))
+			})
+
+			It("rejects port 0", func() {
+				Expect(func() {
+					app.Run([]string{"watchfor", "port", "0"})
+				}).To(Panic())
+				Expect(actualOutput).To(gbytes.Say("port 0 is out of range 1-65535"))
+			})
+		})
+
+		Context("when a port range is too large", func() {
+			JustBeforeEach(func() {
+				exit = func(rc int) {
+					panic(rc)
+				}
+			})
+
+			AfterEach(func() {
+				exit = os.Exit
+			})
+
+			It("rejects it", func() {
+				Expect(func() {
+					app.Run([]string{"watchfor", "port", "1-65535"})
+				}).To(Panic())
+				Expect(actualOutput).To(gbytes.Say("port range 1-65535 exceeds the limit of 1024 ports"))
+			})
+		})
+
+		Context("when IPv6 hosts have been specified", func() {
+			BeforeEach(func() {
+				args = []string{"[::1]:8080"}
+			})
+
+			It("reports them in brackets", func() {
+				Expect(actualOutput).To(gbytes.Say(`tcp://\[::1\]:8080: open`))
+			})
+		})
+	})
+
 	Describe("--closed flag", func() {
 		Context("when it has been specified", func() {
 			BeforeEach(func() {
@@ -161,7 +346,7 @@ var _ = Describe("port command", func() {
 
 			It("logs the correct state", func() {
 				Expect(actualOutput).To(gbytes.Say("to be closed"))
-				Expect(actualOutput).To(gbytes.Say("Success: port is closed"))
+				Expect(actualOutput).To(gbytes.Say(`Success: tcp://\S+:12345 is closed`))
 			})
 		})
 
@@ -172,7 +357,7 @@ var _ = Describe("port command", func() {
 
 			It("logs the correct state", func() {
 				Expect(actualOutput).To(gbytes.Say("to be open"))
-				Expect(actualOutput).To(gbytes.Say("Success: port is open"))
+				Expect(actualOutput).To(gbytes.Say(`Success: tcp://\S+:12345 is open`))
 			})
 		})
 	})
@@ -217,6 +402,249 @@ var _ = Describe("port command", func() {
 		})
 	})
 
+	Describe("--payload flag", func() {
+		Context("when it has been set", func() {
+			BeforeEach(func() {
+				args = []string{"--payload", `ping\n\x00`}
+			})
+
+			It("sends the specified payload", func() {
+				Expect(portcheck.WithPayloadArgsForCall(0)).To(Equal([]byte("ping\n\x00")))
+			})
+		})
+
+		Context("when it has not been set", func() {
+			It("sends an empty payload", func() {
+				Expect(portcheck.WithPayloadArgsForCall(0)).To(BeEmpty())
+			})
+		})
+
+		Context("when it contains quotes", func() {
+			BeforeEach(func() {
+				args = []string{"--payload", `{"ping":"\u00e9"}`}
+			})
+
+			It("does not require them to be escaped", func() {
+				Expect(portcheck.WithPayloadArgsForCall(0)).To(Equal([]byte(`{"ping":"é"}`)))
+			})
+		})
+
+		Context("when it is invalid", func() {
+			var exitCode int
+
+			JustBeforeEach(func() {
+				exitCode = 0
+				exit = func(rc int) {
+					exitCode = rc
+					panic(rc)
+				}
+
+				Expect(func() {
+					app.Run([]string{"watchfor", "port", "123", "--payload", `\xZZ`})
+				}).To(Panic())
+			})
+
+			AfterEach(func() {
+				exit = os.Exit
+			})
+
+			It("exits with the exit code for invalid usage", func() {
+				Expect(exitCode).To(Equal(exitUsage))
+			})
+
+			It("provides a corresponding error", func() {
+				Expect(actualOutput).To(gbytes.Say("invalid payload"))
+			})
+		})
+
+		Context("when it has been set in a config file", func() {
+			var dir string
+
+			BeforeEach(func() {
+				var err error
+				dir, err = ioutil.TempDir("", "port")
+				Expect(err).ToNot(HaveOccurred())
+
+				config := `
+checks:
+- name: db
+  kind: port
+  port: 5432
+  payload: 'ping\n\x00'
+`
+				Expect(ioutil.WriteFile(filepath.Join(dir, "waitfor.yaml"), []byte(config), 0644)).To(Succeed())
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(dir)
+			})
+
+			It("interprets escape sequences the same way", func() {
+				portcheck.IsOpenReturns(true)
+				Expect(app.Run([]string{"waitfor", "run", "-f", filepath.Join(dir, "waitfor.yaml")})).To(Succeed())
+				Expect(portcheck.WithPayloadArgsForCall(portcheck.WithPayloadCallCount() - 1)).To(Equal([]byte("ping\n\x00")))
+			})
+		})
+	})
+
+	Describe("--reply-timeout flag", func() {
+		Context("when it has been set", func() {
+			var expectedTimeout = 123 * time.Millisecond
+
+			BeforeEach(func() {
+				args = []string{"--reply-timeout", expectedTimeout.String()}
+			})
+
+			It("is being used", func() {
+				Expect(portcheck.WithReplyTimeoutArgsForCall(0)).To(Equal(expectedTimeout))
+			})
+		})
+
+		Context("when it has not been set", func() {
+			It("the default reply timeout is being used", func() {
+				Expect(portcheck.WithReplyTimeoutArgsForCall(0)).To(Equal(time.Second))
+			})
+		})
+	})
+
+	Describe("--no-reply-ok flag", func() {
+		Context("when it has been set", func() {
+			BeforeEach(func() {
+				args = []string{"-n", "udp", "--no-reply-ok"}
+			})
+
+			It("is being used", func() {
+				Expect(portcheck.WithNoReplyOKArgsForCall(0)).To(BeTrue())
+			})
+		})
+
+		Context("when it has not been set", func() {
+			It("requires a reply", func() {
+				Expect(portcheck.WithNoReplyOKArgsForCall(0)).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("--protocol flag", func() {
+		var (
+			bannercheck    *fake.BannerCheck
+			actualProtocol string
+			actualAddr     string
+		)
+
+		BeforeEach(func() {
+			bannercheck = new(fake.BannerCheck)
+			bannercheck.WithFingerprintReturns(bannercheck)
+			bannercheck.WithTimeoutReturns(bannercheck)
+			bannercheck.WithLoggerReturns(bannercheck)
+			bannercheck.IsReadyReturns(true)
+			bannerCheckProvider = func(protocol, addr string) check.BannerCheck {
+				actualProtocol = protocol
+				actualAddr = addr
+				return bannercheck
+			}
+
+			args = []string{"--protocol", "ssh", "--host", "bastion", "--fingerprint", "SHA256:abc", "--connect-timeout", "2s"}
+		})
+
+		It("waits for the server to greet its clients", func() {
+			Expect(actualProtocol).To(Equal("ssh"))
+			Expect(actualAddr).To(Equal("bastion:12345"))
+			Expect(bannercheck.IsReadyCallCount()).To(Equal(1))
+			Expect(bannercheck.WithFingerprintArgsForCall(0)).To(Equal("SHA256:abc"))
+			Expect(bannercheck.WithTimeoutArgsForCall(0)).To(Equal(2 * time.Second))
+			Expect(portcheck.IsOpenCallCount()).To(Equal(0))
+		})
+
+		It("logs the correct state", func() {
+			Expect(actualOutput).To(gbytes.Say("to be ready"))
+			Expect(actualOutput).To(gbytes.Say(`Success: tcp://bastion:12345 is ready`))
+		})
+
+		Context("when used as target of the on command", func() {
+			It("waits for the server to greet its clients", func() {
+				Expect(app.Run([]string{"waitfor", "on", "smtp://mail:2525"})).To(Succeed())
+				Expect(actualProtocol).To(Equal("smtp"))
+				Expect(actualAddr).To(Equal("mail:2525"))
+			})
+		})
+
+		Context("when used in a config file", func() {
+			var dir string
+
+			BeforeEach(func() {
+				var err error
+				dir, err = ioutil.TempDir("", "port")
+				Expect(err).ToNot(HaveOccurred())
+
+				config := `
+checks:
+- name: mail
+  kind: port
+  host: relay
+  port: 25
+  protocol: smtp
+`
+				Expect(ioutil.WriteFile(filepath.Join(dir, "waitfor.yaml"), []byte(config), 0644)).To(Succeed())
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(dir)
+			})
+
+			It("waits for the server to greet its clients", func() {
+				Expect(app.Run([]string{"waitfor", "run", "-f", filepath.Join(dir, "waitfor.yaml")})).To(Succeed())
+				Expect(actualProtocol).To(Equal("smtp"))
+				Expect(actualAddr).To(Equal("relay:25"))
+			})
+		})
+
+		Context("when it is invalid", func() {
+			var (
+				exitCode int
+				usage    []string
+			)
+
+			JustBeforeEach(func() {
+				exitCode = 0
+				exit = func(rc int) {
+					exitCode = rc
+					panic(rc)
+				}
+
+				Expect(func() {
+					app.Run(append([]string{"watchfor", "port", "22"}, usage...))
+				}).To(Panic())
+			})
+
+			AfterEach(func() {
+				exit = os.Exit
+			})
+
+			Context("because the protocol is unknown", func() {
+				BeforeEach(func() {
+					usage = []string{"--protocol", "gopher"}
+				})
+
+				It("provides a corresponding error", func() {
+					Expect(exitCode).To(Equal(exitUsage))
+					Expect(actualOutput).To(gbytes.Say("invalid protocol 'gopher', must be one of ftp, smtp, ssh"))
+				})
+			})
+
+			Context("because it has been combined with --closed", func() {
+				BeforeEach(func() {
+					usage = []string{"--protocol", "ftp", "--closed"}
+				})
+
+				It("provides a corresponding error", func() {
+					Expect(exitCode).To(Equal(exitUsage))
+					Expect(actualOutput).To(gbytes.Say("--protocol cannot be combined with --closed"))
+				})
+			})
+		})
+	})
+
 	Describe("--verbose flag", func() {
 		Context("when it has been set", func() {
 			BeforeEach(func() {
@@ -224,13 +652,15 @@ var _ = Describe("port command", func() {
 			})
 
 			It("is uses the correct writer for logging", func() {
-				Expect(portcheck.WithLoggerArgsForCall(0)).To(Equal(app.Writer))
+				fmt.Fprintln(portcheck.WithLoggerArgsForCall(0), "Dialing tcp://127.0.0.1:12345")
+				Expect(actualOutput).To(gbytes.Say("Dialing tcp://127.0.0.1:12345"))
 			})
 		})
 
-		Context("it uses io.Discard for logging", func() {
-			It("", func() {
-				Expect(portcheck.WithLoggerArgsForCall(0)).To(Equal(ioutil.Discard))
+		Context("when it has not been set", func() {
+			It("only records the log for the progress report", func() {
+				fmt.Fprintln(portcheck.WithLoggerArgsForCall(0), "Dialing tcp://127.0.0.1:12345")
+				Expect(actualOutput).ToNot(gbytes.Say("Dialing"))
 			})
 		})
 	})
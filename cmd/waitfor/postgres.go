@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	neturl "net/url"
+	"regexp"
+
+	"github.com/codegangsta/cli"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+)
+
+var postgresCheckProvider = check.Postgres
+
+var dsns = func(c *cli.Context, command string) []string {
+	if !positional(c).Present() {
+		cli.ShowCommandHelp(c, command)
+		fmt.Fprintln(c.App.Writer, "must specify DSN")
+		exitWith(c, exitUsage)
+	}
+	return positional(c)
+}
+
+var postgresCommand = cli.Command{
+	Name:    "postgres",
+	Aliases: []string{"postgresql"},
+	Usage:   "wait for a PostgreSQL server to accept connections",
+
+	ArgsUsage: "<dsn>...",
+
+	HideHelp: true,
+
+	Flags: []cli.Flag{
+		queryFlag,
+		primaryFlag,
+		anyFlag,
+		connectTimeoutFlag,
+		timeoutFlag,
+		intervalFlag,
+		verboseFlag,
+		strictFlag,
+		traceFlag,
+		quietFlag,
+	},
+
+	Action: func(c *cli.Context) error {
+		state := "ready"
+		if c.Bool("primary") {
+			state = "primary"
+		}
+
+		names, conditions := postgresConditions(c)
+		return waitFor(c, names, conditions, "servers", state)
+	},
+}
+
+// postgresConditions returns the DSNs given as positional arguments, without
+// passwords, and the corresponding conditions.
+func postgresConditions(c *cli.Context) ([]string, []waitfor.Check) {
+	var (
+		names      []string
+		conditions []waitfor.Check
+	)
+
+	for _, dsn := range dsns(c, "postgres") {
+		names = append(names, redactDSN(dsn))
+		conditions = append(conditions, postgresCondition(c, dsn))
+	}
+
+	return names, conditions
+}
+
+func postgresCondition(c *cli.Context, dsn string) waitfor.Check {
+	target := currentWait(c).Target("postgres", redactDSN(dsn))
+	pg := postgresCheckProvider(dsn).WithLogger(targetLogger(c, target))
+
+	if timeout := c.Duration("connect-timeout"); timeout > 0 {
+		pg.WithTimeout(timeout)
+	}
+
+	if query := c.String("query"); query != "" {
+		pg.WithQuery(query)
+	}
+
+	if c.Bool("primary") {
+		return target.Check(pg.IsPrimary)
+	}
+
+	return target.Check(pg.IsReady)
+}
+
+var (
+	passwordKeyword    = regexp.MustCompile(`password=('(\\.|[^'])*'|\S+)`)
+	passwordCredential = regexp.MustCompile(`^([^:@\s]*):.*@`)
+)
+
+// redactDSN hides the password of a DSN given either as URL, as list of
+// keywords or in the format of the Go MySQL driver, so that it can be logged.
+func redactDSN(dsn string) string {
+	if u, err := neturl.Parse(dsn); err == nil && u.Scheme != "" && u.Opaque == "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = neturl.UserPassword(u.User.Username(), "xxxxx")
+		}
+		return u.String()
+	}
+
+	dsn = passwordCredential.ReplaceAllString(dsn, "$1:xxxxx@")
+	return passwordKeyword.ReplaceAllString(dsn, "password=xxxxx")
+}
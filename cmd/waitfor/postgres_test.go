@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+	"github.com/st3v/waitfor/cmd/waitfor/fake"
+)
+
+var _ = Describe("postgres command", func() {
+	var (
+		pgcheck     *fake.PostgresCheck
+		command     string
+		args        []string
+		expectedErr error
+
+		actualDSNs    []string
+		actualChecks  []waitfor.Check
+		actualTimeout time.Duration
+		actualOutput  *gbytes.Buffer
+		actualErr     error
+	)
+
+	BeforeEach(func() {
+		pgcheck = new(fake.PostgresCheck)
+		pgcheck.WithQueryReturns(pgcheck)
+		pgcheck.WithTimeoutReturns(pgcheck)
+		pgcheck.WithLoggerReturns(pgcheck)
+		pgcheck.IsReadyReturns(true)
+		pgcheck.IsPrimaryReturns(true)
+		postgresCheckProvider = func(dsn string) check.PostgresCheck {
+			actualDSNs = append(actualDSNs, dsn)
+			return pgcheck
+		}
+
+		command = "postgres"
+		args = []string{"postgres://app:secret@db:5432/app"}
+		expectedErr = nil
+		actualDSNs = nil
+		actualChecks = nil
+		actualOutput = gbytes.NewBuffer()
+
+		waitForConditionWithTimeout = func(check waitfor.Check, interval, timeout time.Duration, ctx context.Context) error {
+			check()
+			actualTimeout = timeout
+			return expectedErr
+		}
+
+		waitForAllWithTimeout = func(checks []waitfor.Check, interval, timeout time.Duration, ctx context.Context) []error {
+			actualChecks = checks
+			for _, check := range checks {
+				check()
+			}
+			return make([]error, len(checks))
+		}
+	})
+
+	JustBeforeEach(func() {
+		app := app()
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		actualErr = app.Run(append([]string{"waitfor", command}, args...))
+	})
+
+	It("waits for the server to be ready", func() {
+		Expect(actualDSNs).To(Equal([]string{"postgres://app:secret@db:5432/app"}))
+		Expect(pgcheck.IsReadyCallCount()).To(Equal(1))
+		Expect(pgcheck.IsPrimaryCallCount()).To(Equal(0))
+		Expect(actualErr).ToNot(HaveOccurred())
+	})
+
+	It("does not print the password", func() {
+		Expect(actualOutput).To(gbytes.Say(`Waiting for postgres://app:xxxxx@db:5432/app to be ready\.\.\.`))
+		Expect(actualOutput).To(gbytes.Say("Success: postgres://app:xxxxx@db:5432/app is ready"))
+	})
+
+	It("uses the default connect timeout", func() {
+		Expect(pgcheck.WithTimeoutArgsForCall(0)).To(Equal(5 * time.Second))
+	})
+
+	Context("when the check fails", func() {
+		BeforeEach(func() {
+			expectedErr = errors.New("some-error")
+		})
+
+		It("returns an error", func() {
+			Expect(actualErr).To(HaveOccurred())
+			Expect(actualOutput).To(gbytes.Say("Error waiting for postgres://app:xxxxx@db:5432/app to be ready: some-error"))
+		})
+	})
+
+	Describe("--query, --primary and --connect-timeout flags", func() {
+		BeforeEach(func() {
+			args = append(args, "--query", "SELECT 1", "--primary", "--connect-timeout", "2s", "-t", "1m")
+		})
+
+		It("are being used", func() {
+			Expect(pgcheck.WithQueryArgsForCall(0)).To(Equal("SELECT 1"))
+			Expect(pgcheck.WithTimeoutArgsForCall(0)).To(Equal(2 * time.Second))
+			Expect(pgcheck.IsPrimaryCallCount()).To(Equal(1))
+			Expect(actualTimeout).To(Equal(time.Minute))
+			Expect(actualOutput).To(gbytes.Say("to be primary"))
+		})
+	})
+
+	Context("when multiple DSNs have been specified", func() {
+		BeforeEach(func() {
+			args = []string{"host=primary password='s e cret'", "host=replica password=secret"}
+		})
+
+		It("waits for all of them", func() {
+			Expect(actualDSNs).To(HaveLen(2))
+			Expect(actualChecks).To(HaveLen(2))
+			Expect(actualOutput).To(gbytes.Say("host=primary password=xxxxx: ready"))
+			Expect(actualOutput).To(gbytes.Say("host=replica password=xxxxx: ready"))
+		})
+	})
+
+	Context("when used as target of the on command", func() {
+		BeforeEach(func() {
+			command = "on"
+			args = []string{"postgresql://db/app", "--query", "SELECT 1"}
+		})
+
+		It("waits for the server to be ready", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+			Expect(actualDSNs).To(ContainElement("postgresql://db/app"))
+			Expect(pgcheck.WithQueryArgsForCall(0)).To(Equal("SELECT 1"))
+		})
+	})
+
+	Context("when used in a config file", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "postgres")
+			Expect(err).ToNot(HaveOccurred())
+
+			config := `
+checks:
+- name: db
+  kind: postgres
+  dsn: postgres://db/app
+  query: SELECT 1
+  primary: true
+`
+			Expect(ioutil.WriteFile(filepath.Join(dir, "waitfor.yaml"), []byte(config), 0644)).To(Succeed())
+
+			command = "run"
+			args = []string{"-f", filepath.Join(dir, "waitfor.yaml")}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("waits for the server to be primary", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+			Expect(actualDSNs).To(ContainElement("postgres://db/app"))
+			Expect(pgcheck.WithQueryArgsForCall(0)).To(Equal("SELECT 1"))
+			Expect(pgcheck.IsPrimaryCallCount()).To(BeNumerically(">", 0))
+		})
+	})
+})
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codegangsta/cli"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+)
+
+var probeCheckProvider = check.Probe
+
+var probeCommand = cli.Command{
+	Name:  "probe",
+	Usage: "wait for a server speaking a simple text protocol to answer, one of " + strings.Join(check.Probes(), ", "),
+
+	ArgsUsage: "<kind> <address>...",
+
+	HideHelp: true,
+
+	Flags: []cli.Flag{
+		anyFlag,
+		connectTimeoutFlag,
+		timeoutFlag,
+		intervalFlag,
+		verboseFlag,
+		strictFlag,
+		traceFlag,
+		quietFlag,
+	},
+
+	Action: func(c *cli.Context) error {
+		names, conditions := probeConditions(c)
+		return waitFor(c, names, conditions, "servers", "ready")
+	},
+}
+
+// probeConditions returns the addresses given as positional arguments after
+// the kind of probe and the corresponding conditions.
+func probeConditions(c *cli.Context) ([]string, []waitfor.Check) {
+	args := positional(c)
+
+	if len(args) < 2 {
+		cli.ShowCommandHelp(c, "probe")
+		fmt.Fprintln(c.App.Writer, "must specify kind and address")
+		exitWith(c, exitUsage)
+	}
+
+	kind := args[0]
+	if !isProbe(kind) {
+		fmt.Fprintf(c.App.Writer, "unknown kind '%s', must be one of %s\n", kind, strings.Join(check.Probes(), ", "))
+		exitWith(c, exitUsage)
+	}
+
+	var (
+		names      []string
+		conditions []waitfor.Check
+	)
+
+	for _, addr := range args[1:] {
+		names = append(names, addr)
+		conditions = append(conditions, probeCondition(c, kind, addr))
+	}
+
+	return names, conditions
+}
+
+func probeCondition(c *cli.Context, kind, addr string) waitfor.Check {
+	target := currentWait(c).Target("probe", kind+"://"+addr)
+	probe := probeCheckProvider(kind, addr).WithLogger(targetLogger(c, target))
+
+	if timeout := c.Duration("connect-timeout"); timeout > 0 {
+		probe.WithTimeout(timeout)
+	}
+
+	return target.Check(probe.IsReady)
+}
+
+func isProbe(kind string) bool {
+	for _, name := range check.Probes() {
+		if name == kind {
+			return true
+		}
+	}
+	return false
+}
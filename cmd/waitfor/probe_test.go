@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+	"github.com/st3v/waitfor/cmd/waitfor/fake"
+)
+
+var _ = Describe("probe command", func() {
+	var (
+		probecheck  *fake.ProbeCheck
+		command     string
+		args        []string
+		expectedErr error
+
+		actualKinds   []string
+		actualAddrs   []string
+		actualChecks  []waitfor.Check
+		actualTimeout time.Duration
+		actualOutput  *gbytes.Buffer
+		actualErr     error
+	)
+
+	BeforeEach(func() {
+		probecheck = new(fake.ProbeCheck)
+		probecheck.WithTimeoutReturns(probecheck)
+		probecheck.WithLoggerReturns(probecheck)
+		probecheck.IsReadyReturns(true)
+		probeCheckProvider = func(kind, addr string) check.ProbeCheck {
+			actualKinds = append(actualKinds, kind)
+			actualAddrs = append(actualAddrs, addr)
+			return probecheck
+		}
+
+		command = "probe"
+		args = []string{"zookeeper", "zk1:2181"}
+		expectedErr = nil
+		actualKinds = nil
+		actualAddrs = nil
+		actualChecks = nil
+		actualOutput = gbytes.NewBuffer()
+
+		waitForConditionWithTimeout = func(check waitfor.Check, interval, timeout time.Duration, ctx context.Context) error {
+			check()
+			actualTimeout = timeout
+			return expectedErr
+		}
+
+		waitForAllWithTimeout = func(checks []waitfor.Check, interval, timeout time.Duration, ctx context.Context) []error {
+			actualChecks = checks
+			for _, check := range checks {
+				check()
+			}
+			return make([]error, len(checks))
+		}
+	})
+
+	JustBeforeEach(func() {
+		app := app()
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		actualErr = app.Run(append([]string{"waitfor", command}, args...))
+	})
+
+	It("waits for the server to answer the probe", func() {
+		Expect(actualKinds).To(Equal([]string{"zookeeper"}))
+		Expect(actualAddrs).To(Equal([]string{"zk1:2181"}))
+		Expect(probecheck.IsReadyCallCount()).To(Equal(1))
+		Expect(probecheck.WithTimeoutArgsForCall(0)).To(Equal(5 * time.Second))
+		Expect(actualErr).ToNot(HaveOccurred())
+		Expect(actualOutput).To(gbytes.Say("Success: zk1:2181 is ready"))
+	})
+
+	Context("when the check fails", func() {
+		BeforeEach(func() {
+			expectedErr = errors.New("some-error")
+		})
+
+		It("returns an error", func() {
+			Expect(actualErr).To(HaveOccurred())
+			Expect(actualOutput).To(gbytes.Say("Error waiting for zk1:2181 to be ready: some-error"))
+		})
+	})
+
+	Describe("flags", func() {
+		BeforeEach(func() {
+			args = append(args, "--connect-timeout", "2s", "-t", "1m")
+		})
+
+		It("are being used", func() {
+			Expect(probecheck.WithTimeoutArgsForCall(0)).To(Equal(2 * time.Second))
+			Expect(actualTimeout).To(Equal(time.Minute))
+		})
+	})
+
+	Context("when multiple addresses have been specified", func() {
+		BeforeEach(func() {
+			args = []string{"memcached", "cache1", "cache2:11212"}
+		})
+
+		It("waits for all of them", func() {
+			Expect(actualKinds).To(Equal([]string{"memcached", "memcached"}))
+			Expect(actualAddrs).To(Equal([]string{"cache1", "cache2:11212"}))
+			Expect(actualChecks).To(HaveLen(2))
+			Expect(actualOutput).To(gbytes.Say("cache1: ready"))
+			Expect(actualOutput).To(gbytes.Say("cache2:11212: ready"))
+		})
+	})
+
+	Context("when used incorrectly", func() {
+		var (
+			exitCode int
+			usage    []string
+		)
+
+		JustBeforeEach(func() {
+			exitCode = 0
+			exit = func(rc int) {
+				exitCode = rc
+				panic(rc)
+			}
+
+			app := app()
+			app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+			Expect(func() {
+				app.Run(append([]string{"waitfor", "probe"}, usage...))
+			}).To(Panic())
+		})
+
+		AfterEach(func() {
+			exit = os.Exit
+		})
+
+		Context("without an address", func() {
+			BeforeEach(func() {
+				usage = []string{"zookeeper"}
+			})
+
+			It("exits with the exit code for invalid usage", func() {
+				Expect(exitCode).To(Equal(exitUsage))
+				Expect(actualOutput).To(gbytes.Say("must specify kind and address"))
+			})
+		})
+
+		Context("with an unknown kind", func() {
+			BeforeEach(func() {
+				usage = []string{"gopher", "host:70"}
+			})
+
+			It("exits with the exit code for invalid usage", func() {
+				Expect(exitCode).To(Equal(exitUsage))
+				Expect(actualOutput).To(gbytes.Say("unknown kind 'gopher', must be one of .*zookeeper"))
+			})
+		})
+	})
+
+	Context("when used as target of the on command", func() {
+		BeforeEach(func() {
+			command = "on"
+			args = []string{"zookeeper://zk1:2181", "nats://nats"}
+		})
+
+		It("waits for the servers to answer the probes", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+			Expect(actualKinds).To(Equal([]string{"zookeeper", "nats"}))
+			Expect(actualAddrs).To(Equal([]string{"zk1:2181", "nats"}))
+		})
+	})
+
+	Context("when used in a config file", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "probe")
+			Expect(err).ToNot(HaveOccurred())
+
+			config := `
+checks:
+- name: jobs
+  kind: probe
+  probe: beanstalkd
+  host: queue
+  port: 11300
+`
+			Expect(ioutil.WriteFile(filepath.Join(dir, "waitfor.yaml"), []byte(config), 0644)).To(Succeed())
+
+			command = "run"
+			args = []string{"-f", filepath.Join(dir, "waitfor.yaml")}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("waits for the server to answer the probe", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+			Expect(actualKinds).To(Equal([]string{"beanstalkd"}))
+			Expect(actualAddrs).To(Equal([]string{"queue:11300"}))
+		})
+	})
+})
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codegangsta/cli"
+
+	"github.com/st3v/waitfor"
+)
+
+const progressKey = "progress"
+
+var (
+	isTerminal = func(w io.Writer) bool {
+		f, ok := w.(*os.File)
+		if !ok {
+			return false
+		}
+
+		info, err := f.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+
+	spinnerPeriod  = 100 * time.Millisecond
+	progressPeriod = 10 * time.Second
+)
+
+var spinner = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// progress reports elapsed and remaining time, the number of attempts and
+// the reason of the last failure while waiting. On a terminal, the report is
+// a single line updated in place, otherwise a line is printed periodically.
+// Checks log to the progress, which keeps the first line of the last message
+// of an attempt as the reason once the attempt has failed.
+type progress struct {
+	out     io.Writer
+	tty     bool
+	enabled bool
+
+	mutex    sync.Mutex
+	attempts int
+	last     string
+	reason   string
+}
+
+// trackActions wraps the actions of the app and all its commands, so that
+// every action reports the progress of its waits.
+func trackActions(app *cli.App) {
+	if app.Metadata == nil {
+		app.Metadata = map[string]interface{}{}
+	}
+
+	app.Action = trackAction(app.Action)
+	for i := range app.Commands {
+		app.Commands[i].Action = trackAction(app.Commands[i].Action)
+	}
+}
+
+func trackAction(action interface{}) func(*cli.Context) error {
+	return func(c *cli.Context) error {
+		c.App.Metadata[progressKey] = &progress{
+			out:     c.App.Writer,
+			tty:     isTerminal(c.App.Writer),
+			enabled: !quiet(c) && !verbose(c),
+		}
+
+		return action.(func(*cli.Context) error)(c)
+	}
+}
+
+// currentProgress returns the progress of the running action.
+func currentProgress(c *cli.Context) *progress {
+	if p, ok := c.App.Metadata[progressKey].(*progress); ok {
+		return p
+	}
+	return &progress{out: ioutil.Discard}
+}
+
+// track counts the attempts of the given conditions and reports the progress
+// of waiting for them until the returned function is called.
+func track(c *cli.Context, timeout time.Duration, conditions ...waitfor.Check) ([]waitfor.Check, func()) {
+	p := currentProgress(c)
+
+	tracked := make([]waitfor.Check, len(conditions))
+	for i, condition := range conditions {
+		tracked[i] = p.check(condition)
+	}
+
+	return tracked, p.start(timeout)
+}
+
+func (p *progress) Write(b []byte) (int, error) {
+	line := strings.SplitN(string(b), "\n", 2)[0]
+	line = strings.TrimSuffix(strings.TrimSpace(line), ":")
+
+	if line != "" {
+		p.mutex.Lock()
+		p.last = line
+		p.mutex.Unlock()
+	}
+
+	return len(b), nil
+}
+
+// Observe counts the attempts of a named check.
+func (p *progress) Observe(name, kind string, check waitfor.Check) waitfor.Check {
+	return p.check(check)
+}
+
+func (p *progress) check(check waitfor.Check) waitfor.Check {
+	return func() bool {
+		ok := check()
+
+		p.mutex.Lock()
+		p.attempts++
+		if !ok && p.last != "" {
+			p.reason = p.last
+		}
+		p.last = ""
+		p.mutex.Unlock()
+
+		return ok
+	}
+}
+
+// start reports the progress until the returned function is called, which
+// also clears the status line on a terminal.
+func (p *progress) start(timeout time.Duration) func() {
+	if !p.enabled {
+		return func() {}
+	}
+
+	period := progressPeriod
+	if p.tty {
+		period = spinnerPeriod
+	}
+
+	begin := time.Now()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		for frame := 0; ; frame++ {
+			select {
+			case <-done:
+				if p.tty {
+					fmt.Fprint(p.out, "\r\033[K")
+				}
+				return
+			case <-ticker.C:
+				p.report(frame, time.Since(begin), timeout)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func (p *progress) report(frame int, elapsed, timeout time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	remaining := timeout - elapsed
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	line := fmt.Sprintf("%s elapsed, %s remaining, attempts: %d", elapsed.Round(time.Second), remaining.Round(time.Second), p.attempts)
+	if p.reason != "" {
+		line = fmt.Sprintf("%s, last: %s", line, p.reason)
+	}
+
+	if p.tty {
+		fmt.Fprintf(p.out, "\r\033[K%s %s", spinner[frame%len(spinner)], line)
+		return
+	}
+
+	fmt.Fprintf(p.out, "Still waiting, %s\n", line)
+}
+
+// info returns the writer for informational messages, which are suppressed
+// by --quiet. Final results are always written to the app's writer.
+func info(c *cli.Context) io.Writer {
+	if quiet(c) {
+		return ioutil.Discard
+	}
+	return c.App.Writer
+}
+
+func quiet(c *cli.Context) bool {
+	return c.Bool("quiet") || c.GlobalBool("quiet")
+}
+
+func verbose(c *cli.Context) bool {
+	return c.Bool("verbose") || c.GlobalBool("verbose")
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+	"github.com/st3v/waitfor/cmd/waitfor/fake"
+)
+
+var _ = Describe("progress", func() {
+	var (
+		args         []string
+		tty          bool
+		actualOutput *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		portcheck := new(fake.PortCheck)
+		portcheck.OnHostReturns(portcheck)
+		portcheck.ForNetworkReturns(portcheck)
+		portcheck.WithPayloadReturns(portcheck)
+		portcheck.WithReplyTimeoutReturns(portcheck)
+		portcheck.WithNoReplyOKReturns(portcheck)
+		portcheck.WithLoggerReturns(portcheck)
+		portcheck.IsOpenStub = func() bool {
+			logger := portcheck.WithLoggerArgsForCall(0)
+			fmt.Fprintf(logger, "Dialing tcp://127.0.0.1:5432\n")
+			time.Sleep(20 * time.Millisecond)
+			fmt.Fprintf(logger, "dial tcp 127.0.0.1:5432: connect: connection refused\n")
+			return false
+		}
+		portCheckProvider = func(int) check.PortCheck {
+			return portcheck
+		}
+
+		waitForConditionWithTimeout = func(condition waitfor.Check, interval, timeout time.Duration, ctx context.Context) error {
+			for i := 0; i < 3; i++ {
+				condition()
+			}
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		}
+
+		tty = false
+		isTerminal = func(io.Writer) bool {
+			return tty
+		}
+		spinnerPeriod = 5 * time.Millisecond
+		progressPeriod = 5 * time.Millisecond
+
+		actualOutput = gbytes.NewBuffer()
+		args = []string{"waitfor", "port", "--timeout", "1m", "5432"}
+	})
+
+	AfterEach(func() {
+		spinnerPeriod = 100 * time.Millisecond
+		progressPeriod = 10 * time.Second
+	})
+
+	JustBeforeEach(func() {
+		app := app()
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		app.Run(args)
+	})
+
+	Context("when the output is a terminal", func() {
+		BeforeEach(func() {
+			tty = true
+		})
+
+		It("updates a status line in place", func() {
+			Expect(actualOutput).To(gbytes.Say(`Waiting for tcp://127.0.0.1:5432 to be open\.\.\.\n`))
+			Expect(actualOutput).To(gbytes.Say("\r\033\\[K. 0s elapsed, 1m0s remaining, attempts: \\d"))
+			Expect(actualOutput).To(gbytes.Say("attempts: 3, last: dial tcp 127.0.0.1:5432: connect: connection refused"))
+		})
+
+		It("only reports the reasons of failed attempts", func() {
+			Expect(actualOutput).ToNot(gbytes.Say("last: Dialing"))
+		})
+
+		It("clears the status line before printing the result", func() {
+			Expect(actualOutput).To(gbytes.Say("\r\033\\[KSuccess: tcp://127.0.0.1:5432 is open\n"))
+		})
+	})
+
+	Context("when the output is not a terminal", func() {
+		It("periodically prints the progress", func() {
+			Expect(actualOutput).To(gbytes.Say(`Still waiting, 0s elapsed, 1m0s remaining, attempts: \d`))
+			Expect(actualOutput).To(gbytes.Say(`Still waiting, 0s elapsed, 1m0s remaining, attempts: 3, last: dial tcp 127.0.0.1:5432: connect: connection refused\n`))
+			Expect(actualOutput).To(gbytes.Say("Success: tcp://127.0.0.1:5432 is open\n"))
+		})
+	})
+
+	Context("when --quiet has been set", func() {
+		BeforeEach(func() {
+			tty = true
+			args = []string{"waitfor", "port", "--quiet", "5432"}
+		})
+
+		It("only prints the final result", func() {
+			Expect(string(actualOutput.Contents())).To(Equal("Success: tcp://127.0.0.1:5432 is open\n"))
+		})
+	})
+
+	Context("when --verbose has been set", func() {
+		BeforeEach(func() {
+			args = []string{"waitfor", "port", "--verbose", "5432"}
+		})
+
+		It("does not report the progress", func() {
+			Expect(actualOutput).ToNot(gbytes.Say("Still waiting"))
+		})
+	})
+})
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/codegangsta/cli"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+)
+
+var redisCheckProvider = check.Redis
+
+var redisCommand = cli.Command{
+	Name:  "redis",
+	Usage: "wait for a Redis server to answer PING, e.g. once it has loaded its dataset",
+
+	ArgsUsage: "<addr>...",
+
+	HideHelp: true,
+
+	Flags: []cli.Flag{
+		usernameFlag,
+		passwordFlag,
+		tlsFlag,
+		insecureFlag,
+		infoFlag,
+		anyFlag,
+		connectTimeoutFlag,
+		timeoutFlag,
+		intervalFlag,
+		verboseFlag,
+		strictFlag,
+		traceFlag,
+		quietFlag,
+	},
+
+	Action: func(c *cli.Context) error {
+		names, conditions := redisConditions(c)
+		return waitFor(c, names, conditions, "servers", "ready")
+	},
+}
+
+// redisConditions returns the addresses given as positional arguments,
+// without passwords, and the corresponding conditions.
+func redisConditions(c *cli.Context) ([]string, []waitfor.Check) {
+	if !positional(c).Present() {
+		cli.ShowCommandHelp(c, "redis")
+		fmt.Fprintln(c.App.Writer, "must specify address")
+		exitWith(c, exitUsage)
+	}
+
+	var (
+		names      []string
+		conditions []waitfor.Check
+	)
+
+	for _, addr := range positional(c) {
+		names = append(names, redactDSN(addr))
+		conditions = append(conditions, redisCondition(c, addr))
+	}
+
+	return names, conditions
+}
+
+func redisCondition(c *cli.Context, addr string) waitfor.Check {
+	target := currentWait(c).Target("redis", redactDSN(addr))
+	redis := redisCheckProvider(addr).WithLogger(targetLogger(c, target))
+
+	if timeout := c.Duration("connect-timeout"); timeout > 0 {
+		redis.WithTimeout(timeout)
+	}
+
+	if username := c.String("username"); username != "" {
+		redis.WithUsername(username)
+	}
+
+	if password := c.String("password"); password != "" {
+		redis.WithPassword(password)
+	}
+
+	if config := tlsConfig(c); config != nil {
+		redis.WithTLS(config)
+	}
+
+	for _, field := range c.StringSlice("info") {
+		redis.WithInfo(field)
+	}
+
+	return target.Check(redis.IsReady)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+	"github.com/st3v/waitfor/cmd/waitfor/fake"
+)
+
+var _ = Describe("redis command", func() {
+	var (
+		redischeck  *fake.RedisCheck
+		command     string
+		args        []string
+		expectedErr error
+
+		actualAddrs   []string
+		actualChecks  []waitfor.Check
+		actualTimeout time.Duration
+		actualOutput  *gbytes.Buffer
+		actualErr     error
+	)
+
+	BeforeEach(func() {
+		redischeck = new(fake.RedisCheck)
+		redischeck.WithUsernameReturns(redischeck)
+		redischeck.WithPasswordReturns(redischeck)
+		redischeck.WithTLSReturns(redischeck)
+		redischeck.WithInfoReturns(redischeck)
+		redischeck.WithTimeoutReturns(redischeck)
+		redischeck.WithLoggerReturns(redischeck)
+		redischeck.IsReadyReturns(true)
+		redisCheckProvider = func(addr string) check.RedisCheck {
+			actualAddrs = append(actualAddrs, addr)
+			return redischeck
+		}
+
+		command = "redis"
+		args = []string{"cache:6379"}
+		expectedErr = nil
+		actualAddrs = nil
+		actualChecks = nil
+		actualOutput = gbytes.NewBuffer()
+
+		waitForConditionWithTimeout = func(check waitfor.Check, interval, timeout time.Duration, ctx context.Context) error {
+			check()
+			actualTimeout = timeout
+			return expectedErr
+		}
+
+		waitForAllWithTimeout = func(checks []waitfor.Check, interval, timeout time.Duration, ctx context.Context) []error {
+			actualChecks = checks
+			for _, check := range checks {
+				check()
+			}
+			return make([]error, len(checks))
+		}
+	})
+
+	JustBeforeEach(func() {
+		app := app()
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		actualErr = app.Run(append([]string{"waitfor", command}, args...))
+	})
+
+	It("waits for the server to be ready", func() {
+		Expect(actualAddrs).To(Equal([]string{"cache:6379"}))
+		Expect(redischeck.IsReadyCallCount()).To(Equal(1))
+		Expect(redischeck.WithPasswordCallCount()).To(Equal(0))
+		Expect(redischeck.WithTLSCallCount()).To(Equal(0))
+		Expect(redischeck.WithTimeoutArgsForCall(0)).To(Equal(5 * time.Second))
+		Expect(actualOutput).To(gbytes.Say("Success: cache:6379 is ready"))
+		Expect(actualErr).ToNot(HaveOccurred())
+	})
+
+	Context("when the check fails", func() {
+		BeforeEach(func() {
+			expectedErr = errors.New("some-error")
+		})
+
+		It("returns an error", func() {
+			Expect(actualErr).To(HaveOccurred())
+			Expect(actualOutput).To(gbytes.Say("Error waiting for cache:6379 to be ready: some-error"))
+		})
+	})
+
+	Describe("flags", func() {
+		BeforeEach(func() {
+			args = append(args,
+				"--username", "app",
+				"--password", "secret",
+				"--tls",
+				"--info", "loading:0",
+				"--info", "role:master",
+				"--connect-timeout", "2s",
+				"-t", "1m",
+			)
+		})
+
+		It("are being used", func() {
+			Expect(redischeck.WithUsernameArgsForCall(0)).To(Equal("app"))
+			Expect(redischeck.WithPasswordArgsForCall(0)).To(Equal("secret"))
+			Expect(redischeck.WithTLSArgsForCall(0)).To(Equal(&tls.Config{}))
+			Expect(redischeck.WithInfoCallCount()).To(Equal(2))
+			Expect(redischeck.WithInfoArgsForCall(0)).To(Equal("loading:0"))
+			Expect(redischeck.WithInfoArgsForCall(1)).To(Equal("role:master"))
+			Expect(redischeck.WithTimeoutArgsForCall(0)).To(Equal(2 * time.Second))
+			Expect(actualTimeout).To(Equal(time.Minute))
+		})
+	})
+
+	Describe("--insecure flag", func() {
+		BeforeEach(func() {
+			args = append(args, "-k")
+		})
+
+		It("connects using TLS without verification", func() {
+			Expect(redischeck.WithTLSArgsForCall(0)).To(Equal(&tls.Config{InsecureSkipVerify: true}))
+		})
+	})
+
+	Context("when multiple addresses have been specified", func() {
+		BeforeEach(func() {
+			args = []string{"redis://:secret@primary", "rediss://replica:6380"}
+		})
+
+		It("waits for all of them", func() {
+			Expect(actualAddrs).To(HaveLen(2))
+			Expect(actualChecks).To(HaveLen(2))
+			Expect(actualOutput).To(gbytes.Say("redis://:xxxxx@primary: ready"))
+			Expect(actualOutput).To(gbytes.Say("rediss://replica:6380: ready"))
+		})
+	})
+
+	Context("when no address has been specified", func() {
+		var exitCode int
+
+		JustBeforeEach(func() {
+			exitCode = 0
+			exit = func(rc int) {
+				exitCode = rc
+				panic(rc)
+			}
+
+			app := app()
+			app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+			Expect(func() {
+				app.Run([]string{"waitfor", "redis"})
+			}).To(Panic())
+		})
+
+		AfterEach(func() {
+			exit = os.Exit
+		})
+
+		It("exits with the exit code for invalid usage", func() {
+			Expect(exitCode).To(Equal(exitUsage))
+			Expect(actualOutput).To(gbytes.Say("must specify address"))
+		})
+	})
+
+	Context("when used as target of the on command", func() {
+		BeforeEach(func() {
+			command = "on"
+			args = []string{"rediss://cache", "--info", "loading:0"}
+		})
+
+		It("waits for the server to be ready", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+			Expect(actualAddrs).To(ContainElement("rediss://cache"))
+			Expect(redischeck.WithInfoArgsForCall(0)).To(Equal("loading:0"))
+		})
+	})
+
+	Context("when used in a config file", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "redis")
+			Expect(err).ToNot(HaveOccurred())
+
+			config := `
+checks:
+- name: cache
+  kind: redis
+  host: cache
+  port: 6380
+  password: secret
+  info:
+  - role:master
+`
+			Expect(ioutil.WriteFile(filepath.Join(dir, "waitfor.yaml"), []byte(config), 0644)).To(Succeed())
+
+			command = "run"
+			args = []string{"-f", filepath.Join(dir, "waitfor.yaml")}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("waits for the server to be ready", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+			Expect(actualAddrs).To(ContainElement("cache:6380"))
+			Expect(redischeck.WithPasswordArgsForCall(0)).To(Equal("secret"))
+			Expect(redischeck.WithInfoArgsForCall(0)).To(Equal("role:master"))
+		})
+	})
+})
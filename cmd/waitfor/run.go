@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"text/tabwriter"
+
+	"github.com/codegangsta/cli"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/metrics"
+)
+
+func status(r waitfor.Result) string {
+	switch {
+	case r.BlockedBy != "":
+		return fmt.Sprintf("blocked by %s", r.BlockedBy)
+	case r.Err != nil:
+		return r.Err.Error()
+	}
+	return "ready"
+}
+
+func serveMetrics(c *cli.Context, addr string, observer *metrics.Observer) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", observer)
+
+	if err := listenAndServe(addr, mux); err != nil {
+		fmt.Fprintf(c.App.Writer, "Error serving metrics: %s\n", err)
+	}
+}
+
+var runCommand = cli.Command{
+	Name:  "run",
+	Usage: "wait for the checks described in a YAML or JSON config file",
+
+	HideHelp: true,
+
+	Flags: []cli.Flag{
+		fileFlag,
+		metricsFlag,
+		timeoutFlag,
+		intervalFlag,
+		verboseFlag,
+		strictFlag,
+		traceFlag,
+		quietFlag,
+	},
+
+	Action: func(c *cli.Context) error {
+		path := c.String("file")
+
+		cfg, err := loadConfig(path)
+		if err != nil {
+			fmt.Fprintf(c.App.Writer, "invalid config file '%s': %s\n", path, err)
+			exitWith(c, exitUsage)
+			return usageError(err)
+		}
+
+		if cfg.Timeout == 0 || c.IsSet("timeout") {
+			cfg.Timeout = c.Duration("timeout")
+		}
+
+		if cfg.Interval == 0 || c.IsSet("interval") {
+			cfg.Interval = c.Duration("interval")
+		}
+
+		observers := []checkObserver{currentProgress(c)}
+		if addr := c.String("metrics"); addr != "" {
+			observer := metrics.NewObserver()
+			observers = append(observers, observer)
+			go serveMetrics(c, addr, observer)
+		}
+
+		ctx, cancel := context.WithTimeout(waitContext(c), cfg.Timeout)
+		defer cancel()
+
+		graph, err := cfg.graph(logger(c), currentWait(c), ctx, observers...)
+		if err != nil {
+			fmt.Fprintf(c.App.Writer, "invalid config file '%s': %s\n", path, err)
+			exitWith(c, exitUsage)
+			return usageError(err)
+		}
+
+		fmt.Fprintf(info(c), "Waiting for %d checks from %s...\n", len(cfg.Checks), path)
+
+		stop := currentProgress(c).start(cfg.Timeout)
+		results, err := graph.WaitWithContext(cfg.Interval, ctx)
+		stop()
+
+		w := tabwriter.NewWriter(info(c), 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tKIND\tSTATUS\tDURATION")
+
+		for i, check := range cfg.Checks {
+			duration := "-"
+			if results[i].Duration > 0 {
+				duration = results[i].Duration.String()
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", check.Name, check.Kind, status(results[i]), duration)
+		}
+		w.Flush()
+
+		if err != nil {
+			fmt.Fprintf(c.App.Writer, "Error waiting for checks: %s\n", err)
+			return err
+		}
+
+		fmt.Fprintf(c.App.Writer, "Success: %d of %d checks ready\n", len(cfg.Checks), len(cfg.Checks))
+		return nil
+	},
+}
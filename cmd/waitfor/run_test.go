@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("run command", func() {
+	var (
+		app = app()
+
+		dir        string
+		configFile string
+		config     string
+		args       []string
+
+		actualOutput *gbytes.Buffer
+		actualErr    error
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "run")
+		Expect(err).ToNot(HaveOccurred())
+
+		configFile = filepath.Join(dir, "waitfor.yaml")
+		Expect(ioutil.WriteFile(filepath.Join(dir, "present"), []byte{}, 0644)).To(Succeed())
+
+		fileCheckProvider = check.File
+
+		args = []string{}
+		actualOutput = gbytes.NewBuffer()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	JustBeforeEach(func() {
+		Expect(ioutil.WriteFile(configFile, []byte(config), 0644)).To(Succeed())
+
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		actualErr = app.Run(append([]string{"waitfor", "run", "-f", configFile}, args...))
+	})
+
+	Context("when all checks succeed", func() {
+		BeforeEach(func() {
+			config = fmt.Sprintf(`
+timeout: 1s
+interval: 10ms
+checks:
+- name: first
+  kind: file
+  path: %[1]s/present
+- name: second
+  kind: file
+  path: %[1]s/present
+  depends_on: [first]
+`, dir)
+		})
+
+		It("does not return an error", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+		})
+
+		It("prints a summary", func() {
+			Expect(actualOutput).To(gbytes.Say("Waiting for 2 checks from " + configFile))
+			Expect(actualOutput).To(gbytes.Say(`NAME\s+KIND\s+STATUS\s+DURATION`))
+			Expect(actualOutput).To(gbytes.Say(`first\s+file\s+ready`))
+			Expect(actualOutput).To(gbytes.Say(`second\s+file\s+ready`))
+			Expect(actualOutput).To(gbytes.Say("Success: 2 of 2 checks ready"))
+		})
+	})
+
+	Context("when a check fails", func() {
+		BeforeEach(func() {
+			config = fmt.Sprintf(`{
+  "timeout": "100ms",
+  "interval": "10ms",
+  "checks": [
+    {"name": "missing", "kind": "file", "path": "%[1]s/missing"},
+    {"name": "dependent", "kind": "file", "path": "%[1]s/present", "depends_on": ["missing"]},
+    {"name": "independent", "kind": "file", "path": "%[1]s/present"}
+  ]
+}`, dir)
+		})
+
+		It("returns an error", func() {
+			Expect(actualErr).To(MatchError("check 'missing' failed: timeout exceeded, blocking 'dependent'"))
+		})
+
+		It("reports the failing and blocked checks", func() {
+			Expect(actualOutput).To(gbytes.Say(`missing\s+file\s+timeout exceeded`))
+			Expect(actualOutput).To(gbytes.Say(`dependent\s+file\s+blocked by missing\s+-`))
+			Expect(actualOutput).To(gbytes.Say(`independent\s+file\s+ready`))
+		})
+	})
+
+	Context("when a check has its own timeout", func() {
+		BeforeEach(func() {
+			config = fmt.Sprintf(`
+timeout: 1m
+interval: 10ms
+checks:
+- name: missing
+  kind: file
+  path: %s/missing
+  timeout: 50ms
+`, dir)
+		})
+
+		It("is being used", func() {
+			Expect(actualOutput).To(gbytes.Say(`missing\s+file\s+timeout exceeded`))
+		})
+	})
+
+	Context("when the config is invalid", func() {
+		var exitCode int
+
+		BeforeEach(func() {
+			exitCode = 0
+			exit = func(rc int) {
+				exitCode = rc
+			}
+
+			config = `
+checks:
+- name: first
+  kind: file
+  path: /tmp
+  depends_on: [unknown]
+`
+		})
+
+		AfterEach(func() {
+			exit = os.Exit
+		})
+
+		It("exits with the exit code for invalid usage", func() {
+			Expect(exitCode).To(Equal(exitUsage))
+		})
+
+		It("provides a corresponding error", func() {
+			Expect(actualOutput).To(gbytes.Say("check 'first' depends on unknown check 'unknown'"))
+		})
+
+		Context("because of a misspelled key", func() {
+			BeforeEach(func() {
+				config = `
+checks:
+- name: first
+  kind: file
+  path: /tmp
+  timout: 1s
+`
+			})
+
+			It("provides a corresponding error", func() {
+				Expect(exitCode).To(Equal(exitUsage))
+				Expect(actualOutput).To(gbytes.Say("field timout not found"))
+			})
+		})
+
+		Context("because of a dependency cycle", func() {
+			BeforeEach(func() {
+				config = `
+checks:
+- name: first
+  kind: file
+  path: /tmp
+  depends_on: [second]
+- name: second
+  kind: file
+  path: /tmp
+  depends_on: [first]
+`
+			})
+
+			It("provides a corresponding error", func() {
+				Expect(exitCode).To(Equal(exitUsage))
+				Expect(actualOutput).To(gbytes.Say("dependency cycle detected: first -> second -> first"))
+			})
+		})
+	})
+})
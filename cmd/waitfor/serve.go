@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/metrics"
+)
+
+var listenAndServe = http.ListenAndServe
+
+type checkStatus struct {
+	Name        string    `json:"name"`
+	Kind        string    `json:"kind"`
+	Ready       bool      `json:"ready"`
+	Attempts    int       `json:"attempts"`
+	BlockedBy   string    `json:"blocked_by,omitempty"`
+	LastChecked time.Time `json:"last_checked"`
+	LastChanged time.Time `json:"last_changed"`
+}
+
+// statusServer continuously evaluates checks and serves their cached results.
+type statusServer struct {
+	mutex    sync.RWMutex
+	statuses []checkStatus
+	index    map[string]int
+	mux      *http.ServeMux
+}
+
+func newStatusServer(cfg config, observer *metrics.Observer) *statusServer {
+	s := &statusServer{
+		statuses: make([]checkStatus, len(cfg.Checks)),
+		index:    make(map[string]int, len(cfg.Checks)),
+		mux:      http.NewServeMux(),
+	}
+
+	for i, c := range cfg.Checks {
+		s.statuses[i] = checkStatus{Name: c.Name, Kind: c.Kind}
+		s.index[c.Name] = i
+	}
+
+	s.mux.HandleFunc("/live", s.live)
+	s.mux.HandleFunc("/ready", s.ready)
+	s.mux.HandleFunc("/status", s.status)
+	s.mux.HandleFunc("/status/", s.status)
+	s.mux.Handle("/metrics", observer)
+
+	return s
+}
+
+// watch evaluates each node on its interval until the context is done. A
+// node is only evaluated while all its dependencies are ready, and every
+// attempt is limited by the node's timeout.
+func (s *statusServer) watch(nodes []waitfor.Node, interval time.Duration, ctx context.Context) {
+	for i, n := range nodes {
+		if n.Interval == 0 {
+			n.Interval = interval
+		}
+
+		condition := n.Check
+		if n.Timeout != 0 {
+			condition = attemptWithTimeout(condition, n.Timeout)
+		}
+
+		go waitfor.Watch(s.afterDependencies(i, n.DependsOn, condition), n.Interval, s.observer(i), ctx)
+	}
+}
+
+// afterDependencies returns a condition that fails without being evaluated
+// as long as one of the given dependencies is not ready.
+func (s *statusServer) afterDependencies(i int, dependencies []string, condition waitfor.Check) waitfor.Check {
+	return func() bool {
+		s.mutex.Lock()
+		blockedBy := ""
+		for _, dep := range dependencies {
+			if !s.statuses[s.index[dep]].Ready {
+				blockedBy = dep
+				break
+			}
+		}
+		s.statuses[i].BlockedBy = blockedBy
+		s.mutex.Unlock()
+
+		return blockedBy == "" && condition()
+	}
+}
+
+// attemptWithTimeout returns a condition that fails if an attempt takes
+// longer than the given timeout. An attempt that is still running is not
+// started again, the next evaluation waits for its result instead.
+func attemptWithTimeout(condition waitfor.Check, timeout time.Duration) waitfor.Check {
+	var pending chan bool
+
+	return func() bool {
+		if pending == nil {
+			pending = make(chan bool, 1)
+			go func(result chan bool) {
+				result <- condition()
+			}(pending)
+		}
+
+		select {
+		case ready := <-pending:
+			pending = nil
+			return ready
+		case <-time.After(timeout):
+			return false
+		}
+	}
+}
+
+func (s *statusServer) observer(i int) func(bool) bool {
+	return func(ready bool) bool {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		now := time.Now()
+		status := &s.statuses[i]
+
+		if status.Attempts == 0 || status.Ready != ready {
+			status.LastChanged = now
+		}
+
+		status.Ready = ready
+		status.Attempts++
+		status.LastChecked = now
+
+		return true
+	}
+}
+
+func (s *statusServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *statusServer) live(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+func (s *statusServer) ready(w http.ResponseWriter, r *http.Request) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var pending []string
+	for _, status := range s.statuses {
+		switch {
+		case status.BlockedBy != "":
+			pending = append(pending, fmt.Sprintf("%s (blocked by %s)", status.Name, status.BlockedBy))
+		case !status.Ready:
+			pending = append(pending, status.Name)
+		}
+	}
+
+	if len(pending) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "not ready: %s\n", strings.Join(pending, ", "))
+		return
+	}
+
+	fmt.Fprintln(w, "ready")
+}
+
+func (s *statusServer) status(w http.ResponseWriter, r *http.Request) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var body interface{} = s.statuses
+
+	if name := strings.TrimPrefix(r.URL.Path, "/status/"); name != r.URL.Path && name != "" {
+		i, ok := s.index[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		body = s.statuses[i]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+var serveCommand = cli.Command{
+	Name:  "serve",
+	Usage: "continuously evaluate the checks in a config file and serve their status over HTTP",
+
+	HideHelp: true,
+
+	Flags: []cli.Flag{
+		fileFlag,
+		listenFlag,
+		intervalFlag,
+		verboseFlag,
+	},
+
+	Action: func(c *cli.Context) error {
+		path := c.String("file")
+		addr := c.String("listen")
+
+		cfg, err := loadConfig(path)
+		if err != nil {
+			fmt.Fprintf(c.App.Writer, "invalid config file '%s': %s\n", path, err)
+			exitWith(c, exitUsage)
+			return usageError(err)
+		}
+
+		if cfg.Interval == 0 || c.IsSet("interval") {
+			cfg.Interval = c.Duration("interval")
+		}
+
+		observer := metrics.NewObserver()
+
+		ctx := waitContext(c)
+
+		graph, err := cfg.graph(logger(c), noopWait(), ctx, observer)
+		if err != nil {
+			fmt.Fprintf(c.App.Writer, "invalid config file '%s': %s\n", path, err)
+			exitWith(c, exitUsage)
+			return usageError(err)
+		}
+
+		server := newStatusServer(cfg, observer)
+		server.watch(graph.Nodes(), cfg.Interval, ctx)
+
+		fmt.Fprintf(c.App.Writer, "Serving status of %d checks from %s on %s...\n", len(cfg.Checks), path, addr)
+
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- listenAndServe(addr, server)
+		}()
+
+		select {
+		case err := <-errChan:
+			if err != nil {
+				fmt.Fprintf(c.App.Writer, "Error serving status: %s\n", err)
+				return internalError(err)
+			}
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	},
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("serve command", func() {
+	var (
+		app = app()
+
+		dir     string
+		config  string
+		handler http.Handler
+		stop    chan struct{}
+		done    chan struct{}
+
+		actualAddr   string
+		actualOutput *gbytes.Buffer
+	)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", path, nil)
+		Expect(err).ToNot(HaveOccurred())
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	statuses := func() []checkStatus {
+		var statuses []checkStatus
+		Expect(json.Unmarshal(get("/status").Body.Bytes(), &statuses)).To(Succeed())
+		return statuses
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "serve")
+		Expect(err).ToNot(HaveOccurred())
+
+		config = fmt.Sprintf(`
+interval: 10ms
+checks:
+- name: first
+  kind: file
+  path: %[1]s/first
+- name: second
+  kind: file
+  path: %[1]s/second
+`, dir)
+		Expect(ioutil.WriteFile(filepath.Join(dir, "first"), []byte{}, 0644)).To(Succeed())
+
+		fileCheckProvider = check.File
+	})
+
+	JustBeforeEach(func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "waitfor.yaml"), []byte(config), 0644)).To(Succeed())
+
+		served := make(chan http.Handler, 1)
+		stop = make(chan struct{})
+		listenAndServe = func(addr string, h http.Handler) error {
+			actualAddr = addr
+			served <- h
+			<-stop
+			return nil
+		}
+
+		actualOutput = gbytes.NewBuffer()
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		done = make(chan struct{})
+		go func() {
+			defer close(done)
+			app.Run([]string{"waitfor", "serve", "-f", filepath.Join(dir, "waitfor.yaml"), "--listen", ":9999"})
+		}()
+
+		Eventually(served).Should(Receive(&handler))
+	})
+
+	AfterEach(func() {
+		close(stop)
+		Eventually(done).Should(BeClosed())
+		listenAndServe = http.ListenAndServe
+		os.RemoveAll(dir)
+	})
+
+	It("listens on the specified address", func() {
+		Expect(actualAddr).To(Equal(":9999"))
+		Expect(actualOutput).To(gbytes.Say("Serving status of 2 checks"))
+	})
+
+	It("is always live", func() {
+		Expect(get("/live").Code).To(Equal(http.StatusOK))
+	})
+
+	It("reports per-check status", func() {
+		Eventually(func() int { return statuses()[1].Attempts }).Should(BeNumerically(">", 1))
+
+		s := statuses()
+		Expect(s[0].Name).To(Equal("first"))
+		Expect(s[0].Kind).To(Equal("file"))
+		Expect(s[0].Ready).To(BeTrue())
+		Expect(s[1].Name).To(Equal("second"))
+		Expect(s[1].Ready).To(BeFalse())
+	})
+
+	It("reports the status of a single check", func() {
+		Eventually(func() string { return get("/status/first").Body.String() }).Should(ContainSubstring(`"ready":true`))
+		Expect(get("/status/unknown").Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("exposes metrics", func() {
+		Eventually(func() string { return get("/metrics").Body.String() }).Should(ContainSubstring(`waitfor_check_up{check="first",kind="file"} 1`))
+		Expect(get("/metrics").Body.String()).To(ContainSubstring(`waitfor_check_up{check="second",kind="file"} 0`))
+	})
+
+	It("is ready once all checks are ready", func() {
+		Eventually(func() string { return get("/ready").Body.String() }).Should(Equal("not ready: second\n"))
+		Expect(get("/ready").Code).To(Equal(http.StatusServiceUnavailable))
+
+		Expect(ioutil.WriteFile(filepath.Join(dir, "second"), []byte{}, 0644)).To(Succeed())
+		Eventually(func() int { return get("/ready").Code }).Should(Equal(http.StatusOK))
+	})
+
+	Context("when a check depends on another one", func() {
+		BeforeEach(func() {
+			config += fmt.Sprintf(`
+- name: third
+  kind: file
+  path: %s/first
+  depends_on: [second]
+`, dir)
+		})
+
+		It("is not evaluated before the dependency is ready", func() {
+			Eventually(func() string { return get("/ready").Body.String() }).Should(Equal("not ready: second, third (blocked by second)\n"))
+			Expect(statuses()[2].BlockedBy).To(Equal("second"))
+			Expect(statuses()[2].Ready).To(BeFalse())
+
+			Expect(ioutil.WriteFile(filepath.Join(dir, "second"), []byte{}, 0644)).To(Succeed())
+			Eventually(func() int { return get("/ready").Code }).Should(Equal(http.StatusOK))
+			Expect(statuses()[2].BlockedBy).To(BeEmpty())
+		})
+	})
+
+	Context("when an attempt hangs", func() {
+		BeforeEach(func() {
+			config += `
+- name: hanging
+  kind: sh
+  command: [sleep, "1"]
+  timeout: 20ms
+`
+		})
+
+		It("counts as failed once the check's timeout has been exceeded", func() {
+			Eventually(func() int { return statuses()[2].Attempts }, "500ms").Should(BeNumerically(">", 2))
+			Expect(statuses()[2].Ready).To(BeFalse())
+		})
+	})
+})
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
-	"regexp"
+	"strings"
+	"time"
 
 	"github.com/codegangsta/cli"
+	"golang.org/x/net/context"
 
 	"github.com/st3v/waitfor"
 	"github.com/st3v/waitfor/check"
@@ -14,8 +18,8 @@ import (
 var shellCommand = cli.Command{
 	Name:            "sh",
 	Aliases:         []string{"shell"},
-	Flags:           []cli.Flag{closedFlag},
-	Usage:           "wait for arbitrary shell commands to succeed (or fail)",
+	Flags:           shellFlags,
+	Usage:           "wait for arbitrary shell commands to succeed (or fail), flags must precede the command",
 	SkipFlagParsing: true,
 	Action:          shellAction,
 }
@@ -23,26 +27,67 @@ var shellCommand = cli.Command{
 var shellAction = func(c *cli.Context) error {
 	timeout := c.GlobalDuration("timeout")
 	interval := c.GlobalDuration("interval")
-	verbose := c.GlobalBool("verbose")
+
+	ctx, cancel := context.WithTimeout(waitContext(c), timeout)
+	defer cancel()
+
+	cmd, checkFunc, state := shellCondition(c, ctx)
+
+	fmt.Fprintf(info(c), "Waiting for %s to %s\n", cmd, state)
+
+	conditions, stop := track(c, timeout, checkFunc)
+	err := waitfor.ConditionWithContext(conditions[0], interval, ctx)
+	stop()
+
+	if err != nil {
+		fmt.Fprintf(c.App.Writer, "Error waiting for %s: %s\n", cmd, err)
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "Success: %s did %s\n", cmd, state)
+	return nil
+}
+
+// shellConditions returns the command given as positional arguments and the
+// corresponding condition. The command is killed once the wait is over.
+func shellConditions(c *cli.Context) ([]string, []waitfor.Check) {
+	cmd, condition, _ := shellCondition(c, waitContext(c))
+	return []string{cmd}, []waitfor.Check{condition}
+}
+
+// shellCondition returns the name of the command given as positional
+// arguments, the condition and the state it waits for. The command is killed
+// once ctx is done.
+func shellCondition(c *cli.Context, ctx context.Context) (string, waitfor.Check, string) {
 	fail := c.GlobalBool("fail")
 	exitCode := c.GlobalInt("status")
 	match := c.GlobalString("match")
 
-	cmd := c.Args().First()
-	args := c.Args().Tail()
+	opts := shellOptions(c)
 
-	// parts := strings.Split(cmd, " ")
-	// if len(parts) > 1 {
-	// 	cmd = parts[0]
-	// 	args = append(parts[1:], args...)
-	// }
+	if len(opts.args) == 0 {
+		cli.ShowAppHelp(c)
+		fmt.Fprintln(c.App.Writer, "must specify command")
+		exitWith(c, exitUsage)
+		return "", nil, ""
+	}
 
-	command := check.Command(cmd, args...).WithStdin(os.Stdin)
+	cmd := opts.args[0]
+	command := check.Command(cmd, opts.args[1:]...)
 
-	if verbose {
-		command.WithLogger(c.App.Writer)
+	if opts.shell {
+		cmd = strings.Join(opts.args, " ")
+		command = check.Command(opts.interpreter, "-c", cmd)
 	}
 
+	target := currentWait(c).Target("sh", cmd)
+	command.WithStdin(os.Stdin).
+		WithContext(ctx).
+		WithLogger(targetLogger(c, target)).
+		WithDir(opts.workdir).
+		WithExtraEnv(opts.env).
+		WithRunTimeout(opts.timeoutPerRun)
+
 	checkFunc := command.Succeeds
 	state := "succeed"
 
@@ -59,7 +104,7 @@ var shellAction = func(c *cli.Context) error {
 	}
 
 	if match != "" {
-		r := regexp.MustCompile(match)
+		r := matchRegexp(c, match)
 		checkFunc = func() bool {
 			return command.MatchesOutput(r)
 		}
@@ -67,12 +112,61 @@ var shellAction = func(c *cli.Context) error {
 		state = fmt.Sprintf("match regex '%s'", match)
 	}
 
-	fmt.Fprintf(c.App.Writer, "Waiting for %s to %s\n", cmd, state)
-	if err := waitfor.ConditionWithTimeout(checkFunc, interval, timeout); err != nil {
-		fmt.Fprintf(c.App.Writer, "Error waiting for %s: %s\n", cmd, err)
-		return err
+	return cmd, target.Check(checkFunc), state
+}
+
+type shellOpts struct {
+	shell         bool
+	interpreter   string
+	workdir       string
+	env           []string
+	timeoutPerRun time.Duration
+	args          []string
+}
+
+// shellOptions returns the options of the command to run and its arguments.
+// The sh command skips flag parsing to leave the flags of the command to run
+// untouched. Its own flags must therefore precede the command and are parsed
+// here, using the global flags as defaults.
+func shellOptions(c *cli.Context) shellOpts {
+	opts := shellOpts{
+		shell:         c.GlobalBool("shell"),
+		interpreter:   c.GlobalString("interpreter"),
+		workdir:       c.GlobalString("workdir"),
+		env:           c.GlobalStringSlice("env"),
+		timeoutPerRun: c.GlobalDuration("timeout-per-run"),
+		args:          positional(c),
 	}
 
-	fmt.Fprintf(c.App.Writer, "Success: %s did %s\n", cmd, state)
-	return nil
+	if c.Command.SkipFlagParsing {
+		env := cli.StringSlice(opts.env)
+
+		set := flag.NewFlagSet(c.Command.Name, flag.ContinueOnError)
+		set.SetOutput(ioutil.Discard)
+		set.BoolVar(&opts.shell, "shell", opts.shell, "")
+		set.BoolVar(&opts.shell, "c", opts.shell, "")
+		set.StringVar(&opts.interpreter, "interpreter", opts.interpreter, "")
+		set.StringVar(&opts.workdir, "workdir", opts.workdir, "")
+		set.StringVar(&opts.workdir, "w", opts.workdir, "")
+		set.Var(&env, "env", "")
+		set.Var(&env, "e", "")
+		set.DurationVar(&opts.timeoutPerRun, "timeout-per-run", opts.timeoutPerRun, "")
+
+		if err := set.Parse(opts.args); err != nil {
+			onUsageError(c, err, false)
+			exitWith(c, exitUsage)
+		}
+
+		opts.env = env
+		opts.args = set.Args()
+	}
+
+	for _, e := range opts.env {
+		if !strings.Contains(e, "=") {
+			fmt.Fprintf(c.App.Writer, "invalid env '%s', must be KEY=VAL\n", e)
+			exitWith(c, exitUsage)
+		}
+	}
+
+	return opts
 }
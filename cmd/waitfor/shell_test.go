@@ -0,0 +1,141 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("sh command", func() {
+	var (
+		args         []string
+		actualOutput *gbytes.Buffer
+		actualErr    error
+	)
+
+	BeforeEach(func() {
+		actualOutput = gbytes.NewBuffer()
+	})
+
+	JustBeforeEach(func() {
+		app := app()
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		actualErr = app.Run(args)
+	})
+
+	Describe("--shell flag", func() {
+		BeforeEach(func() {
+			args = []string{"waitfor", "-t", "1s", "sh", "-c", "true && test 1 -eq 1"}
+		})
+
+		It("runs the command as a single string through /bin/sh", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+			Expect(actualOutput).To(gbytes.Say("Success: true && test 1 -eq 1 did succeed"))
+		})
+
+		Context("when it precedes the sh command", func() {
+			BeforeEach(func() {
+				args = []string{"waitfor", "-t", "1s", "--shell", "sh", "true && test 1 -eq 1"}
+			})
+
+			It("is being used", func() {
+				Expect(actualErr).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when no command has been given", func() {
+			BeforeEach(func() {
+				args = []string{"waitfor", "-t", "1s", "-c", "true || false"}
+			})
+
+			It("is a flag of the default command", func() {
+				Expect(actualErr).ToNot(HaveOccurred())
+				Expect(actualOutput).To(gbytes.Say("Success: true || false did succeed"))
+			})
+		})
+
+		Context("when an interpreter has been specified", func() {
+			BeforeEach(func() {
+				args = []string{"waitfor", "-t", "1s", "-m", "^-c some command", "sh", "--interpreter", "echo", "-c", "some", "command"}
+			})
+
+			It("runs the command through it", func() {
+				Expect(actualErr).ToNot(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("--workdir flag", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "shell")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "ready"), []byte{}, 0644)).To(Succeed())
+
+			args = []string{"waitfor", "-t", "1s", "sh", "--workdir", dir, "test", "-f", "ready"}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("runs the command in the given directory", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("--env flag", func() {
+		BeforeEach(func() {
+			os.Setenv("WAITFOR_TEST_PARENT", "parent")
+			args = []string{"waitfor", "-t", "1s", "sh", "-e", "ONE=1", "--env", "TWO=2", "-c", `test "$ONE$TWO$WAITFOR_TEST_PARENT" = 12parent`}
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("WAITFOR_TEST_PARENT")
+		})
+
+		It("adds the variables to the environment of waitfor", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+		})
+
+		Context("when a variable is invalid", func() {
+			var exitCode int
+
+			BeforeEach(func() {
+				exitCode = 0
+				exit = func(rc int) {
+					exitCode = rc
+				}
+				args = []string{"waitfor", "sh", "-e", "ONE", "true"}
+			})
+
+			AfterEach(func() {
+				exit = os.Exit
+			})
+
+			It("exits with the exit code for invalid usage", func() {
+				Expect(exitCode).To(Equal(exitUsage))
+				Expect(actualOutput).To(gbytes.Say("invalid env 'ONE', must be KEY=VAL"))
+			})
+		})
+	})
+
+	Describe("--timeout-per-run flag", func() {
+		BeforeEach(func() {
+			args = []string{"waitfor", "-t", "500ms", "-i", "10ms", "-v", "sh", "--timeout-per-run", "50ms", "sleep", "5"}
+		})
+
+		It("kills runs of the command that take longer", func() {
+			Expect(actualErr).To(HaveOccurred())
+			Expect(actualOutput).To(gbytes.Say("command timed out after 50ms"))
+			Expect(actualOutput).To(gbytes.Say("Error waiting for sleep: timeout exceeded"))
+		})
+	})
+})
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/codegangsta/cli"
+	"golang.org/x/net/context"
+)
+
+const contextKey = "context"
+
+var notifySignals = func(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+}
+
+// handleSignals wraps the actions of the app and all its commands. Receiving
+// SIGINT or SIGTERM cancels the context of the running action, which stops
+// the wait and kills the commands run by checks. Once the action returns,
+// waitfor exits with 128 plus the number of the received signal.
+func handleSignals(app *cli.App) {
+	if app.Metadata == nil {
+		app.Metadata = map[string]interface{}{}
+	}
+
+	app.Action = cancelAction(app.Action)
+	for i := range app.Commands {
+		app.Commands[i].Action = cancelAction(app.Commands[i].Action)
+	}
+}
+
+func cancelAction(action interface{}) func(*cli.Context) error {
+	return func(c *cli.Context) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		signals := make(chan os.Signal, 1)
+		notifySignals(signals)
+		defer signal.Stop(signals)
+
+		received := make(chan os.Signal, 1)
+		go func() {
+			select {
+			case sig := <-signals:
+				received <- sig
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		c.App.Metadata[contextKey] = ctx
+
+		err := action.(func(*cli.Context) error)(c)
+
+		if err != nil && ctx.Err() != nil {
+			sig := <-received
+			fmt.Fprintf(c.App.Writer, "Cancelled: received %s\n", sig)
+			exit(exitInterrupted + int(sig.(syscall.Signal)))
+		}
+
+		return err
+	}
+}
+
+// waitContext returns the context of the running action, which is canceled
+// once waitfor receives SIGINT or SIGTERM.
+func waitContext(c *cli.Context) context.Context {
+	if ctx, ok := c.App.Metadata[contextKey].(context.Context); ok {
+		return ctx
+	}
+	return context.Background()
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+	"github.com/st3v/waitfor/cmd/waitfor/fake"
+)
+
+var _ = Describe("signal handling", func() {
+	var (
+		args     []string
+		signals  chan<- os.Signal
+		received os.Signal
+
+		exitCode     int
+		actualOutput *gbytes.Buffer
+		actualErr    error
+	)
+
+	BeforeEach(func() {
+		portcheck := new(fake.PortCheck)
+		portcheck.OnHostReturns(portcheck)
+		portcheck.ForNetworkReturns(portcheck)
+		portcheck.WithPayloadReturns(portcheck)
+		portcheck.WithReplyTimeoutReturns(portcheck)
+		portcheck.WithNoReplyOKReturns(portcheck)
+		portcheck.WithLoggerReturns(portcheck)
+		portCheckProvider = func(int) check.PortCheck {
+			return portcheck
+		}
+
+		waitForConditionWithTimeout = func(condition waitfor.Check, interval, timeout time.Duration, ctx context.Context) error {
+			<-ctx.Done()
+			return waitfor.ErrCanceled
+		}
+
+		received = syscall.SIGTERM
+		notifySignals = func(c chan<- os.Signal) {
+			signals = c
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				signals <- received
+			}()
+		}
+
+		exitCode = -1
+		exit = func(rc int) {
+			exitCode = rc
+		}
+
+		actualOutput = gbytes.NewBuffer()
+		args = []string{"waitfor", "port", "5432"}
+	})
+
+	AfterEach(func() {
+		exit = os.Exit
+		notifySignals = func(c chan<- os.Signal) {
+			signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+		}
+	})
+
+	JustBeforeEach(func() {
+		app := app()
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		actualErr = app.Run(args)
+	})
+
+	Context("when SIGTERM is received while waiting", func() {
+		It("cancels the wait", func() {
+			Expect(actualErr).To(MatchError(waitfor.ErrCanceled))
+			Expect(actualOutput).To(gbytes.Say("Error waiting for tcp://127.0.0.1:5432 to be open: canceled"))
+		})
+
+		It("reports the cancellation", func() {
+			Expect(actualOutput).To(gbytes.Say("Cancelled: received terminated"))
+		})
+
+		It("exits with 128 plus the signal number", func() {
+			Expect(exitCode).To(Equal(143))
+		})
+	})
+
+	Context("when SIGINT is received while waiting", func() {
+		BeforeEach(func() {
+			received = syscall.SIGINT
+		})
+
+		It("exits with 128 plus the signal number", func() {
+			Expect(actualOutput).To(gbytes.Say("Cancelled: received interrupt"))
+			Expect(exitCode).To(Equal(130))
+		})
+	})
+
+	Context("when a shell command is running", func() {
+		var start time.Time
+
+		BeforeEach(func() {
+			start = time.Now()
+			args = []string{"waitfor", "--timeout", "1m", "sh", "sleep", "30"}
+		})
+
+		It("stops waiting right away", func() {
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+			Expect(actualOutput).To(gbytes.Say("Error waiting for sleep: canceled"))
+			Expect(exitCode).To(Equal(143))
+		})
+	})
+})
+
+var _ = Describe("waitfor binary", func() {
+	var (
+		bin     string
+		dir     string
+		session *gexec.Session
+	)
+
+	BeforeEach(func() {
+		var err error
+		bin, err = gexec.Build("github.com/st3v/waitfor/cmd/waitfor")
+		Expect(err).ToNot(HaveOccurred())
+
+		dir, err = ioutil.TempDir("", "signal")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+		gexec.CleanupBuildArtifacts()
+	})
+
+	Context("when SIGINT is received while a shell command is running", func() {
+		BeforeEach(func() {
+			pidFile := filepath.Join(dir, "pid")
+			cmd := exec.Command(bin, "-t", "30s", "sh", "-c", "sleep 77 & echo $! > "+pidFile+"; wait")
+
+			var err error
+			session, err = gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(func() error {
+				_, err := os.Stat(pidFile)
+				return err
+			}, 5*time.Second).Should(Succeed())
+
+			session.Interrupt()
+		})
+
+		It("kills the command and its children before exiting", func() {
+			Eventually(session, 5*time.Second).Should(gexec.Exit(130))
+
+			pid, err := ioutil.ReadFile(filepath.Join(dir, "pid"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(running(strings.TrimSpace(string(pid)))).To(BeFalse())
+		})
+	})
+})
+
+// running returns whether the process with the given pid exists and is not a
+// zombie waiting to be reaped.
+func running(pid string) bool {
+	out, _ := exec.Command("ps", "-o", "stat=", "-p", pid).Output()
+	state := strings.TrimSpace(string(out))
+	return state != "" && !strings.HasPrefix(state, "Z")
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/codegangsta/cli"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor/tracing"
+)
+
+const waitKey = "wait"
+
+var newTraceExporter = func(c *cli.Context, dest string) (sdktrace.SpanExporter, error) {
+	switch dest {
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(c.App.Writer), stdouttrace.WithPrettyPrint())
+	case "otlp":
+		return otlptracehttp.New(context.Background())
+	}
+
+	return nil, fmt.Errorf("unsupported exporter '%s'", dest)
+}
+
+// traceActions wraps the actions of the app and all its commands. Every
+// action is recorded as a wait, the spans of which are exported according
+// to the --trace flag once the action returns.
+func traceActions(app *cli.App) {
+	if app.Metadata == nil {
+		app.Metadata = map[string]interface{}{}
+	}
+
+	app.Action = traceAction("sh", app.Action)
+	for i := range app.Commands {
+		app.Commands[i].Action = traceAction(app.Commands[i].Name, app.Commands[i].Action)
+	}
+}
+
+func traceAction(name string, action interface{}) func(*cli.Context) error {
+	return func(c *cli.Context) error {
+		tracer, shutdown := tracer(c)
+
+		t := &actionTrace{
+			wait:     tracing.Start(traceContext(), tracer, name),
+			shutdown: shutdown,
+		}
+		c.App.Metadata[waitKey] = t
+
+		err := action.(func(*cli.Context) error)(c)
+		t.end(err)
+
+		return err
+	}
+}
+
+// actionTrace is the trace of a running action.
+type actionTrace struct {
+	wait     *tracing.Wait
+	shutdown func()
+	once     sync.Once
+}
+
+// end records the outcome of the wait and exports the trace. Only the first
+// call has an effect.
+func (t *actionTrace) end(err error) {
+	t.once.Do(func() {
+		t.wait.End(err)
+		t.shutdown()
+	})
+}
+
+// exitWith exports the trace of the running action, if any, before exiting
+// with the given code.
+func exitWith(c *cli.Context, code int) {
+	if t, ok := c.App.Metadata[waitKey].(*actionTrace); ok {
+		t.end(fmt.Errorf("exit status %d", code))
+	}
+	exit(code)
+}
+
+// currentWait returns the wait of the running action. Attempts of checks
+// created outside of a traced action are not recorded.
+func currentWait(c *cli.Context) *tracing.Wait {
+	if t, ok := c.App.Metadata[waitKey].(*actionTrace); ok {
+		return t.wait
+	}
+	return noopWait()
+}
+
+// targetLogger returns the logger of a check, which also lets the given
+// target record the error of failed attempts.
+func targetLogger(c *cli.Context, target *tracing.Target) io.Writer {
+	return io.MultiWriter(logger(c), target)
+}
+
+func noopWait() *tracing.Wait {
+	return tracing.Start(context.Background(), noop.NewTracerProvider().Tracer(""), "")
+}
+
+func tracer(c *cli.Context) (trace.Tracer, func()) {
+	dest := c.String("trace")
+	if dest == "" {
+		dest = c.GlobalString("trace")
+	}
+
+	if dest == "" {
+		return noop.NewTracerProvider().Tracer(""), func() {}
+	}
+
+	exporter, err := newTraceExporter(c, dest)
+	if err != nil {
+		fmt.Fprintf(c.App.Writer, "invalid trace exporter '%s': %s\n", dest, err)
+		exit(exitUsage)
+		return noop.NewTracerProvider().Tracer(""), func() {}
+	}
+
+	res, _ := resource.New(context.Background(),
+		resource.WithAttributes(attribute.String("service.name", "waitfor")),
+		resource.WithFromEnv(),
+	)
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	shutdown := func() {
+		if err := provider.Shutdown(context.Background()); err != nil {
+			fmt.Fprintf(c.App.Writer, "Error exporting trace: %s\n", err)
+		}
+	}
+
+	return provider.Tracer("github.com/st3v/waitfor"), shutdown
+}
+
+// traceContext continues the trace of the calling process if it has been
+// passed via the TRACEPARENT environment variable.
+func traceContext() context.Context {
+	carrier := propagation.MapCarrier{
+		"traceparent": os.Getenv("TRACEPARENT"),
+		"tracestate":  os.Getenv("TRACESTATE"),
+	}
+
+	return propagation.TraceContext{}.Extract(context.Background(), carrier)
+}
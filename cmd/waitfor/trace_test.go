@@ -0,0 +1,195 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/ghttp"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+)
+
+var _ = Describe("tracing", func() {
+	var (
+		server       *ghttp.Server
+		args         []string
+		actualOutput *gbytes.Buffer
+		actualErr    error
+	)
+
+	BeforeEach(func() {
+		curlCheckProvider = check.Curl
+
+		server = ghttp.NewServer()
+		server.RouteToHandler("GET", "/health", ghttp.RespondWith(200, "ok"))
+
+		waitForConditionWithTimeout = func(condition waitfor.Check, interval, timeout time.Duration, ctx context.Context) error {
+			if !condition() {
+				return waitfor.ErrTimeoutExceeded
+			}
+			return nil
+		}
+
+		actualOutput = gbytes.NewBuffer()
+		args = []string{"waitfor", "--trace", "stdout", "curl", server.URL() + "/health"}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	JustBeforeEach(func() {
+		app := app()
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		actualErr = app.Run(args)
+	})
+
+	It("succeeds", func() {
+		Expect(actualErr).ToNot(HaveOccurred())
+	})
+
+	It("exports a span for the attempt", func() {
+		Expect(actualOutput).To(gbytes.Say(`"Name": "attempt"`))
+		Expect(actualOutput).To(gbytes.Say(`"Key": "waitfor.check.kind"`))
+		Expect(actualOutput).To(gbytes.Say(`"Value": "curl"`))
+	})
+
+	It("exports a span for the wait", func() {
+		Expect(actualOutput).To(gbytes.Say(`"Name": "waitfor curl"`))
+		Expect(actualOutput).To(gbytes.Say(`"Key": "waitfor.outcome"`))
+		Expect(actualOutput).To(gbytes.Say(`"Value": "success"`))
+	})
+
+	It("propagates the trace context to the checked service", func() {
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+		Expect(server.ReceivedRequests()[0].Header.Get("traceparent")).To(MatchRegexp(`^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`))
+	})
+
+	Context("when the --trace flag has been passed to the command", func() {
+		BeforeEach(func() {
+			args = []string{"waitfor", "curl", "--trace", "stdout", server.URL() + "/health"}
+		})
+
+		It("exports the trace", func() {
+			Expect(actualOutput).To(gbytes.Say(`"Name": "waitfor curl"`))
+		})
+	})
+
+	Context("when an attempt of a check fails", func() {
+		BeforeEach(func() {
+			args = []string{"waitfor", "--timeout", "100ms", "--trace", "stdout", "sh", "false"}
+		})
+
+		It("exports the error of the attempt", func() {
+			Expect(actualOutput).To(gbytes.Say(`"Name": "attempt"`))
+			Expect(actualOutput).To(gbytes.Say(`"Key": "waitfor.error"`))
+			Expect(actualOutput).To(gbytes.Say(`"Value": "exit status 1"`))
+		})
+	})
+
+	Context("when a trace context has been passed via TRACEPARENT", func() {
+		BeforeEach(func() {
+			os.Setenv("TRACEPARENT", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("TRACEPARENT")
+		})
+
+		It("continues the trace", func() {
+			Expect(server.ReceivedRequests()[0].Header.Get("traceparent")).To(HavePrefix("00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+			Expect(actualOutput).To(gbytes.Say(`"SpanID": "00f067aa0ba902b7"`))
+		})
+	})
+
+	Context("when tracing is disabled", func() {
+		BeforeEach(func() {
+			args = []string{"waitfor", "curl", server.URL() + "/health"}
+		})
+
+		It("does not export a trace", func() {
+			Expect(actualOutput).ToNot(gbytes.Say("attempt"))
+		})
+
+		It("does not propagate a trace context", func() {
+			Expect(server.ReceivedRequests()[0].Header.Get("traceparent")).To(BeEmpty())
+		})
+	})
+
+	Context("when an unsupported exporter has been specified", func() {
+		var exitCode int
+
+		BeforeEach(func() {
+			args = []string{"waitfor", "--trace", "zipkin", "curl", server.URL() + "/health"}
+
+			exitCode = -1
+			exit = func(rc int) {
+				exitCode = rc
+			}
+		})
+
+		AfterEach(func() {
+			exit = os.Exit
+		})
+
+		It("exits with an error", func() {
+			Expect(actualOutput).To(gbytes.Say("invalid trace exporter 'zipkin': unsupported exporter 'zipkin'"))
+			Expect(exitCode).To(Equal(exitUsage))
+		})
+	})
+
+	Context("when the command exits because of invalid usage", func() {
+		var exitCode int
+
+		AfterEach(func() {
+			exit = os.Exit
+		})
+
+		JustBeforeEach(func() {
+			exitCode = -1
+			exit = func(rc int) {
+				exitCode = rc
+				panic(rc)
+			}
+
+			usage := gbytes.NewBuffer()
+			actualOutput = usage
+
+			Expect(func() {
+				app := app()
+				app.Writer = io.MultiWriter(GinkgoWriter, usage)
+				app.Run([]string{"waitfor", "--trace", "stdout", "port"})
+			}).To(Panic())
+		})
+
+		It("exports the trace before exiting", func() {
+			Expect(exitCode).To(Equal(exitUsage))
+			Expect(actualOutput).To(gbytes.Say(`"Name": "waitfor port"`))
+			Expect(actualOutput).To(gbytes.Say(`exit status 2`))
+		})
+	})
+
+	Context("when exiting does not stop the command", func() {
+		BeforeEach(func() {
+			args = []string{"waitfor", "--trace", "stdout", "port", "--protocol", "ftp", "--closed", "5432"}
+
+			exit = func(int) {}
+		})
+
+		AfterEach(func() {
+			exit = os.Exit
+		})
+
+		It("exports the trace only once", func() {
+			Expect(strings.Count(string(actualOutput.Contents()), `"Name": "waitfor port"`)).To(Equal(1))
+			Expect(actualOutput).To(gbytes.Say(`exit status 2`))
+		})
+	})
+})
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/codegangsta/cli"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+)
+
+var webSocketCheckProvider = check.WebSocket
+
+var webSocketCommand = cli.Command{
+	Name:    "ws",
+	Aliases: []string{"websocket"},
+	Usage:   "wait for a server to complete a WebSocket upgrade, and optionally to send a matching message",
+
+	ArgsUsage: "<url>...",
+
+	HideHelp: true,
+
+	Flags: []cli.Flag{
+		headerFlag,
+		messageFlag,
+		matchFlag,
+		insecureFlag,
+		anyFlag,
+		connectTimeoutFlag,
+		timeoutFlag,
+		intervalFlag,
+		verboseFlag,
+		strictFlag,
+		traceFlag,
+		quietFlag,
+	},
+
+	Action: func(c *cli.Context) error {
+		names, conditions := webSocketConditions(c)
+		return waitFor(c, names, conditions, "endpoints", "ready")
+	},
+}
+
+// webSocketConditions returns the URLs given as positional arguments and the
+// corresponding conditions.
+func webSocketConditions(c *cli.Context) ([]string, []waitfor.Check) {
+	if !positional(c).Present() {
+		cli.ShowCommandHelp(c, "ws")
+		fmt.Fprintln(c.App.Writer, "must specify URL")
+		exitWith(c, exitUsage)
+	}
+
+	var conditions []waitfor.Check
+	for _, url := range positional(c) {
+		conditions = append(conditions, webSocketCondition(c, url))
+	}
+
+	return positional(c), conditions
+}
+
+func webSocketCondition(c *cli.Context, url string) waitfor.Check {
+	target := currentWait(c).Target("ws", url)
+	ws := webSocketCheckProvider(url).WithLogger(targetLogger(c, target))
+
+	if timeout := c.Duration("connect-timeout"); timeout > 0 {
+		ws.WithTimeout(timeout)
+	}
+
+	if config := tlsConfig(c); config != nil {
+		ws.WithTLS(config)
+	}
+
+	headers := append(c.StringSlice("header"), fmt.Sprintf("user-agent:waitfor/%s", c.App.Version))
+	for _, h := range headers {
+		ws.WithHeader(splitByColon(h))
+	}
+
+	if message := c.String("message"); message != "" {
+		ws.WithMessage(message)
+	}
+
+	if match := c.String("match"); match != "" {
+		ws.WithMatch(matchRegexp(c, match))
+	}
+
+	return target.Check(ws.IsReady)
+}
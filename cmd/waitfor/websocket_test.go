@@ -0,0 +1,187 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+	"github.com/st3v/waitfor/check"
+	"github.com/st3v/waitfor/cmd/waitfor/fake"
+)
+
+var _ = Describe("ws command", func() {
+	var (
+		wscheck     *fake.WebSocketCheck
+		command     string
+		args        []string
+		expectedErr error
+
+		actualURLs    []string
+		actualChecks  []waitfor.Check
+		actualTimeout time.Duration
+		actualOutput  *gbytes.Buffer
+		actualErr     error
+	)
+
+	BeforeEach(func() {
+		wscheck = new(fake.WebSocketCheck)
+		wscheck.WithHeaderReturns(wscheck)
+		wscheck.WithMessageReturns(wscheck)
+		wscheck.WithMatchReturns(wscheck)
+		wscheck.WithTLSReturns(wscheck)
+		wscheck.WithTimeoutReturns(wscheck)
+		wscheck.WithLoggerReturns(wscheck)
+		wscheck.IsReadyReturns(true)
+		webSocketCheckProvider = func(url string) check.WebSocketCheck {
+			actualURLs = append(actualURLs, url)
+			return wscheck
+		}
+
+		command = "ws"
+		args = []string{"ws://gateway/realtime"}
+		expectedErr = nil
+		actualURLs = nil
+		actualChecks = nil
+		actualOutput = gbytes.NewBuffer()
+
+		waitForConditionWithTimeout = func(check waitfor.Check, interval, timeout time.Duration, ctx context.Context) error {
+			check()
+			actualTimeout = timeout
+			return expectedErr
+		}
+
+		waitForAllWithTimeout = func(checks []waitfor.Check, interval, timeout time.Duration, ctx context.Context) []error {
+			actualChecks = checks
+			for _, check := range checks {
+				check()
+			}
+			return make([]error, len(checks))
+		}
+	})
+
+	JustBeforeEach(func() {
+		app := app()
+		app.Writer = io.MultiWriter(GinkgoWriter, actualOutput)
+		actualErr = app.Run(append([]string{"waitfor", command}, args...))
+	})
+
+	It("waits for the upgrade", func() {
+		Expect(actualURLs).To(Equal([]string{"ws://gateway/realtime"}))
+		Expect(wscheck.IsReadyCallCount()).To(Equal(1))
+		Expect(wscheck.WithMessageCallCount()).To(Equal(0))
+		Expect(wscheck.WithMatchCallCount()).To(Equal(0))
+		Expect(wscheck.WithTLSCallCount()).To(Equal(0))
+		Expect(wscheck.WithTimeoutArgsForCall(0)).To(Equal(5 * time.Second))
+		Expect(actualOutput).To(gbytes.Say("Success: ws://gateway/realtime is ready"))
+		Expect(actualErr).ToNot(HaveOccurred())
+	})
+
+	It("sends a user agent", func() {
+		key, value := wscheck.WithHeaderArgsForCall(0)
+		Expect(key).To(Equal("user-agent"))
+		Expect(value).To(HavePrefix("waitfor/"))
+	})
+
+	Context("when the check fails", func() {
+		BeforeEach(func() {
+			expectedErr = errors.New("some-error")
+		})
+
+		It("returns an error", func() {
+			Expect(actualErr).To(HaveOccurred())
+			Expect(actualOutput).To(gbytes.Say("Error waiting for ws://gateway/realtime to be ready: some-error"))
+		})
+	})
+
+	Describe("flags", func() {
+		BeforeEach(func() {
+			args = []string{"wss://gateway/realtime", "-H", "authorization:Bearer token", "--message", `{"type":"ping"}`, "-m", `"pong"`, "-k", "--connect-timeout", "2s", "-t", "1m"}
+		})
+
+		It("are being used", func() {
+			key, value := wscheck.WithHeaderArgsForCall(0)
+			Expect(key).To(Equal("authorization"))
+			Expect(value).To(Equal("Bearer token"))
+			Expect(wscheck.WithMessageArgsForCall(0)).To(Equal(`{"type":"ping"}`))
+			Expect(wscheck.WithMatchArgsForCall(0)).To(Equal(regexp.MustCompile(`"pong"`)))
+			Expect(wscheck.WithTLSArgsForCall(0)).To(Equal(&tls.Config{InsecureSkipVerify: true}))
+			Expect(wscheck.WithTimeoutArgsForCall(0)).To(Equal(2 * time.Second))
+			Expect(actualTimeout).To(Equal(time.Minute))
+		})
+	})
+
+	Context("when multiple URLs have been specified", func() {
+		BeforeEach(func() {
+			args = []string{"ws://gateway1/realtime", "ws://gateway2/realtime"}
+		})
+
+		It("waits for all of them", func() {
+			Expect(actualURLs).To(Equal([]string{"ws://gateway1/realtime", "ws://gateway2/realtime"}))
+			Expect(actualChecks).To(HaveLen(2))
+			Expect(actualOutput).To(gbytes.Say("ws://gateway1/realtime: ready"))
+			Expect(actualOutput).To(gbytes.Say("ws://gateway2/realtime: ready"))
+		})
+	})
+
+	Context("when used as target of the on command", func() {
+		BeforeEach(func() {
+			command = "on"
+			args = []string{"wss://gateway/realtime", "--message", "ping"}
+		})
+
+		It("waits for the upgrade", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+			Expect(actualURLs).To(ContainElement("wss://gateway/realtime"))
+			Expect(wscheck.WithMessageArgsForCall(0)).To(Equal("ping"))
+			Expect(wscheck.IsReadyCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when used in a config file", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "ws")
+			Expect(err).ToNot(HaveOccurred())
+
+			config := `
+checks:
+- name: gateway
+  kind: ws
+  url: ws://gateway/realtime
+  headers:
+    origin: https://app
+  message: ping
+  match: pong
+`
+			Expect(ioutil.WriteFile(filepath.Join(dir, "waitfor.yaml"), []byte(config), 0644)).To(Succeed())
+
+			command = "run"
+			args = []string{"-f", filepath.Join(dir, "waitfor.yaml")}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("waits for a matching message", func() {
+			Expect(actualErr).ToNot(HaveOccurred())
+			Expect(actualURLs).To(ContainElement("ws://gateway/realtime"))
+			Expect(wscheck.WithMessageArgsForCall(0)).To(Equal("ping"))
+			Expect(wscheck.WithMatchArgsForCall(0)).To(Equal(regexp.MustCompile("pong")))
+			Expect(wscheck.IsReadyCallCount()).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,262 @@
+package waitfor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+var ErrDependencyFailed = errors.New("dependency failed")
+
+// Node is a named check within a Graph. A node is only checked once all the
+// nodes it depends on have succeeded. Interval and Timeout are optional and
+// override the values passed to Graph.WaitWithTimeout for this node only.
+type Node struct {
+	Name      string
+	Check     Check
+	DependsOn []string
+	Interval  time.Duration
+	Timeout   time.Duration
+}
+
+// Result describes the outcome of waiting for a single node. BlockedBy is set
+// to the name of the failed dependency if the node has never been checked.
+type Result struct {
+	Name      string
+	Err       error
+	BlockedBy string
+	Duration  time.Duration
+}
+
+// GraphError is returned by Graph.WaitWithTimeout. It names the failed node
+// that sorts first by name and all nodes that have been blocked by it,
+// directly or transitively.
+type GraphError struct {
+	Name    string
+	Err     error
+	Blocked []string
+}
+
+func (e *GraphError) Error() string {
+	msg := fmt.Sprintf("check '%s' failed: %s", e.Name, e.Err)
+	if len(e.Blocked) == 0 {
+		return msg
+	}
+
+	return fmt.Sprintf("%s, blocking '%s'", msg, strings.Join(e.Blocked, "', '"))
+}
+
+type Graph struct {
+	nodes []Node
+	index map[string]int
+}
+
+// NewGraph validates the given nodes and returns the corresponding Graph.
+// Names have to be unique, dependencies have to refer to existing nodes and
+// must not form cycles.
+func NewGraph(nodes ...Node) (*Graph, error) {
+	g := &Graph{
+		nodes: nodes,
+		index: make(map[string]int, len(nodes)),
+	}
+
+	for i, n := range nodes {
+		if n.Name == "" {
+			return nil, fmt.Errorf("check #%d has no name", i+1)
+		}
+
+		if _, ok := g.index[n.Name]; ok {
+			return nil, fmt.Errorf("duplicate check '%s'", n.Name)
+		}
+
+		if n.Check == nil {
+			return nil, fmt.Errorf("check '%s' has no condition", n.Name)
+		}
+
+		g.index[n.Name] = i
+	}
+
+	for _, n := range nodes {
+		for _, dep := range n.DependsOn {
+			if _, ok := g.index[dep]; !ok {
+				return nil, fmt.Errorf("check '%s' depends on unknown check '%s'", n.Name, dep)
+			}
+		}
+	}
+
+	if err := g.detectCycles(); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// Nodes returns the nodes of the graph in the order they have been added.
+func (g *Graph) Nodes() []Node {
+	nodes := make([]Node, len(g.nodes))
+	copy(nodes, g.nodes)
+	return nodes
+}
+
+// detectCycles performs a depth-first search along the dependencies of all
+// nodes. Nodes that are part of a cycle would wait for each other forever.
+func (g *Graph) detectCycles() error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := make([]int, len(g.nodes))
+	var path []string
+
+	var visit func(int) error
+	visit = func(i int) error {
+		name := g.nodes[i].Name
+
+		switch state[i] {
+		case visiting:
+			return fmt.Errorf("dependency cycle detected: %s -> %s", strings.Join(path, " -> "), name)
+		case visited:
+			return nil
+		}
+
+		state[i] = visiting
+		path = append(path, name)
+
+		for _, dep := range g.nodes[i].DependsOn {
+			if err := visit(g.index[dep]); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[i] = visited
+
+		return nil
+	}
+
+	for i := range g.nodes {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WaitWithTimeout concurrently waits for all nodes within the given timeout.
+// The returned results are in the same order as the nodes of the graph. If
+// any node failed, the returned error is a *GraphError.
+func (g *Graph) WaitWithTimeout(interval, timeout time.Duration) ([]Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return g.WaitWithContext(interval, ctx)
+}
+
+// WaitWithContext is like WaitWithTimeout but waits until the given context
+// is done instead.
+func (g *Graph) WaitWithContext(interval time.Duration, ctx context.Context) ([]Result, error) {
+	results := make([]Result, len(g.nodes))
+	done := make([]chan struct{}, len(g.nodes))
+	for i := range g.nodes {
+		done[i] = make(chan struct{})
+	}
+
+	var (
+		mutex    sync.Mutex
+		failures []int
+	)
+
+	for i, n := range g.nodes {
+		go func(i int, n Node) {
+			defer close(done[i])
+
+			results[i].Name = n.Name
+
+			for _, dep := range n.DependsOn {
+				d := g.index[dep]
+				<-done[d]
+
+				if results[d].Err != nil {
+					results[i].Err = ErrDependencyFailed
+					results[i].BlockedBy = dep
+					return
+				}
+			}
+
+			start := time.Now()
+			results[i].Err = g.wait(ctx, n, interval)
+			results[i].Duration = time.Since(start)
+
+			if results[i].Err != nil {
+				mutex.Lock()
+				failures = append(failures, i)
+				mutex.Unlock()
+			}
+		}(i, n)
+	}
+
+	for i := range done {
+		<-done[i]
+	}
+
+	if len(failures) == 0 {
+		return results, nil
+	}
+
+	// nodes fail in no particular order, report the same one every time
+	sort.Slice(failures, func(a, b int) bool {
+		return g.nodes[failures[a]].Name < g.nodes[failures[b]].Name
+	})
+
+	return results, g.graphError(results, failures[0])
+}
+
+func (g *Graph) wait(ctx context.Context, n Node, interval time.Duration) error {
+	if n.Interval != 0 {
+		interval = n.Interval
+	}
+
+	if n.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.Timeout)
+		defer cancel()
+	}
+
+	errChan := make(chan error, 1)
+	go Condition(n.Check, interval, errChan, ctx)
+
+	select {
+	case err := <-errChan:
+		return handleErr(err)
+	case <-ctx.Done():
+		<-errChan
+		return handleErr(ctx.Err())
+	}
+}
+
+func (g *Graph) graphError(results []Result, failed int) error {
+	err := &GraphError{
+		Name: results[failed].Name,
+		Err:  results[failed].Err,
+	}
+
+	for _, r := range results {
+		root := r
+		for root.BlockedBy != "" {
+			root = results[g.index[root.BlockedBy]]
+		}
+
+		if r.BlockedBy != "" && root.Name == err.Name {
+			err.Blocked = append(err.Blocked, r.Name)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,202 @@
+package waitfor_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+)
+
+var _ = Describe("Graph", func() {
+	var (
+		succeed = func() bool { return true }
+		fail    = func() bool { return false }
+
+		interval = 10 * time.Millisecond
+		timeout  = 100 * time.Millisecond
+	)
+
+	Describe("NewGraph", func() {
+		It("accepts valid dependencies", func() {
+			_, err := waitfor.NewGraph(
+				waitfor.Node{Name: "a", Check: succeed},
+				waitfor.Node{Name: "b", Check: succeed, DependsOn: []string{"a"}},
+				waitfor.Node{Name: "c", Check: succeed, DependsOn: []string{"a", "b"}},
+			)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects nodes without a name", func() {
+			_, err := waitfor.NewGraph(waitfor.Node{Check: succeed})
+			Expect(err).To(MatchError("check #1 has no name"))
+		})
+
+		It("rejects duplicate names", func() {
+			_, err := waitfor.NewGraph(
+				waitfor.Node{Name: "a", Check: succeed},
+				waitfor.Node{Name: "a", Check: succeed},
+			)
+			Expect(err).To(MatchError("duplicate check 'a'"))
+		})
+
+		It("rejects nodes without a check", func() {
+			_, err := waitfor.NewGraph(waitfor.Node{Name: "a"})
+			Expect(err).To(MatchError("check 'a' has no condition"))
+		})
+
+		It("rejects unknown dependencies", func() {
+			_, err := waitfor.NewGraph(waitfor.Node{Name: "a", Check: succeed, DependsOn: []string{"b"}})
+			Expect(err).To(MatchError("check 'a' depends on unknown check 'b'"))
+		})
+
+		It("rejects cycles", func() {
+			_, err := waitfor.NewGraph(
+				waitfor.Node{Name: "a", Check: succeed, DependsOn: []string{"c"}},
+				waitfor.Node{Name: "b", Check: succeed, DependsOn: []string{"a"}},
+				waitfor.Node{Name: "c", Check: succeed, DependsOn: []string{"b"}},
+			)
+			Expect(err).To(MatchError("dependency cycle detected: a -> c -> b -> a"))
+		})
+
+		It("rejects nodes depending on themselves", func() {
+			_, err := waitfor.NewGraph(waitfor.Node{Name: "a", Check: succeed, DependsOn: []string{"a"}})
+			Expect(err).To(MatchError("dependency cycle detected: a -> a"))
+		})
+	})
+
+	Describe(".WaitWithTimeout", func() {
+		Context("when all nodes succeed", func() {
+			It("runs dependencies first", func() {
+				order := make(chan string, 2)
+
+				graph, err := waitfor.NewGraph(
+					waitfor.Node{Name: "b", DependsOn: []string{"a"}, Check: func() bool {
+						order <- "b"
+						return true
+					}},
+					waitfor.Node{Name: "a", Check: func() bool {
+						time.Sleep(20 * time.Millisecond)
+						order <- "a"
+						return true
+					}},
+				)
+				Expect(err).ToNot(HaveOccurred())
+
+				results, err := graph.WaitWithTimeout(interval, timeout)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(HaveLen(2))
+				Expect(results[0].Name).To(Equal("b"))
+				Expect(results[1].Name).To(Equal("a"))
+
+				Expect(<-order).To(Equal("a"))
+				Expect(<-order).To(Equal("b"))
+			})
+		})
+
+		Context("when a node fails", func() {
+			var (
+				results []waitfor.Result
+				err     error
+			)
+
+			BeforeEach(func() {
+				graph, buildErr := waitfor.NewGraph(
+					waitfor.Node{Name: "db", Check: fail},
+					waitfor.Node{Name: "migrations", Check: succeed, DependsOn: []string{"db"}},
+					waitfor.Node{Name: "api", Check: succeed, DependsOn: []string{"migrations"}},
+					waitfor.Node{Name: "cache", Check: succeed},
+				)
+				Expect(buildErr).ToNot(HaveOccurred())
+
+				results, err = graph.WaitWithTimeout(interval, timeout)
+			})
+
+			It("reports the failed node", func() {
+				Expect(results[0].Err).To(MatchError(waitfor.ErrTimeoutExceeded))
+				Expect(results[0].BlockedBy).To(BeEmpty())
+			})
+
+			It("does not check dependent nodes", func() {
+				Expect(results[1].Err).To(MatchError(waitfor.ErrDependencyFailed))
+				Expect(results[1].BlockedBy).To(Equal("db"))
+				Expect(results[2].BlockedBy).To(Equal("migrations"))
+			})
+
+			It("checks independent nodes", func() {
+				Expect(results[3].Err).ToNot(HaveOccurred())
+			})
+
+			It("returns an error describing the blocked subtree", func() {
+				Expect(err).To(MatchError("check 'db' failed: timeout exceeded, blocking 'migrations', 'api'"))
+
+				graphErr, ok := err.(*waitfor.GraphError)
+				Expect(ok).To(BeTrue())
+				Expect(graphErr.Name).To(Equal("db"))
+				Expect(graphErr.Blocked).To(Equal([]string{"migrations", "api"}))
+			})
+		})
+
+		Context("when multiple nodes fail", func() {
+			It("reports the one that sorts first by name", func() {
+				graph, buildErr := waitfor.NewGraph(
+					waitfor.Node{Name: "queue", Check: fail, Timeout: timeout / 10},
+					waitfor.Node{Name: "db", Check: fail},
+					waitfor.Node{Name: "search", Check: fail, Timeout: timeout / 5},
+				)
+				Expect(buildErr).ToNot(HaveOccurred())
+
+				_, err := graph.WaitWithTimeout(interval, timeout)
+				Expect(err).To(MatchError("check 'db' failed: timeout exceeded"))
+			})
+		})
+
+		Context("when a node has its own timeout", func() {
+			It("is being used", func() {
+				graph, err := waitfor.NewGraph(waitfor.Node{Name: "a", Check: fail, Timeout: 20 * time.Millisecond})
+				Expect(err).ToNot(HaveOccurred())
+
+				results, err := graph.WaitWithTimeout(interval, time.Minute)
+				Expect(err).To(HaveOccurred())
+				Expect(results[0].Duration).To(BeNumerically("<", time.Second))
+			})
+		})
+	})
+
+	Describe(".WaitWithContext", func() {
+		Context("when the context is canceled", func() {
+			It("stops waiting", func() {
+				graph, err := waitfor.NewGraph(
+					waitfor.Node{Name: "a", Check: fail},
+					waitfor.Node{Name: "b", Check: succeed, DependsOn: []string{"a"}},
+				)
+				Expect(err).ToNot(HaveOccurred())
+
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(5*interval, cancel)
+
+				results, err := graph.WaitWithContext(interval, ctx)
+				Expect(err).To(MatchError("check 'a' failed: canceled, blocking 'b'"))
+				Expect(results[0].Err).To(MatchError(waitfor.ErrCanceled))
+			})
+
+			It("waits for running attempts to return", func() {
+				returned := make(chan bool, 1)
+				graph, err := waitfor.NewGraph(waitfor.Node{Name: "a", Check: func() bool {
+					time.Sleep(5 * interval)
+					returned <- true
+					return false
+				}})
+				Expect(err).ToNot(HaveOccurred())
+
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				graph.WaitWithContext(interval, ctx)
+				Expect(returned).To(Receive())
+			})
+		})
+	})
+})
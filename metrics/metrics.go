@@ -0,0 +1,163 @@
+// Package metrics records attempts, latencies and status of checks and
+// exposes them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/st3v/waitfor"
+)
+
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type series struct {
+	name string
+	kind string
+
+	successes int
+	failures  int
+	up        bool
+
+	buckets []int
+	sum     float64
+	count   int
+
+	firstAttempt time.Time
+	timeToReady  time.Duration
+	ready        bool
+}
+
+type Observer struct {
+	mutex   sync.Mutex
+	buckets []float64
+	series  []*series
+}
+
+func NewObserver() *Observer {
+	return &Observer{
+		buckets: DefaultBuckets,
+	}
+}
+
+// Observe wraps the given check, recording every attempt under the given
+// check name and kind.
+func (o *Observer) Observe(name, kind string, check waitfor.Check) waitfor.Check {
+	o.mutex.Lock()
+	s := &series{
+		name:    name,
+		kind:    kind,
+		buckets: make([]int, len(o.buckets)),
+	}
+	o.series = append(o.series, s)
+	o.mutex.Unlock()
+
+	return func() bool {
+		start := time.Now()
+		ok := check()
+		o.record(s, start, time.Now(), ok)
+		return ok
+	}
+}
+
+func (o *Observer) record(s *series, start, end time.Time, ok bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if s.firstAttempt.IsZero() {
+		s.firstAttempt = start
+	}
+
+	if ok {
+		s.successes++
+	} else {
+		s.failures++
+	}
+	s.up = ok
+
+	if ok && !s.ready {
+		s.ready = true
+		s.timeToReady = end.Sub(s.firstAttempt)
+	}
+
+	latency := end.Sub(start).Seconds()
+	for i, le := range o.buckets {
+		if latency <= le {
+			s.buckets[i]++
+		}
+	}
+	s.sum += latency
+	s.count++
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format.
+func (o *Observer) WriteTo(w io.Writer) (int64, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	var buf bytes.Buffer
+
+	header(&buf, "waitfor_check_attempts_total", "counter", "Number of times a check has been evaluated.")
+	for _, s := range o.series {
+		fmt.Fprintf(&buf, "waitfor_check_attempts_total{%s,result=\"success\"} %d\n", s.labels(), s.successes)
+		fmt.Fprintf(&buf, "waitfor_check_attempts_total{%s,result=\"failure\"} %d\n", s.labels(), s.failures)
+	}
+
+	header(&buf, "waitfor_check_duration_seconds", "histogram", "Time it took to evaluate a check.")
+	for _, s := range o.series {
+		for i, le := range o.buckets {
+			fmt.Fprintf(&buf, "waitfor_check_duration_seconds_bucket{%s,le=\"%s\"} %d\n", s.labels(), formatFloat(le), s.buckets[i])
+		}
+		fmt.Fprintf(&buf, "waitfor_check_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", s.labels(), s.count)
+		fmt.Fprintf(&buf, "waitfor_check_duration_seconds_sum{%s} %s\n", s.labels(), formatFloat(s.sum))
+		fmt.Fprintf(&buf, "waitfor_check_duration_seconds_count{%s} %d\n", s.labels(), s.count)
+	}
+
+	header(&buf, "waitfor_check_up", "gauge", "Whether the last evaluation of a check succeeded.")
+	for _, s := range o.series {
+		up := 0
+		if s.up {
+			up = 1
+		}
+		fmt.Fprintf(&buf, "waitfor_check_up{%s} %d\n", s.labels(), up)
+	}
+
+	header(&buf, "waitfor_check_time_to_ready_seconds", "gauge", "Time from the first evaluation of a check until it first succeeded.")
+	for _, s := range o.series {
+		if s.ready {
+			fmt.Fprintf(&buf, "waitfor_check_time_to_ready_seconds{%s} %s\n", s.labels(), formatFloat(s.timeToReady.Seconds()))
+		}
+	}
+
+	return buf.WriteTo(w)
+}
+
+func (o *Observer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	o.WriteTo(w)
+}
+
+func (s *series) labels() string {
+	return fmt.Sprintf("check=\"%s\",kind=\"%s\"", escape(s.name), escape(s.kind))
+}
+
+func header(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(value string) string {
+	return escaper.Replace(value)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,107 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/st3v/waitfor/metrics"
+)
+
+var _ = Describe("Observer", func() {
+	var (
+		observer *metrics.Observer
+		results  []bool
+		check    func() bool
+	)
+
+	output := func() string {
+		var buf bytes.Buffer
+		_, err := observer.WriteTo(&buf)
+		Expect(err).ToNot(HaveOccurred())
+		return buf.String()
+	}
+
+	BeforeEach(func() {
+		observer = metrics.NewObserver()
+		results = []bool{false, false, true}
+
+		check = observer.Observe("db", "port", func() bool {
+			time.Sleep(time.Millisecond)
+			result := results[0]
+			results = results[1:]
+			return result
+		})
+	})
+
+	It("passes through the result of the check", func() {
+		Expect(check()).To(BeFalse())
+		Expect(check()).To(BeFalse())
+		Expect(check()).To(BeTrue())
+	})
+
+	Context("when the check has been evaluated", func() {
+		BeforeEach(func() {
+			check()
+			check()
+			check()
+		})
+
+		It("counts attempts by result", func() {
+			Expect(output()).To(ContainSubstring("# TYPE waitfor_check_attempts_total counter\n"))
+			Expect(output()).To(ContainSubstring(`waitfor_check_attempts_total{check="db",kind="port",result="success"} 1` + "\n"))
+			Expect(output()).To(ContainSubstring(`waitfor_check_attempts_total{check="db",kind="port",result="failure"} 2` + "\n"))
+		})
+
+		It("records a latency histogram", func() {
+			Expect(output()).To(ContainSubstring("# TYPE waitfor_check_duration_seconds histogram\n"))
+			Expect(output()).To(ContainSubstring(`waitfor_check_duration_seconds_bucket{check="db",kind="port",le="0.005"}`))
+			Expect(output()).To(ContainSubstring(`waitfor_check_duration_seconds_bucket{check="db",kind="port",le="+Inf"} 3` + "\n"))
+			Expect(output()).To(ContainSubstring(`waitfor_check_duration_seconds_count{check="db",kind="port"} 3` + "\n"))
+			Expect(output()).To(MatchRegexp(`waitfor_check_duration_seconds_sum{check="db",kind="port"} 0\.0\d+`))
+		})
+
+		It("reports the current status", func() {
+			Expect(output()).To(ContainSubstring(`waitfor_check_up{check="db",kind="port"} 1` + "\n"))
+		})
+
+		It("reports the time to ready", func() {
+			Expect(output()).To(MatchRegexp(`waitfor_check_time_to_ready_seconds{check="db",kind="port"} 0\.0\d+`))
+		})
+	})
+
+	Context("when the check has not succeeded yet", func() {
+		BeforeEach(func() {
+			check()
+		})
+
+		It("reports the check as down", func() {
+			Expect(output()).To(ContainSubstring(`waitfor_check_up{check="db",kind="port"} 0` + "\n"))
+		})
+
+		It("does not report a time to ready", func() {
+			Expect(output()).ToNot(ContainSubstring(`waitfor_check_time_to_ready_seconds{`))
+		})
+	})
+
+	It("escapes label values", func() {
+		observer.Observe("a \"quoted\"\\name\n", "sh", func() bool { return true })()
+		Expect(output()).To(ContainSubstring(`waitfor_check_up{check="a \"quoted\"\\name\n",kind="sh"} 1`))
+	})
+
+	It("serves metrics over HTTP", func() {
+		check()
+
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/metrics", nil)
+		Expect(err).ToNot(HaveOccurred())
+		observer.ServeHTTP(recorder, req)
+
+		Expect(recorder.Header().Get("Content-Type")).To(Equal("text/plain; version=0.0.4"))
+		Expect(recorder.Body.String()).To(Equal(output()))
+	})
+})
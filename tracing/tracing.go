@@ -0,0 +1,172 @@
+// Package tracing records OpenTelemetry spans for waits. Every wait gets a
+// span of its own and every attempt to evaluate one of its checks becomes a
+// child span of the wait.
+package tracing
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor"
+)
+
+const (
+	CommandKey = attribute.Key("waitfor.command")
+	KindKey    = attribute.Key("waitfor.check.kind")
+	TargetKey  = attribute.Key("waitfor.check.target")
+	AttemptKey = attribute.Key("waitfor.attempt")
+	OutcomeKey = attribute.Key("waitfor.outcome")
+	ErrorKey   = attribute.Key("waitfor.error")
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+type Wait struct {
+	ctx    context.Context
+	span   trace.Span
+	tracer trace.Tracer
+}
+
+// Start opens the span of a wait as a child of the span in the given context,
+// if any.
+func Start(ctx context.Context, tracer trace.Tracer, command string) *Wait {
+	ctx, span := tracer.Start(ctx, "waitfor "+command, trace.WithAttributes(CommandKey.String(command)))
+
+	return &Wait{
+		ctx:    ctx,
+		span:   span,
+		tracer: tracer,
+	}
+}
+
+// Target returns the recorder for the attempts of a single check of the wait.
+func (w *Wait) Target(kind, target string) *Target {
+	return &Target{
+		wait:   w,
+		kind:   kind,
+		target: target,
+		ctx:    w.ctx,
+	}
+}
+
+// End records the outcome of the wait and closes its span.
+func (w *Wait) End(err error) {
+	end(w.span, err)
+}
+
+// Target records the attempts to evaluate a single check. It implements
+// check.Tracer, which allows checks to propagate the context of the current
+// attempt and to report errors. It also implements io.Writer, the last line
+// logged by a failed attempt is recorded as its error unless the check
+// reported one itself.
+type Target struct {
+	wait   *Wait
+	kind   string
+	target string
+
+	mutex    sync.Mutex
+	ctx      context.Context
+	attempts int
+	reported bool
+	logged   string
+}
+
+// Check wraps the given check, recording a span for every evaluation.
+func (t *Target) Check(check waitfor.Check) waitfor.Check {
+	return func() bool {
+		t.mutex.Lock()
+		t.attempts++
+		ctx, span := t.wait.tracer.Start(t.wait.ctx, "attempt", trace.WithAttributes(
+			KindKey.String(t.kind),
+			TargetKey.String(t.target),
+			AttemptKey.Int(t.attempts),
+		))
+		t.ctx = ctx
+		t.reported = false
+		t.logged = ""
+		t.mutex.Unlock()
+
+		ok := check()
+
+		t.mutex.Lock()
+		t.ctx = t.wait.ctx
+		reported, logged := t.reported, t.logged
+		t.mutex.Unlock()
+
+		switch {
+		case ok:
+			end(span, nil)
+		case reported:
+			span.SetAttributes(OutcomeKey.String(OutcomeFailure))
+			span.End()
+		case logged != "":
+			end(span, errors.New(logged))
+		default:
+			end(span, errors.New("check failed"))
+		}
+
+		return ok
+	}
+}
+
+// Inject adds the W3C trace context of the current attempt to the headers of
+// the given request.
+func (t *Target) Inject(req *http.Request) {
+	propagation.TraceContext{}.Inject(t.context(), propagation.HeaderCarrier(req.Header))
+}
+
+// Error records the given error on the current attempt.
+func (t *Target) Error(err error) {
+	span := trace.SpanFromContext(t.context())
+	span.RecordError(err)
+	span.SetAttributes(ErrorKey.String(err.Error()))
+	span.SetStatus(codes.Error, err.Error())
+
+	t.mutex.Lock()
+	t.reported = true
+	t.mutex.Unlock()
+}
+
+// Write keeps the last non-empty line written by the current attempt.
+func (t *Target) Write(p []byte) (int, error) {
+	lines := strings.Split(string(p), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			t.mutex.Lock()
+			t.logged = line
+			t.mutex.Unlock()
+			break
+		}
+	}
+
+	return len(p), nil
+}
+
+func (t *Target) context() context.Context {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.ctx
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetAttributes(OutcomeKey.String(OutcomeFailure), ErrorKey.String(err.Error()))
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(OutcomeKey.String(OutcomeSuccess))
+	}
+
+	span.End()
+}
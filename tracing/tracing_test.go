@@ -0,0 +1,178 @@
+package tracing_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/net/context"
+
+	"github.com/st3v/waitfor/tracing"
+)
+
+var _ = Describe("Wait", func() {
+	var (
+		recorder *tracetest.SpanRecorder
+		wait     *tracing.Wait
+		target   *tracing.Target
+		results  []bool
+		check    func() bool
+	)
+
+	attributes := func(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		attrs := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		return attrs
+	}
+
+	BeforeEach(func() {
+		recorder = tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+		wait = tracing.Start(context.Background(), provider.Tracer("test"), "port")
+		target = wait.Target("port", "tcp://localhost:5432")
+		results = []bool{false, true}
+
+		check = target.Check(func() bool {
+			result := results[0]
+			results = results[1:]
+			return result
+		})
+	})
+
+	It("passes through the result of the check", func() {
+		Expect(check()).To(BeFalse())
+		Expect(check()).To(BeTrue())
+	})
+
+	It("records a span for every attempt", func() {
+		check()
+		check()
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(2))
+
+		for i, span := range spans {
+			Expect(span.Name()).To(Equal("attempt"))
+
+			attrs := attributes(span)
+			Expect(attrs[tracing.KindKey].AsString()).To(Equal("port"))
+			Expect(attrs[tracing.TargetKey].AsString()).To(Equal("tcp://localhost:5432"))
+			Expect(attrs[tracing.AttemptKey].AsInt64()).To(BeEquivalentTo(i + 1))
+		}
+
+		Expect(attributes(spans[0])[tracing.OutcomeKey].AsString()).To(Equal(tracing.OutcomeFailure))
+		Expect(attributes(spans[1])[tracing.OutcomeKey].AsString()).To(Equal(tracing.OutcomeSuccess))
+	})
+
+	It("records attempts as children of the wait", func() {
+		check()
+		wait.End(nil)
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(2))
+
+		attempt, parent := spans[0], spans[1]
+		Expect(parent.Name()).To(Equal("waitfor port"))
+		Expect(attempt.Parent().SpanID()).To(Equal(parent.SpanContext().SpanID()))
+		Expect(attempt.SpanContext().TraceID()).To(Equal(parent.SpanContext().TraceID()))
+	})
+
+	It("records the outcome of the wait", func() {
+		wait.End(nil)
+
+		span := recorder.Ended()[0]
+		Expect(attributes(span)[tracing.CommandKey].AsString()).To(Equal("port"))
+		Expect(attributes(span)[tracing.OutcomeKey].AsString()).To(Equal(tracing.OutcomeSuccess))
+	})
+
+	It("records the error of a failed wait", func() {
+		wait.End(errors.New("timeout exceeded"))
+
+		span := recorder.Ended()[0]
+		Expect(attributes(span)[tracing.OutcomeKey].AsString()).To(Equal(tracing.OutcomeFailure))
+		Expect(attributes(span)[tracing.ErrorKey].AsString()).To(Equal("timeout exceeded"))
+		Expect(span.Status().Code).To(Equal(codes.Error))
+	})
+
+	It("records errors reported during an attempt", func() {
+		check = target.Check(func() bool {
+			target.Error(errors.New("connection refused"))
+			return false
+		})
+		check()
+
+		span := recorder.Ended()[0]
+		Expect(attributes(span)[tracing.ErrorKey].AsString()).To(Equal("connection refused"))
+		Expect(span.Status().Code).To(Equal(codes.Error))
+	})
+
+	It("records the last line logged by a failed attempt as its error", func() {
+		check = target.Check(func() bool {
+			fmt.Fprintln(target, "Dialing tcp://localhost:5432")
+			fmt.Fprintln(target, "dial tcp 127.0.0.1:5432: connect: connection refused")
+			return false
+		})
+		check()
+
+		span := recorder.Ended()[0]
+		Expect(attributes(span)[tracing.OutcomeKey].AsString()).To(Equal(tracing.OutcomeFailure))
+		Expect(attributes(span)[tracing.ErrorKey].AsString()).To(Equal("dial tcp 127.0.0.1:5432: connect: connection refused"))
+		Expect(span.Status().Code).To(Equal(codes.Error))
+	})
+
+	It("prefers errors reported during an attempt over logged lines", func() {
+		check = target.Check(func() bool {
+			target.Error(errors.New("connection refused"))
+			fmt.Fprintln(target, "Retrying")
+			return false
+		})
+		check()
+
+		span := recorder.Ended()[0]
+		Expect(attributes(span)[tracing.ErrorKey].AsString()).To(Equal("connection refused"))
+	})
+
+	It("records an error for failed attempts that logged nothing", func() {
+		check()
+
+		span := recorder.Ended()[0]
+		Expect(attributes(span)[tracing.ErrorKey].AsString()).To(Equal("check failed"))
+	})
+
+	It("does not carry logged lines over to the next attempt", func() {
+		fmt.Fprintln(target, "connection refused")
+		check()
+		check()
+
+		spans := recorder.Ended()
+		Expect(attributes(spans[0])[tracing.ErrorKey].AsString()).To(Equal("check failed"))
+		Expect(attributes(spans[1])).ToNot(HaveKey(tracing.ErrorKey))
+	})
+
+	It("injects the context of the current attempt into requests", func() {
+		var header string
+
+		check = target.Check(func() bool {
+			req, err := http.NewRequest("GET", "http://localhost", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			target.Inject(req)
+			header = req.Header.Get("traceparent")
+			return true
+		})
+		check()
+
+		span := recorder.Ended()[0]
+		Expect(header).To(Equal("00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"))
+	})
+})
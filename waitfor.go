@@ -2,51 +2,163 @@ package waitfor
 
 import (
 	"errors"
+	"sync"
 	"time"
 
 	"golang.org/x/net/context"
 )
 
-var ErrTimeoutExceeded = errors.New("timeout exceeded")
+var (
+	ErrTimeoutExceeded = errors.New("timeout exceeded")
+	ErrCanceled        = errors.New("canceled")
+)
 
 type Check func() bool
 
 func ConditionWithTimeout(condition Check, interval, timeout time.Duration) error {
-	errChan := make(chan error)
-	ctx, _ := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return ConditionWithContext(condition, interval, ctx)
+}
+
+// ConditionWithContext waits for the condition to become true until the
+// context is done, e.g. because it has been canceled or its deadline passed.
+// It returns once an attempt still being evaluated at that point has returned.
+func ConditionWithContext(condition Check, interval time.Duration, ctx context.Context) error {
+	errChan := make(chan error, 1)
 	go Condition(condition, interval, errChan, ctx)
 
 	select {
 	case err := <-errChan:
 		return handleErr(err)
 	case <-ctx.Done():
+		<-errChan
 		return handleErr(ctx.Err())
 	}
 }
 
 func Condition(condition Check, interval time.Duration, errChan chan error, ctx context.Context) {
-	if condition() {
-		errChan <- nil
-		return
+	errChan <- Watch(condition, interval, func(ok bool) bool {
+		return !ok
+	}, ctx)
+}
+
+// Watch evaluates the condition right away and then once per interval,
+// passing each result to the given observer. It returns nil as soon as the
+// observer returns false, or the context's error once the context is done.
+func Watch(condition Check, interval time.Duration, observer func(bool) bool, ctx context.Context) error {
+	if !observer(condition()) {
+		return nil
 	}
 
 	for {
 		select {
 		case <-ctx.Done():
-			errChan <- ctx.Err()
-			return
+			return ctx.Err()
 		case <-time.After(interval):
-			if condition() {
-				errChan <- nil
-				return
+			if !observer(condition()) {
+				return nil
 			}
 		}
 	}
 }
 
+// AllWithTimeout concurrently waits for all conditions to become true within
+// a single shared timeout. The returned slice contains one error per
+// condition, in the same order as the given conditions.
+func AllWithTimeout(conditions []Check, interval, timeout time.Duration) []error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return AllWithContext(conditions, interval, ctx)
+}
+
+// AllWithContext is like AllWithTimeout but waits until the given context is
+// done instead.
+func AllWithContext(conditions []Check, interval time.Duration, ctx context.Context) []error {
+	return conditionsWithContext(conditions, len(conditions), interval, ctx)
+}
+
+// AnyWithTimeout concurrently waits for at least one of the conditions to
+// become true within the given timeout. Conditions that were still pending
+// when the first one succeeded are reported as ErrCanceled.
+func AnyWithTimeout(conditions []Check, interval, timeout time.Duration) []error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return AnyWithContext(conditions, interval, ctx)
+}
+
+// AnyWithContext is like AnyWithTimeout but waits until the given context is
+// done instead.
+func AnyWithContext(conditions []Check, interval time.Duration, ctx context.Context) []error {
+	return conditionsWithContext(conditions, 1, interval, ctx)
+}
+
+type result struct {
+	index int
+	err   error
+}
+
+// conditionsWithContext returns once the outcome is decided and all pending
+// conditions have been stopped, i.e. none of them is still being evaluated.
+func conditionsWithContext(conditions []Check, required int, interval time.Duration, ctx context.Context) []error {
+	errs := make([]error, len(conditions))
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	results := make(chan result, len(conditions))
+	for i, condition := range conditions {
+		wg.Add(1)
+		go func(i int, condition Check) {
+			defer wg.Done()
+			errChan := make(chan error, 1)
+			Condition(condition, interval, errChan, ctx)
+			results <- result{i, <-errChan}
+		}(i, condition)
+	}
+
+	pending := make(map[int]bool, len(conditions))
+	for i := range conditions {
+		pending[i] = true
+	}
+
+	var err error
+	for succeeded := 0; len(pending) > 0 && err == nil; {
+		select {
+		case r := <-results:
+			delete(pending, r.index)
+			errs[r.index] = handleErr(r.err)
+			if r.err == nil {
+				succeeded++
+			}
+			if succeeded >= required {
+				err = context.Canceled
+			}
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	for i := range pending {
+		errs[i] = handleErr(err)
+	}
+
+	return errs
+}
+
 func handleErr(err error) error {
-	if err == context.DeadlineExceeded {
+	switch err {
+	case context.DeadlineExceeded:
 		return ErrTimeoutExceeded
+	case context.Canceled:
+		return ErrCanceled
 	}
 	return err
 }
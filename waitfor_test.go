@@ -103,6 +103,64 @@ var _ = Describe("waitfor", func() {
 		})
 	})
 
+	Describe(".ConditionWithContext", func() {
+		var (
+			ctx    context.Context
+			cancel context.CancelFunc
+			err    error
+		)
+
+		BeforeEach(func() {
+			ctx, cancel = context.WithCancel(context.Background())
+		})
+
+		JustBeforeEach(func() {
+			err = waitfor.ConditionWithContext(cond.Check, interval, ctx)
+		})
+
+		Context("when the context is canceled", func() {
+			BeforeEach(func() {
+				time.AfterFunc(5*interval, cancel)
+			})
+
+			It("returns a corresponding error", func() {
+				Expect(err).To(MatchError(waitfor.ErrCanceled))
+			})
+		})
+
+		Context("when the context is canceled during an attempt", func() {
+			BeforeEach(func() {
+				cancel()
+			})
+
+			It("waits for the attempt to return", func() {
+				returned := false
+				err := waitfor.ConditionWithContext(func() bool {
+					time.Sleep(5 * interval)
+					returned = true
+					return false
+				}, interval, ctx)
+
+				Expect(err).To(MatchError(waitfor.ErrCanceled))
+				Expect(returned).To(BeTrue())
+			})
+		})
+
+		Context("when the check succeeds", func() {
+			BeforeEach(func() {
+				cond.SetResult(true)
+			})
+
+			AfterEach(func() {
+				cancel()
+			})
+
+			It("does not return an error", func() {
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+	})
+
 	Describe(".Condition", func() {
 		var (
 			ctx     context.Context
@@ -152,4 +210,150 @@ var _ = Describe("waitfor", func() {
 			})
 		})
 	})
+
+	Describe(".Watch", func() {
+		var (
+			ctx      context.Context
+			cancel   context.CancelFunc
+			results  chan bool
+			errChan  chan error
+			observed int
+		)
+
+		BeforeEach(func() {
+			ctx, cancel = context.WithCancel(context.Background())
+			results = make(chan bool, 100)
+			errChan = make(chan error, 1)
+			observed = 0
+		})
+
+		AfterEach(func() {
+			cancel()
+		})
+
+		It("passes every result to the observer until the context is done", func() {
+			go func() {
+				errChan <- waitfor.Watch(cond.Check, interval, func(ok bool) bool {
+					results <- ok
+					return true
+				}, ctx)
+			}()
+
+			Eventually(results).Should(Receive(BeFalse()))
+			cond.SetResult(true)
+			Eventually(results).Should(Receive(BeTrue()))
+
+			cancel()
+			Eventually(errChan).Should(Receive(MatchError(context.Canceled)))
+		})
+
+		It("stops as soon as the observer returns false", func() {
+			err := waitfor.Watch(cond.Check, interval, func(ok bool) bool {
+				observed++
+				return observed < 3
+			}, ctx)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cond.CheckCount()).To(Equal(3))
+		})
+	})
+
+	Describe(".AllWithTimeout", func() {
+		var (
+			errs    []error
+			other   condition
+			timeout = 100 * time.Millisecond
+		)
+
+		BeforeEach(func() {
+			other = condition{}
+		})
+
+		JustBeforeEach(func() {
+			errs = waitfor.AllWithTimeout([]waitfor.Check{cond.Check, other.Check}, interval, timeout)
+		})
+
+		Context("when all checks succeed", func() {
+			BeforeEach(func() {
+				cond.SetResult(true)
+				other.SetResult(true)
+			})
+
+			It("does not return any errors", func() {
+				Expect(errs).To(Equal([]error{nil, nil}))
+			})
+		})
+
+		Context("when one check does not succeed", func() {
+			BeforeEach(func() {
+				cond.SetResult(true)
+			})
+
+			It("returns a timeout error for the failing check only", func() {
+				Expect(errs).To(HaveLen(2))
+				Expect(errs[0]).ToNot(HaveOccurred())
+				Expect(errs[1]).To(MatchError(waitfor.ErrTimeoutExceeded))
+			})
+
+			It("checks the conditions concurrently", func() {
+				Expect(other.CheckCount()).To(BeNumerically("<", 11))
+				Expect(other.CheckCount()).To(BeNumerically(">", 3))
+			})
+		})
+	})
+
+	Describe(".AnyWithTimeout", func() {
+		var (
+			errs    []error
+			other   condition
+			timeout = 100 * time.Millisecond
+		)
+
+		BeforeEach(func() {
+			other = condition{}
+		})
+
+		JustBeforeEach(func() {
+			errs = waitfor.AnyWithTimeout([]waitfor.Check{cond.Check, other.Check}, interval, timeout)
+		})
+
+		Context("when one check succeeds eventually", func() {
+			BeforeEach(func() {
+				go func() {
+					<-time.After(timeout / 2)
+					other.SetResult(true)
+				}()
+			})
+
+			It("does not return an error for the successful check", func() {
+				Expect(errs[1]).ToNot(HaveOccurred())
+			})
+
+			It("cancels the remaining checks", func() {
+				Expect(errs[0]).To(MatchError(waitfor.ErrCanceled))
+			})
+
+			It("stops the remaining checks before returning", func() {
+				count := cond.CheckCount()
+				Consistently(cond.CheckCount, 5*interval, interval/2).Should(Equal(count))
+			})
+		})
+
+		Context("when no check succeeds", func() {
+			It("returns timeout errors", func() {
+				Expect(errs).To(Equal([]error{waitfor.ErrTimeoutExceeded, waitfor.ErrTimeoutExceeded}))
+			})
+		})
+	})
+
+	Describe(".AllWithContext", func() {
+		It("returns canceled errors for pending checks once the context is canceled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(5*interval, cancel)
+
+			other := condition{result: true}
+			errs := waitfor.AllWithContext([]waitfor.Check{cond.Check, other.Check}, interval, ctx)
+			Expect(errs).To(Equal([]error{waitfor.ErrCanceled, nil}))
+		})
+	})
 })